@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+)
+
+// ApiConfig -
+type ApiConfig struct {
+	Bind   string `validate:"required"       yaml:"bind"`
+	MaxLag uint64 `validate:"omitempty,min=0" yaml:"max_lag"`
+}
+
+// Api - HTTP server of the indexer
+type Api struct {
+	server *http.Server
+	mux    *http.ServeMux
+	wg     *sync.WaitGroup
+}
+
+// NewApi -
+func NewApi(cfg ApiConfig) *Api {
+	mux := http.NewServeMux()
+	return &Api{
+		server: &http.Server{
+			Addr:              cfg.Bind,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		mux: mux,
+		wg:  new(sync.WaitGroup),
+	}
+}
+
+// Handle - registers handler for the pattern. Should be called before Start.
+func (api *Api) Handle(pattern string, handler http.HandlerFunc) {
+	api.mux.HandleFunc(pattern, handler)
+}
+
+// Start -
+func (api *Api) Start() {
+	api.wg.Add(1)
+	go func() {
+		defer api.wg.Done()
+
+		log.Info().Str("bind", api.server.Addr).Msg("starting HTTP API...")
+		if err := api.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Err(err).Msg("HTTP API")
+		}
+	}()
+}
+
+// Close -
+func (api *Api) Close() error {
+	if err := api.server.Close(); err != nil {
+		return err
+	}
+	api.wg.Wait()
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Err(err).Msg("write response")
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newArchiveCmd() *cobra.Command {
+	archiveCmd := &cobra.Command{
+		Use:   "archive",
+		Short: "Record event archives for offline indexing",
+	}
+
+	archiveCmd.AddCommand(&cobra.Command{
+		Use:   "record FILE",
+		Short: "Run the indexer and write messages of the data source to archive file",
+		Long: `Run the indexer and write messages of the data source to archive file.
+
+Messages are written as newline-delimited JSON, files with .gz extension are compressed.
+The file is truncated on start. Blocks repeated by the data source after reconnection are
+written once. The network selected by --network flag (the first one by default) is recorded.
+Index the archive later by setting 'archive.path' instead of 'grpc' section.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(cmd)
+			if err != nil {
+				return err
+			}
+			network, err := cmd.Flags().GetString("network")
+			if err != nil {
+				return err
+			}
+			runIndexer(cfg, &recording{
+				Network: network,
+				Path:    args[0],
+			})
+			return nil
+		},
+	})
+	return archiveCmd
+}
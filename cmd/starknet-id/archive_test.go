@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/archive"
+	"github.com/dipdup-io/starknet-id/internal/fakeindexer"
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/stretchr/testify/require"
+)
+
+func sqliteDatabase(t *testing.T, name string) config.Database {
+	return config.Database{
+		Kind: config.DBKindSqlite,
+		Path: filepath.Join(t.TempDir(), name),
+	}
+}
+
+func waitRunnerHeight(t *testing.T, ctx context.Context, runner *networkRunner, height uint64) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		state, err := runner.db.Backend().State.ByName(ctx, defaultNetworkChannel)
+		return err == nil && state.LastHeight >= height
+	}, 30*time.Second, 100*time.Millisecond)
+}
+
+func startRunner(t *testing.T, ctx context.Context, cfg Config, path string) *networkRunner {
+	t.Helper()
+	instances, err := cfg.instances()
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	runner, err := newNetworkRunner(ctx, cfg, instances[0], nil)
+	require.NoError(t, err)
+	if path != "" {
+		require.NoError(t, runner.record(path))
+	}
+	require.NoError(t, runner.Start(ctx, make(chan error, 1)))
+	return runner
+}
+
+func TestArchive_RecordAndIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson.gz")
+
+	server, err := fakeindexer.New()
+	require.NoError(t, err)
+	defer server.Close()
+
+	messages, err := stream.Load("testdata/replay/handlers/stream.yml")
+	require.NoError(t, err)
+
+	// record the live stream while indexing it
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	live := Config{
+		GRPC:    &grpc.ClientConfig{ServerAddress: server.Address()},
+		Network: &NetworkConfig{Preset: "mainnet"},
+	}
+	live.Database = sqliteDatabase(t, "live.db")
+	recorded := startRunner(t, ctx, live, path)
+
+	sub, err := server.WaitSubscription(ctx)
+	require.NoError(t, err)
+	require.NoError(t, server.Send(sub.Id, messages...))
+	waitRunnerHeight(t, ctx, recorded, 100)
+
+	liveDomain, err := recorded.db.Backend().Domains.GetByName(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	liveAddressId, err := recorded.db.Backend().Addresses.LastID(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, recorded.Stop(ctx))
+	cancel()
+	require.NoError(t, recorded.Close())
+
+	// rebuild another database from the archive
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	offline := Config{
+		Archive: &archive.Config{Path: path},
+		Network: &NetworkConfig{Preset: "mainnet"},
+	}
+	offline.Database = sqliteDatabase(t, "offline.db")
+	rebuilt := startRunner(t, ctx, offline, "")
+	waitRunnerHeight(t, ctx, rebuilt, 100)
+
+	domain, err := rebuilt.db.Backend().Domains.GetByName(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	require.Equal(t, liveDomain.Owner.String(), domain.Owner.String())
+	require.Equal(t, liveDomain.AddressId, domain.AddressId)
+	require.Equal(t, liveDomain.Expiry.Unix(), domain.Expiry.Unix())
+
+	addressId, err := rebuilt.db.Backend().Addresses.LastID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, liveAddressId, addressId)
+	require.False(t, rebuilt.IsFailed(defaultNetworkChannel))
+
+	require.NoError(t, rebuilt.Stop(ctx))
+	cancel()
+	require.NoError(t, rebuilt.Close())
+}
+
+func TestNetworkRunner_Record(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cfg := Config{
+		Archive: &archive.Config{Path: filepath.Join(t.TempDir(), "events.ndjson")},
+		Network: &NetworkConfig{Preset: "mainnet"},
+	}
+	cfg.Database = sqliteDatabase(t, "starknet-id.db")
+	instances, err := cfg.instances()
+	require.NoError(t, err)
+
+	runner, err := newNetworkRunner(ctx, cfg, instances[0], nil)
+	require.NoError(t, err)
+	defer runner.Close()
+
+	require.Error(t, runner.record(filepath.Join(t.TempDir(), "copy.ndjson")), "archive can't be recorded from archive")
+}
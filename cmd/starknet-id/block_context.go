@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // BlockContext -
@@ -22,13 +24,16 @@ type BlockContext struct {
 
 	domains            *syncMap[string, *storage.Domain]
 	transferredDomains *syncMap[string, *storage.Domain]
+	clearedDomains     *syncMap[string, *storage.Domain]
 	starknetIds        *syncMap[string, *TypeWithAction[*storage.StarknetId]]
 	fields             *syncMap[string, *storage.Field]
 	addresses          *syncMap[string, *storage.Address]
 	subdomains         *syncMap[string, *storage.Subdomain]
+	equippedInfts      *syncMap[string, *storage.StarknetId]
 
 	addressRepo   storage.IAddress
 	subdomainsMap map[string]string
+	rootDomain    string
 
 	state *storage.State
 }
@@ -36,18 +41,22 @@ type BlockContext struct {
 func newBlockContext(
 	subdomainRepo storage.ISubdomain,
 	addressRepo storage.IAddress,
-	subdomainsMap map[string]string,
+	network starknetid.Network,
+	metrics *Metrics,
 ) *BlockContext {
 	return &BlockContext{
-		cache:              NewCache(subdomainRepo),
+		cache:              NewCache(subdomainRepo, network.RootDomain, metrics),
 		domains:            newSyncMap[string, *storage.Domain](),
 		transferredDomains: newSyncMap[string, *storage.Domain](),
+		clearedDomains:     newSyncMap[string, *storage.Domain](),
 		starknetIds:        newSyncMap[string, *TypeWithAction[*storage.StarknetId]](),
 		fields:             newSyncMap[string, *storage.Field](),
 		addresses:          newSyncMap[string, *storage.Address](),
 		subdomains:         newSyncMap[string, *storage.Subdomain](),
+		equippedInfts:      newSyncMap[string, *storage.StarknetId](),
 		addressRepo:        addressRepo,
-		subdomainsMap:      subdomainsMap,
+		subdomainsMap:      network.Subdomains(),
+		rootDomain:         network.RootDomain,
 		state:              new(storage.State),
 	}
 }
@@ -56,18 +65,22 @@ func (bc *BlockContext) isEmpty() bool {
 	return bc.domains.Len() == 0 &&
 		bc.fields.Len() == 0 &&
 		bc.transferredDomains.Len() == 0 &&
+		bc.clearedDomains.Len() == 0 &&
 		bc.addresses.Len() == 0 &&
 		bc.starknetIds.Len() == 0 &&
-		bc.subdomains.Len() == 0
+		bc.subdomains.Len() == 0 &&
+		bc.equippedInfts.Len() == 0
 }
 
 func (bc *BlockContext) reset() {
 	bc.domains.Reset()
 	bc.transferredDomains.Reset()
+	bc.clearedDomains.Reset()
 	bc.starknetIds.Reset()
 	bc.fields.Reset()
 	bc.addresses.Reset()
 	bc.subdomains.Reset()
+	bc.equippedInfts.Reset()
 }
 
 func (bc *BlockContext) findAddress(ctx context.Context, hash []byte) (*storage.Address, error) {
@@ -108,7 +121,7 @@ func (bc *BlockContext) getFullDomainName(ctx context.Context, domains []data.Fe
 	parts = append(parts, subdomain)
 
 	if ok {
-		parts = append(parts, rootDomain)
+		parts = append(parts, bc.rootDomain)
 	}
 
 	return strings.Join(parts, "."), nil
@@ -127,13 +140,18 @@ func (bc *BlockContext) decodeDomainName(domains []data.Felt) ([]string, error)
 }
 
 func (bc *BlockContext) addDomains(ctx context.Context, domains []data.Felt, address data.Felt, contract storage.Address) error {
-	hash := address.Bytes()
-	addr, err := bc.findAddress(ctx, hash)
+	domain, err := bc.getFullDomainName(ctx, domains, contract)
 	if err != nil {
 		return err
 	}
 
-	domain, err := bc.getFullDomainName(ctx, domains, contract)
+	hash := address.Bytes()
+	if bytes.Equal(hash, ZeroAddress) {
+		return bc.clearDomain(domain)
+	}
+	bc.clearedDomains.Delete(domain)
+
+	addr, err := bc.findAddress(ctx, hash)
 	if err != nil {
 		return err
 	}
@@ -152,12 +170,24 @@ func (bc *BlockContext) addDomains(ctx context.Context, domains []data.Felt, add
 	return nil
 }
 
+// clearDomain - zero address removes the record: the domain doesn't resolve until the next update
+func (bc *BlockContext) clearDomain(domain string) error {
+	if item, ok := bc.domains.Get(domain); ok {
+		item.AddressHash = nil
+		item.AddressId = 0
+	}
+	bc.clearedDomains.Set(domain, &storage.Domain{
+		Domain: domain,
+	})
+	return nil
+}
+
 func (bc *BlockContext) applyStaknetIdUpdate(update starknetid.StarknetIdUpdate) error {
 	parts, err := bc.decodeDomainName(update.Domain)
 	if err != nil {
 		return err
 	}
-	parts = append(parts, rootDomain)
+	parts = append(parts, bc.rootDomain)
 	domain := strings.Join(parts, ".")
 
 	expiry, err := update.Expiry.Uint64()
@@ -183,7 +213,7 @@ func (bc *BlockContext) applyDomainTransfer(update starknetid.DomainTransfer) er
 	if err != nil {
 		return err
 	}
-	parts = append(parts, rootDomain)
+	parts = append(parts, bc.rootDomain)
 	domain := strings.Join(parts, ".")
 	bc.transferredDomains.Set(domain, &storage.Domain{
 		Domain: domain,
@@ -285,13 +315,35 @@ func (bc *BlockContext) addTransferedStarknetId(ctx context.Context, transfer st
 }
 
 func (bc *BlockContext) addField(update starknetid.VerifierDataUpdate) error {
-	starknetId := update.StarknetId.Decimal()
-	key := fmt.Sprintf("%s_%s_%d", starknetId.String(), update.Field.String(), storage.FieldNamespaceVerifier)
-	bc.fields.Set(key, &storage.Field{
+	return bc.setField(update.StarknetId, update.Field, update.Data, storage.FieldNamespaceVerifier)
+}
+
+func (bc *BlockContext) addUserField(update starknetid.UserDataUpdate) error {
+	return bc.setField(update.StarknetId, update.Field, update.Data, storage.FieldNamespaceUser)
+}
+
+func (bc *BlockContext) setField(id, name, value data.Felt, namespace storage.FieldNamespace) error {
+	starknetId := id.Decimal()
+	key := fmt.Sprintf("%s_%s_%d", starknetId.String(), name.String(), namespace)
+	field := &storage.Field{
 		OwnerId:   starknetId,
-		Namespace: storage.FieldNamespaceVerifier,
-		Name:      update.Field.ToAsciiString(),
-		Value:     encoding.MustDecodeHex(update.Data.String()),
+		Namespace: namespace,
+		Name:      name.ToAsciiString(),
+		Value:     encoding.MustDecodeHex(value.String()),
+	}
+	if err := decodeField(field); err != nil {
+		return err
+	}
+	bc.fields.Set(key, field)
+	return nil
+}
+
+func (bc *BlockContext) equipInft(update starknetid.OnInftEquipped) error {
+	starknetId := update.StarknetId.Decimal()
+	bc.equippedInfts.Set(starknetId.String(), &storage.StarknetId{
+		StarknetId:   starknetId,
+		InftContract: update.InftContract.Bytes(),
+		InftId:       decimal.NewNullDecimal(update.InftId.Decimal()),
 	})
 	return nil
 }
@@ -306,6 +358,10 @@ func (bc *BlockContext) addAddress(address *pb.Address) {
 	})
 }
 
+func (bc *BlockContext) setBlockTime(timestamp uint64) {
+	bc.state.LastBlockTime = time.Unix(int64(timestamp), 0).UTC()
+}
+
 func (bc *BlockContext) updateState(name string, height uint64) {
 	bc.state.LastHeight = height
 	bc.state.LastTime = time.Now().UTC()
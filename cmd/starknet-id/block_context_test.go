@@ -1,10 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBlockContext_decodeDomainName(t *testing.T) {
@@ -39,3 +48,129 @@ func TestBlockContext_decodeDomainName(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockContext_findAddress(t *testing.T) {
+	ctx := context.Background()
+	strg := memory.New()
+	saveBlock(t, strg, func(tx storage.Transaction) error {
+		return tx.SaveAddress(ctx, &storage.Address{Id: 16, Hash: []byte{0x16}})
+	})
+
+	bc := newBlockContext(strg.Subdomains, strg.Addresses, starknetid.Mainnet, nil)
+	bc.addAddress(&pb.Address{Id: 14, Hash: []byte{0x14}})
+
+	tests := []struct {
+		name string
+		hash []byte
+		want uint64
+	}{
+		{name: "stored", hash: []byte{0x16}, want: 16},
+		{name: "in block", hash: []byte{0x14}, want: 14},
+		{name: "unknown", hash: []byte{0x1}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := bc.findAddress(ctx, tt.hash)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, address.Id)
+			require.Equal(t, tt.hash, address.Hash)
+		})
+	}
+}
+
+func TestBlockContext_getFullDomainName(t *testing.T) {
+	ctx := context.Background()
+	strg := memory.New()
+	saveBlock(t, strg, func(tx storage.Transaction) error {
+		return tx.SaveSubdomain(ctx, &storage.Subdomain{Subdomain: "deployer", ResolverId: 20})
+	})
+
+	bc := newBlockContext(strg.Subdomains, strg.Addresses, starknetid.Mainnet, nil)
+	braavos := data.Felt(starknetid.AddressBraavos).Bytes()
+
+	tests := []struct {
+		name     string
+		contract storage.Address
+		want     string
+	}{
+		{name: "naming contract", contract: storage.Address{Id: 2}, want: "fricoben.stark"},
+		{name: "preset resolver", contract: storage.Address{Id: 3, Hash: braavos}, want: "fricoben.braavos.stark"},
+		{name: "registered resolver", contract: storage.Address{Id: 20}, want: "fricoben.deployer.stark"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domain, err := bc.getFullDomainName(ctx, []data.Felt{"0x15d246f6c1b"}, tt.contract)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, domain)
+		})
+	}
+}
+
+func TestBlockContext_addDomains(t *testing.T) {
+	var (
+		alice   = data.Felt("0xa1")
+		bob     = data.Felt("0xb0")
+		zero    = data.Felt("0x0")
+		naming  = storage.Address{Id: 2}
+		domain  = []data.Felt{"0x15d246f6c1b"}
+		expiry  = time.Now().Add(time.Hour).UTC()
+		updates = func(addresses ...data.Felt) [][]data.Felt {
+			blocks := make([][]data.Felt, len(addresses))
+			for i := range addresses {
+				blocks[i] = []data.Felt{addresses[i]}
+			}
+			return blocks
+		}
+	)
+
+	tests := []struct {
+		name   string
+		blocks [][]data.Felt
+		want   []byte
+	}{
+		{name: "set", blocks: updates(alice), want: alice.Bytes()},
+		{name: "cleared in the next block", blocks: updates(alice, zero), want: nil},
+		{name: "cleared and set again in the next block", blocks: updates(alice, zero, bob), want: bob.Bytes()},
+		{name: "set and cleared in the same block", blocks: [][]data.Felt{{alice}, {bob, zero}}, want: nil},
+		{name: "cleared and set in the same block", blocks: [][]data.Felt{{alice}, {zero, bob}}, want: bob.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			strg := memory.New()
+			store := NewStore(strg, zerolog.Nop())
+			bc := newBlockContext(strg.Subdomains, strg.Addresses, starknetid.Mainnet, nil)
+
+			require.NoError(t, bc.applyStaknetIdUpdate(starknetid.StarknetIdUpdate{
+				Domain: domain,
+				Owner:  data.Felt("0x1"),
+				Expiry: data.Felt(fmt.Sprintf("0x%x", expiry.Unix())),
+			}))
+			for i, block := range tt.blocks {
+				for _, address := range block {
+					require.NoError(t, bc.addDomains(ctx, domain, address, naming))
+				}
+				bc.updateState("starknet_id", uint64(100+i))
+				require.NoError(t, store.Save(ctx, bc))
+			}
+
+			stored, err := strg.Domains.GetByName(ctx, "fricoben.stark")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, stored.AddressHash)
+			require.Equal(t, "1", stored.Owner.String(), "owner is kept")
+		})
+	}
+}
+
+// saveBlock - saves data to the storage in single transaction
+func saveBlock(t *testing.T, strg *memory.Storage, f func(tx storage.Transaction) error) {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := strg.BeginTransaction(ctx)
+	require.NoError(t, err)
+	defer tx.Close(ctx)
+
+	require.NoError(t, f(tx))
+	require.NoError(t, tx.Flush(ctx))
+}
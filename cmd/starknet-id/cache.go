@@ -14,35 +14,41 @@ type Cache struct {
 	*ccache.Cache
 
 	subdomains storage.ISubdomain
+	rootDomain string
+	metrics    *Metrics
 }
 
 // NewCache -
-func NewCache(subdomains storage.ISubdomain) *Cache {
+func NewCache(subdomains storage.ISubdomain, rootDomain string, metrics *Metrics) *Cache {
 	return &Cache{
 		Cache:      ccache.New(ccache.Configure().MaxSize(1000)),
 		subdomains: subdomains,
+		rootDomain: rootDomain,
+		metrics:    metrics,
 	}
 }
 
 // SetSubdomain -
 func (c *Cache) SetSubdomain(resolverId uint64, domain string) {
-	c.Set(fmt.Sprintf("subdomain:%d", resolverId), domain+"."+rootDomain, time.Hour)
+	c.Set(fmt.Sprintf("subdomain:%d", resolverId), domain+"."+c.rootDomain, time.Hour)
 }
 
-const rootDomain = "stark"
-
 // GetSubdomain -
 func (c *Cache) GetSubdomain(ctx context.Context, resolverId uint64) (string, error) {
-	value, err := c.Fetch(fmt.Sprintf("subdomain:%d", resolverId), time.Hour, func() (interface{}, error) {
+	key := fmt.Sprintf("subdomain:%d", resolverId)
+	hit := true
+	value, err := c.Fetch(key, time.Hour, func() (interface{}, error) {
+		hit = false
 		sd, err := c.subdomains.GetByResolverId(ctx, resolverId)
 		if err != nil {
 			if c.subdomains.IsNoRows(err) {
-				return rootDomain, nil
+				return c.rootDomain, nil
 			}
 			return "", err
 		}
-		return sd.Subdomain + "." + rootDomain, nil
+		return sd.Subdomain + "." + c.rootDomain, nil
 	})
+	c.metrics.IncCacheRequest("subdomain", hit)
 	if err != nil {
 		return "", err
 	}
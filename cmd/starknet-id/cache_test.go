@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetSubdomain(t *testing.T) {
+	ctx := context.Background()
+	strg := memory.New()
+	saveBlock(t, strg, func(tx storage.Transaction) error {
+		return tx.SaveSubdomain(ctx, &storage.Subdomain{Subdomain: "deployer", ResolverId: 20})
+	})
+
+	cache := NewCache(strg.Subdomains, "stark", nil)
+	cache.SetSubdomain(21, "coinify")
+
+	tests := []struct {
+		name       string
+		resolverId uint64
+		want       string
+	}{
+		{name: "stored", resolverId: 20, want: "deployer.stark"},
+		{name: "set in block", resolverId: 21, want: "coinify.stark"},
+		{name: "naming contract", resolverId: 1, want: "stark"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subdomain, err := cache.GetSubdomain(ctx, tt.resolverId)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, subdomain)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// StarknetIdChange -
+type StarknetIdChange struct {
+	Action     Action
+	StarknetId storage.StarknetId
+}
+
+// BlockChanges - data committed to the database by channel at the end of block. It's pushed to indexer's output.
+type BlockChanges struct {
+	Channel            string
+	Height             uint64
+	Addresses          []storage.Address
+	StarknetIds        []StarknetIdChange
+	Domains            []storage.Domain
+	TransferredDomains []storage.Domain
+	ClearedDomains     []storage.Domain
+	Subdomains         []storage.Subdomain
+	Fields             []storage.Field
+	EquippedInfts      []storage.StarknetId
+}
+
+// IsEmpty -
+func (changes *BlockChanges) IsEmpty() bool {
+	return len(changes.Addresses) == 0 &&
+		len(changes.StarknetIds) == 0 &&
+		len(changes.Domains) == 0 &&
+		len(changes.TransferredDomains) == 0 &&
+		len(changes.ClearedDomains) == 0 &&
+		len(changes.Subdomains) == 0 &&
+		len(changes.Fields) == 0 &&
+		len(changes.EquippedInfts) == 0
+}
+
+func (bc *BlockContext) changes() *BlockChanges {
+	changes := &BlockChanges{
+		Channel:            bc.state.Name,
+		Height:             bc.state.LastHeight,
+		Addresses:          make([]storage.Address, 0, bc.addresses.Len()),
+		StarknetIds:        make([]StarknetIdChange, 0, bc.starknetIds.Len()),
+		Domains:            make([]storage.Domain, 0, bc.domains.Len()),
+		TransferredDomains: make([]storage.Domain, 0, bc.transferredDomains.Len()),
+		ClearedDomains:     make([]storage.Domain, 0, bc.clearedDomains.Len()),
+		Subdomains:         make([]storage.Subdomain, 0, bc.subdomains.Len()),
+		Fields:             make([]storage.Field, 0, bc.fields.Len()),
+		EquippedInfts:      make([]storage.StarknetId, 0, bc.equippedInfts.Len()),
+	}
+
+	_ = bc.addresses.Range(func(_ string, value *storage.Address) (bool, error) {
+		changes.Addresses = append(changes.Addresses, *value)
+		return false, nil
+	})
+	_ = bc.starknetIds.Range(func(_ string, value *TypeWithAction[*storage.StarknetId]) (bool, error) {
+		changes.StarknetIds = append(changes.StarknetIds, StarknetIdChange{
+			Action:     value.Action,
+			StarknetId: *value.Data,
+		})
+		return false, nil
+	})
+	_ = bc.domains.Range(func(_ string, value *storage.Domain) (bool, error) {
+		changes.Domains = append(changes.Domains, *value)
+		return false, nil
+	})
+	_ = bc.transferredDomains.Range(func(_ string, value *storage.Domain) (bool, error) {
+		changes.TransferredDomains = append(changes.TransferredDomains, *value)
+		return false, nil
+	})
+	_ = bc.clearedDomains.Range(func(_ string, value *storage.Domain) (bool, error) {
+		changes.ClearedDomains = append(changes.ClearedDomains, *value)
+		return false, nil
+	})
+	_ = bc.subdomains.Range(func(_ string, value *storage.Subdomain) (bool, error) {
+		changes.Subdomains = append(changes.Subdomains, *value)
+		return false, nil
+	})
+	_ = bc.fields.Range(func(_ string, value *storage.Field) (bool, error) {
+		changes.Fields = append(changes.Fields, *value)
+		return false, nil
+	})
+	_ = bc.equippedInfts.Range(func(_ string, value *storage.StarknetId) (bool, error) {
+		changes.EquippedInfts = append(changes.EquippedInfts, *value)
+		return false, nil
+	})
+
+	return changes
+}
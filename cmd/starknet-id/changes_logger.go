@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+)
+
+// ChangesLogger - example of the module which receives committed changes from indexer's output and logs them.
+type ChangesLogger struct {
+	modules.BaseModule
+}
+
+var _ modules.Module = (*ChangesLogger)(nil)
+
+// NewChangesLogger -
+func NewChangesLogger(network string) *ChangesLogger {
+	m := &ChangesLogger{
+		BaseModule: modules.New("changes_logger"),
+	}
+	m.Log = m.Log.With().Str("network", network).Logger()
+	m.CreateInput(InputName)
+	return m
+}
+
+// Start -
+func (m *ChangesLogger) Start(ctx context.Context) {
+	m.G.GoCtx(ctx, m.listen)
+}
+
+// Close - gracefully stops module
+func (m *ChangesLogger) Close() error {
+	m.G.Wait()
+	return nil
+}
+
+func (m *ChangesLogger) listen(ctx context.Context) {
+	input := m.MustInput(InputName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-input.Listen():
+			if !ok {
+				return
+			}
+
+			changes, ok := msg.(*BlockChanges)
+			if !ok {
+				m.Log.Warn().Msgf("unknown message: %T", msg)
+				continue
+			}
+
+			for i := range changes.Domains {
+				m.Log.Debug().
+					Str("channel", changes.Channel).
+					Uint64("height", changes.Height).
+					Str("domain", changes.Domains[i].Domain).
+					Str("address", hex.EncodeToString(changes.Domains[i].AddressHash)).
+					Msg("domain changed")
+			}
+			for i := range changes.ClearedDomains {
+				m.Log.Debug().
+					Str("channel", changes.Channel).
+					Uint64("height", changes.Height).
+					Str("domain", changes.ClearedDomains[i].Domain).
+					Msg("domain address cleared")
+			}
+			m.Log.Info().
+				Str("channel", changes.Channel).
+				Uint64("height", changes.Height).
+				Int("domains", len(changes.Domains)+len(changes.TransferredDomains)+len(changes.ClearedDomains)).
+				Int("starknet_ids", len(changes.StarknetIds)).
+				Int("fields", len(changes.Fields)).
+				Int("subdomains", len(changes.Subdomains)).
+				Msg("committed changes")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockContext_changes(t *testing.T) {
+	bc := newBlockContext(nil, nil, starknetid.Mainnet, nil)
+
+	err := bc.applyStaknetIdUpdate(starknetid.StarknetIdUpdate{
+		Domain: []data.Felt{data.Felt("0x15d246f6c1b")},
+		Owner:  data.Felt("0x1"),
+		Expiry: data.Felt("0x64b3b2d0"),
+	})
+	require.NoError(t, err)
+
+	err = bc.addField(starknetid.VerifierDataUpdate{
+		StarknetId: data.Felt("0x1"),
+		Field:      data.Felt("0x74776974746572"),
+		Data:       data.Felt("0x2a"),
+	})
+	require.NoError(t, err)
+
+	err = bc.addUserField(starknetid.UserDataUpdate{
+		StarknetId: data.Felt("0x1"),
+		Field:      data.Felt("0x74776974746572"),
+		Data:       data.Felt("0x2b"),
+	})
+	require.NoError(t, err)
+
+	bc.addAddress(&pb.Address{
+		Id:     10,
+		Hash:   []byte{0x01},
+		Height: 100,
+	})
+	bc.updateState("test", 100)
+
+	changes := bc.changes()
+	require.False(t, changes.IsEmpty())
+	require.Equal(t, "test", changes.Channel)
+	require.EqualValues(t, 100, changes.Height)
+	require.Len(t, changes.Domains, 1)
+	require.Equal(t, "fricoben.stark", changes.Domains[0].Domain)
+	require.Len(t, changes.Fields, 2, "fields of different namespaces must not override each other")
+	slices.SortFunc(changes.Fields, func(a, b storage.Field) int {
+		return cmp.Compare(a.Namespace, b.Namespace)
+	})
+	require.Equal(t, storage.FieldNamespaceVerifier, changes.Fields[0].Namespace)
+	require.Equal(t, "twitter", changes.Fields[0].Name)
+	require.Equal(t, "42", changes.Fields[0].TextValue)
+	require.Equal(t, "42", changes.Fields[0].NumericValue.Decimal.String())
+	require.Equal(t, storage.FieldNamespaceUser, changes.Fields[1].Namespace)
+	require.Equal(t, "twitter", changes.Fields[1].Name)
+	require.Equal(t, []byte{0x2b}, changes.Fields[1].Value)
+	require.Empty(t, changes.Fields[1].TextValue, "user data isn't decoded")
+	require.Len(t, changes.Addresses, 1)
+	require.EqualValues(t, 10, changes.Addresses[0].Id)
+	require.Empty(t, changes.StarknetIds)
+
+	bc.reset()
+	require.Len(t, changes.Domains, 1, "changes must not depend on block context after reset")
+	require.True(t, bc.changes().IsEmpty())
+}
+
+func TestIndexer_Output(t *testing.T) {
+	indexer := NewIndexer(storage.Backend{}, nil, starknetid.Mainnet, nil)
+
+	sink := modules.New("sink")
+	sink.CreateInput(InputName)
+	require.NoError(t, modules.Connect(indexer, &sink, OutputName, InputName))
+
+	channel := NewChannel("test", storage.Backend{}, starknetid.Mainnet, indexer.MustOutput(OutputName), nil)
+	channel.notify(&BlockChanges{Channel: "test", Height: 1})
+	channel.notify(&BlockChanges{
+		Channel: "test",
+		Height:  2,
+		Domains: []storage.Domain{{Domain: "fricoben.stark"}},
+	})
+
+	select {
+	case msg := <-sink.MustInput(InputName).Listen():
+		changes, ok := msg.(*BlockChanges)
+		require.True(t, ok)
+		require.EqualValues(t, 2, changes.Height)
+		require.Len(t, changes.Domains, 1)
+	case <-time.After(time.Second):
+		t.Fatal("changes were not pushed to output")
+	}
+
+	require.Empty(t, sink.MustInput(InputName).Listen())
+}
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/goccy/go-json"
 
 	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
 	"github.com/dipdup-io/starknet-id/internal/storage"
-	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
 	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,22 +25,30 @@ type EventHandler func(ctx context.Context, blockCtx *BlockContext, event *pb.Ev
 type Channel struct {
 	name          string
 	blockCtx      *BlockContext
-	storage       postgres.Storage
+	storage       storage.Backend
 	eventHandlers map[string]EventHandler
 	store         Store
-	failed        bool
+	output        *modules.Output
+	metrics       *Metrics
+	log           zerolog.Logger
+	failed        *atomic.Bool
 	ch            chan *pb.Subscription
 	wg            *sync.WaitGroup
 }
 
 // NewChannel -
-func NewChannel(name string, pg postgres.Storage, subdomainsMap map[string]string) Channel {
+func NewChannel(name string, backend storage.Backend, network starknetid.Network, output *modules.Output, metrics *Metrics) Channel {
+	logger := log.With().Str("network", network.Name).Logger()
 	ch := Channel{
 		name:     name,
-		storage:  pg,
-		blockCtx: newBlockContext(pg.Subdomains, pg.Addresses, subdomainsMap),
-		store:    NewStore(pg),
+		storage:  backend,
+		blockCtx: newBlockContext(backend.Subdomains, backend.Addresses, network, metrics),
+		store:    NewStore(backend, logger),
+		output:   output,
+		metrics:  metrics,
+		log:      logger,
 		ch:       make(chan *pb.Subscription, 1024*1024),
+		failed:   new(atomic.Bool),
 		wg:       new(sync.WaitGroup),
 	}
 
@@ -48,7 +59,9 @@ func NewChannel(name string, pg postgres.Storage, subdomainsMap map[string]strin
 		starknetid.EventStarknetIdUpdate:       ch.parseStarknetIdUpdate,
 		starknetid.EventDomainTransfer:         ch.parseTransferDomain,
 		starknetid.EventVerifierDataUpdate:     ch.parseVerifierDataUpdate,
+		starknetid.EventUserDataUpdate:         ch.parseUserDataUpdate,
 		starknetid.EventDomainToResolverUpdate: ch.parseDomainToResolverUpdate,
+		starknetid.EventOnInftEquipped:         ch.parseOnInftEquipped,
 	}
 
 	return ch
@@ -77,54 +90,76 @@ func (channel Channel) listen(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case msg := <-channel.ch:
-			if channel.failed {
+			channel.metrics.SetChannelBuffer(channel.name, len(channel.ch))
+			if channel.failed.Load() {
 				continue
 			}
-			response := msg.GetResponse()
-			switch {
-			case msg.EndOfBlock != nil:
-				log.Info().
-					Uint64("subscription", response.GetId()).
-					Uint64("height", msg.EndOfBlock.Height).
-					Str("channel", channel.name).
-					Msg("end of block")
-
-				channel.blockCtx.updateState(channel.name, msg.EndOfBlock.Height)
-				if err := channel.store.Save(ctx, channel.blockCtx); err != nil {
-					log.Err(err).Msg("saving data")
-					channel.failed = true
-				}
-
-			case msg.Event != nil:
-
-				if err := channel.parseEvent(ctx, msg.Event); err != nil {
-					log.Err(err).Msg("event parsing")
-				}
-
-				log.Debug().
-					Str("name", msg.Event.Name).
-					Uint64("height", msg.Event.Height).
-					Uint64("time", msg.Event.Time).
-					Uint64("id", msg.Event.Id).
-					Uint64("subscription", msg.Response.Id).
-					Str("channel", channel.name).
-					Msg("new event")
-
-			case msg.Address != nil:
-				if err := channel.parseAddress(msg.Address); err != nil {
-					log.Err(err).Msg("event parsing")
-				}
-				log.Debug().
-					Uint64("height", msg.Address.Height).
-					Uint64("id", msg.Address.Id).
-					Uint64("subscription", msg.Response.Id).
-					Str("channel", channel.name).
-					Msg("new address")
+			if err := channel.process(ctx, msg); err != nil {
+				channel.log.Err(err).Str("channel", channel.name).Msg("processing message")
 			}
 		}
 	}
 }
 
+// process - applies a single message of the subscription. Saving error stops the channel,
+// event handling error is returned but doesn't stop it.
+func (channel Channel) process(ctx context.Context, msg *pb.Subscription) error {
+	response := msg.GetResponse()
+	switch {
+	case msg.EndOfBlock != nil:
+		channel.log.Info().
+			Uint64("subscription", response.GetId()).
+			Uint64("height", msg.EndOfBlock.Height).
+			Str("channel", channel.name).
+			Msg("end of block")
+
+		channel.blockCtx.updateState(channel.name, msg.EndOfBlock.Height)
+		changes := channel.blockCtx.changes()
+		since := time.Now()
+		if err := channel.store.Save(ctx, channel.blockCtx); err != nil {
+			channel.failed.Store(true)
+			return errors.Wrap(err, "saving data")
+		}
+		channel.metrics.ObserveSave(channel.name, time.Since(since))
+		channel.metrics.SetHead(channel.name, msg.EndOfBlock.Height, channel.blockCtx.state.LastBlockTime)
+		channel.notify(changes)
+
+	case msg.Block != nil:
+		channel.blockCtx.setBlockTime(msg.Block.Time)
+
+	case msg.Event != nil:
+		channel.blockCtx.setBlockTime(msg.Event.Time)
+		channel.metrics.IncEvent(channel.name, msg.Event.Name)
+
+		channel.log.Debug().
+			Str("name", msg.Event.Name).
+			Uint64("height", msg.Event.Height).
+			Uint64("time", msg.Event.Time).
+			Uint64("id", msg.Event.Id).
+			Uint64("subscription", response.GetId()).
+			Str("channel", channel.name).
+			Msg("new event")
+
+		if err := channel.parseEvent(ctx, msg.Event); err != nil {
+			channel.metrics.IncHandlerError(channel.name, msg.Event.Name)
+			return errors.Wrapf(err, "event parsing: %s %d", msg.Event.Name, msg.Event.Id)
+		}
+
+	case msg.Address != nil:
+		channel.log.Debug().
+			Uint64("height", msg.Address.Height).
+			Uint64("id", msg.Address.Id).
+			Uint64("subscription", response.GetId()).
+			Str("channel", channel.name).
+			Msg("new address")
+
+		if err := channel.parseAddress(msg.Address); err != nil {
+			return errors.Wrap(err, "address parsing")
+		}
+	}
+	return nil
+}
+
 // Close -
 func (channel Channel) Close() error {
 	channel.wg.Wait()
@@ -138,11 +173,23 @@ func (channel Channel) Name() string {
 	return channel.name
 }
 
+// IsFailed - returns true if channel stopped processing because of saving error
+func (channel Channel) IsFailed() bool {
+	return channel.failed.Load()
+}
+
 // State -
 func (channel Channel) State() *storage.State {
 	return channel.blockCtx.state
 }
 
+func (channel Channel) notify(changes *BlockChanges) {
+	if channel.output == nil || changes.IsEmpty() {
+		return
+	}
+	channel.output.Push(changes)
+}
+
 func (channel Channel) parseAddress(msg *pb.Address) error {
 	channel.blockCtx.addAddress(msg)
 	return nil
@@ -181,6 +228,11 @@ func (channel Channel) parseAddrToDomainUpdate(ctx context.Context, blockCtx *Bl
 	if err := json.Unmarshal(event.ParsedData, &data); err != nil {
 		return errors.Wrap(err, "parsing data")
 	}
+	// empty domain resets only reverse record of the address. Reverse records aren't stored,
+	// so forward records of domains are kept instead of saving the root domain of the contract.
+	if len(data.Domain) == 0 {
+		return nil
+	}
 	return blockCtx.addDomains(ctx, data.Domain, data.Address, storage.Address{
 		Id:   event.Contract.Id,
 		Hash: event.Contract.Hash,
@@ -223,6 +275,15 @@ func (channel Channel) parseVerifierDataUpdate(ctx context.Context, blockCtx *Bl
 	return blockCtx.addField(data)
 }
 
+func (channel Channel) parseUserDataUpdate(ctx context.Context, blockCtx *BlockContext, event *pb.Event) error {
+	var data starknetid.UserDataUpdate
+	if err := json.Unmarshal(event.ParsedData, &data); err != nil {
+		return errors.Wrap(err, "parsing data")
+	}
+
+	return blockCtx.addUserField(data)
+}
+
 func (channel Channel) parseDomainToResolverUpdate(ctx context.Context, blockCtx *BlockContext, event *pb.Event) error {
 	var data starknetid.DomainToResolverUpdate
 	if err := json.Unmarshal(event.ParsedData, &data); err != nil {
@@ -231,3 +292,12 @@ func (channel Channel) parseDomainToResolverUpdate(ctx context.Context, blockCtx
 
 	return blockCtx.addSubdomain(ctx, event, data)
 }
+
+func (channel Channel) parseOnInftEquipped(ctx context.Context, blockCtx *BlockContext, event *pb.Event) error {
+	var data starknetid.OnInftEquipped
+	if err := json.Unmarshal(event.ParsedData, &data); err != nil {
+		return errors.Wrap(err, "parsing data")
+	}
+
+	return blockCtx.equipInft(data)
+}
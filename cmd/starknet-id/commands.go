@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/snapshot"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/dipdup-io/starknet-id/internal/storage/sqlite"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// commandStorage - storage used by operational commands. It's replaced by stand-ins in tests.
+type commandStorage struct {
+	States    storage.IState
+	Domains   storage.IDomain
+	Rewinder  rewinder
+	Snapshots snapshot.Database // nil if snapshots aren't supported by the database
+	Close     func() error
+
+	// RootDomain - root domain of the configured network
+	RootDomain string
+}
+
+type storageConnector func(cmd *cobra.Command) (commandStorage, error)
+
+func newRootCmd(connect storageConnector, connectMigrator migratorConnector) *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:          "starknet-id",
+		Short:        "DipDup indexer for Starknet ID service",
+		SilenceUsage: true,
+		// starting without subcommand runs the indexer as before subcommands were added
+		RunE: runE,
+	}
+	rootCmd.PersistentFlags().StringP("config", "c", "dipdup.yml", "path to YAML config file")
+	rootCmd.PersistentFlags().String("network", "", "network from networks section to work with (the first one by default)")
+
+	rootCmd.AddCommand(
+		newRunCmd(),
+		newStatusCmd(connect),
+		newReindexCmd(connect),
+		newResolveCmd(connect),
+		newSnapshotCmd(connect),
+		newMigrateCmd(connectMigrator),
+		newArchiveCmd(),
+	)
+	return rootCmd
+}
+
+// loadConfig - parses config from `--config` flag and sets log level
+func loadConfig(cmd *cobra.Command) (Config, error) {
+	configPath, err := cmd.Flags().GetString("config")
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if err := config.Parse(configPath, &cfg); err != nil {
+		return cfg, err
+	}
+
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = zerolog.LevelInfoValue
+	}
+	logLevel, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return cfg, err
+	}
+	zerolog.SetGlobalLevel(logLevel)
+	return cfg, nil
+}
+
+// loadInstance - parses config and returns the network selected by `--network` flag
+func loadInstance(cmd *cobra.Command) (instance, error) {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return instance{}, err
+	}
+	name, err := cmd.Flags().GetString("network")
+	if err != nil {
+		return instance{}, err
+	}
+	return cfg.instance(name)
+}
+
+// connectStorage - connects to the database of the network from config without applying migrations
+func connectStorage(cmd *cobra.Command) (commandStorage, error) {
+	inst, err := loadInstance(cmd)
+	if err != nil {
+		return commandStorage{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), time.Minute)
+	defer cancel()
+
+	if inst.Database.Kind == config.DBKindSqlite {
+		strg, err := sqlite.Create(ctx, inst.Database)
+		if err != nil {
+			return commandStorage{}, err
+		}
+		return commandStorage{
+			States:     strg.State,
+			Domains:    strg.Domains,
+			Rewinder:   strg,
+			Close:      strg.Close,
+			RootDomain: inst.Network.RootDomain,
+		}, nil
+	}
+
+	pg, err := postgres.Connect(ctx, inst.Database)
+	if err != nil {
+		return commandStorage{}, err
+	}
+	return commandStorage{
+		States:     pg.State,
+		Domains:    pg.Domains,
+		Rewinder:   pg,
+		Snapshots:  pg,
+		Close:      pg.Close,
+		RootDomain: inst.Network.RootDomain,
+	}, nil
+}
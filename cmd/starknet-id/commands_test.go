@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+type testRewinder struct {
+	channels []string
+	height   uint64
+	calls    int
+}
+
+func (tr *testRewinder) Rewind(ctx context.Context, channels []string, height uint64) error {
+	tr.channels = channels
+	tr.height = height
+	tr.calls++
+	return nil
+}
+
+func testConnector(strg commandStorage) storageConnector {
+	return func(cmd *cobra.Command) (commandStorage, error) {
+		if strg.Close == nil {
+			strg.Close = func() error { return nil }
+		}
+		return strg, nil
+	}
+}
+
+func executeCommand(cmd *cobra.Command, args ...string) (string, error) {
+	output := new(bytes.Buffer)
+	cmd.SetOut(output)
+	cmd.SetErr(output)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.Background())
+	return output.String(), err
+}
+
+func TestRootCmd_Subcommands(t *testing.T) {
+	root := newRootCmd(testConnector(commandStorage{}), nil)
+
+	names := make([]string, 0)
+	for _, cmd := range root.Commands() {
+		names = append(names, cmd.Name())
+	}
+	require.Subset(t, names, []string{"run", "status", "reindex", "resolve", "snapshot", "migrate", "archive"})
+}
+
+func TestRunCmd_InvalidConfig(t *testing.T) {
+	root := newRootCmd(testConnector(commandStorage{}), nil)
+	_, err := executeCommand(root, "run", "-c", "unknown.yml")
+	require.Error(t, err)
+}
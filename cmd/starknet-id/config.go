@@ -1,6 +1,8 @@
 package main
 
 import (
+	"github.com/dipdup-io/starknet-id/internal/archive"
+	"github.com/dipdup-io/starknet-id/internal/jsonrpc"
 	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
 	"github.com/dipdup-net/go-lib/config"
 )
@@ -10,8 +12,16 @@ type Config struct {
 	config.Config `yaml:",inline"`
 
 	LogLevel   string             `validate:"omitempty,oneof=debug trace info warn error fatal panic" yaml:"log_level"`
-	GRPC       *grpc.ClientConfig `validate:"required"                                                yaml:"grpc"`
-	Subdomains map[string]string  `validate:"required"                                                yaml:"subdomains"`
+	GRPC       *grpc.ClientConfig `validate:"omitempty"                                               yaml:"grpc"`
+	RPC        *jsonrpc.Config    `validate:"omitempty"                                               yaml:"rpc"`
+	Archive    *archive.Config    `validate:"omitempty"                                               yaml:"archive"`
+	Subdomains map[string]string  `validate:"omitempty"                                               yaml:"subdomains"`
+	Network    *NetworkConfig     `validate:"omitempty"                                               yaml:"network"`
+	Networks   []InstanceConfig   `validate:"omitempty,dive"                                          yaml:"networks"`
+	Api        *ApiConfig         `validate:"omitempty"                                               yaml:"api"`
+	Dns        *DnsConfig         `validate:"omitempty"                                               yaml:"dns"`
+	HA         *HAConfig          `validate:"omitempty"                                               yaml:"ha"`
+	Views      string             `validate:"omitempty,dir"                                           yaml:"views"`
 }
 
 // Substitute -
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/dipdup-io/starknet-id/internal/storage/sqlite"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/pkg/errors"
+)
+
+// Database - storage of the network. Its kind is set by `kind` field of database config.
+type Database interface {
+	Backend() storage.Backend
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// openDatabase - opens storage of the network. Postgres storage is returned separately because HA mode, views management
+// and Hasura work with Postgres only, it's nil for SQLite. Replicas in HA mode connect to Postgres without migrations.
+func openDatabase(ctx context.Context, cfg Config, inst instance) (Database, *postgres.Storage, error) {
+	switch inst.Database.Kind {
+	case config.DBKindSqlite:
+		if err := checkSqlite(cfg); err != nil {
+			return nil, nil, err
+		}
+		strg, err := sqlite.Create(ctx, inst.Database)
+		if err != nil {
+			return nil, nil, err
+		}
+		return strg, nil, nil
+	case config.DBKindPostgres:
+		var (
+			pg  postgres.Storage
+			err error
+		)
+		if cfg.HA == nil {
+			pg, err = postgres.Create(ctx, inst.Database)
+		} else {
+			pg, err = postgres.Connect(ctx, inst.Database)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return pg, &pg, nil
+	default:
+		return nil, nil, errors.Errorf("unsupported database kind: %s", inst.Database.Kind)
+	}
+}
+
+// checkSqlite - returns error if features which require Postgres are configured
+func checkSqlite(cfg Config) error {
+	switch {
+	case cfg.HA != nil:
+		return errors.New("high availability mode requires postgres database")
+	case cfg.Hasura != nil:
+		return errors.New("hasura requires postgres database")
+	case cfg.Views != "":
+		return errors.New("custom views are supported by postgres database only")
+	default:
+		return nil
+	}
+}
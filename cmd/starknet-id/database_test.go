@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/identity"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenDatabase_Unsupported(t *testing.T) {
+	sqlite := config.Database{Kind: config.DBKindSqlite, Path: filepath.Join(t.TempDir(), "starknet-id.db")}
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		database config.Database
+	}{
+		{"sqlite with HA", Config{HA: &HAConfig{}}, sqlite},
+		{"sqlite with hasura", Config{Config: config.Config{Hasura: &config.Hasura{}}}, sqlite},
+		{"sqlite with custom views", Config{Views: t.TempDir()}, sqlite},
+		{"sqlite without path", Config{}, config.Database{Kind: config.DBKindSqlite}},
+		{"unknown kind", Config{}, config.Database{Kind: config.DBKindMysql}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := openDatabase(context.Background(), tt.cfg, instance{Database: tt.database})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestOpenDatabase_Sqlite(t *testing.T) {
+	ctx := context.Background()
+	alice := []byte{0xa1}
+
+	db, pg, err := openDatabase(ctx, Config{}, instance{
+		Database: config.Database{Kind: config.DBKindSqlite, Path: filepath.Join(t.TempDir(), "starknet-id.db")},
+	})
+	require.NoError(t, err)
+	require.Nil(t, pg)
+	defer db.Close()
+
+	require.NoError(t, db.Ping(ctx))
+
+	tx, err := db.Backend().BeginTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.SaveStarknetIds(ctx, &storage.StarknetId{StarknetId: decimal.NewFromInt(1), OwnerAddress: alice, OwnerId: 16}))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{
+		Domain:      "fricoben.stark",
+		Owner:       decimal.NewFromInt(1),
+		AddressHash: alice,
+		AddressId:   16,
+		Expiry:      time.Now().Add(time.Hour),
+	}))
+	require.NoError(t, tx.Flush(ctx))
+	require.NoError(t, tx.Close(ctx))
+
+	profiles := (&networkRunner{db: db}).Profiles()
+
+	recorder := httptest.NewRecorder()
+	profiles.Get(recorder, httptest.NewRequest(http.MethodGet, "/identity/1", nil))
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var profile identity.Profile
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &profile))
+	require.Equal(t, "1", profile.StarknetId)
+	require.Len(t, profile.Domains, 1)
+	require.Equal(t, "fricoben.stark", profile.Domains[0].Domain)
+
+	recorder = httptest.NewRecorder()
+	profiles.Get(recorder, httptest.NewRequest(http.MethodGet, "/identity/2", nil))
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/archive"
+	"github.com/dipdup-io/starknet-id/internal/jsonrpc"
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	grpcSDK "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc"
+)
+
+// DataSource - module which sends subscription messages of starknet-indexer (*pb.Subscription) to its `messages` output.
+// It's implemented by starknet-indexer gRPC client, by jsonrpc.Source polling Starknet node and by archive.Source
+// reading archive file.
+type DataSource interface {
+	modules.Module
+
+	Connect(ctx context.Context) error
+	Subscribe(ctx context.Context, req *pb.SubscribeRequest) (uint64, error)
+	Unsubscribe(ctx context.Context, id uint64) error
+	Reconnect() <-chan uint64
+}
+
+// grpcSource - starknet-indexer gRPC client
+type grpcSource struct {
+	*grpc.Client
+}
+
+// Connect - waits for gRPC server
+func (s grpcSource) Connect(ctx context.Context) error {
+	return s.Client.Connect(ctx,
+		grpcSDK.WaitServer(),
+		grpcSDK.WithUserAgent("starknet-id"),
+	)
+}
+
+// newDataSource - returns JSON-RPC source if `rpc` section is set, archive source if `archive` section is set
+// and gRPC client otherwise
+func newDataSource(inst instance, addresses models.IAddress) (DataSource, error) {
+	switch {
+	case inst.RPC != nil:
+		return jsonrpc.NewSource(*inst.RPC, addresses)
+	case inst.Archive != nil:
+		return archive.NewSource(*inst.Archive), nil
+	default:
+		return grpcSource{grpc.NewClient(inst.GRPC)}, nil
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/miekg/dns"
+	"github.com/rs/zerolog/log"
+)
+
+// DnsConfig - if several networks are indexed, names are resolved in the network (the first one by default)
+type DnsConfig struct {
+	Bind    string `validate:"required"       yaml:"bind"`
+	TTL     uint32 `validate:"omitempty,min=0" yaml:"ttl"`
+	Network string `validate:"omitempty"       yaml:"network"`
+}
+
+// DnsServer - resolves names of the root domain (.stark) with TXT records over UDP and TCP
+type DnsServer struct {
+	domains storage.IDomain
+	zone    string
+	ttl     uint32
+	servers []*dns.Server
+	wg      *sync.WaitGroup
+}
+
+// NewDnsServer -
+func NewDnsServer(cfg DnsConfig, domains storage.IDomain, rootDomain string) *DnsServer {
+	s := &DnsServer{
+		domains: domains,
+		zone:    dns.Fqdn(rootDomain),
+		ttl:     cfg.TTL,
+		wg:      new(sync.WaitGroup),
+	}
+	if s.ttl == 0 {
+		s.ttl = 60
+	}
+
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", s.handle)
+
+	s.servers = []*dns.Server{
+		{Addr: cfg.Bind, Net: "udp", Handler: mux},
+		{Addr: cfg.Bind, Net: "tcp", Handler: mux},
+	}
+	return s
+}
+
+// Start -
+func (s *DnsServer) Start() error {
+	for i := range s.servers {
+		started := make(chan struct{})
+		s.servers[i].NotifyStartedFunc = func() { close(started) }
+
+		errs := make(chan error, 1)
+		s.wg.Add(1)
+		go func(server *dns.Server) {
+			defer s.wg.Done()
+			if err := server.ListenAndServe(); err != nil {
+				errs <- err
+			}
+		}(s.servers[i])
+
+		select {
+		case <-started:
+			log.Info().Str("bind", s.servers[i].Addr).Str("net", s.servers[i].Net).Msg("DNS server started")
+		case err := <-errs:
+			return err
+		}
+	}
+	return nil
+}
+
+// Close -
+func (s *DnsServer) Close() error {
+	for i := range s.servers {
+		if err := s.servers[i].Shutdown(); err != nil {
+			return err
+		}
+	}
+	s.wg.Wait()
+	return nil
+}
+
+func (s *DnsServer) handle(w dns.ResponseWriter, r *dns.Msg) {
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	msg.Authoritative = true
+
+	if len(r.Question) != 1 {
+		msg.SetRcode(r, dns.RcodeFormatError)
+		s.write(w, msg)
+		return
+	}
+
+	question := r.Question[0]
+	name := strings.ToLower(question.Name)
+	if question.Qclass != dns.ClassINET || !dns.IsSubDomain(s.zone, name) || name == s.zone {
+		msg.SetRcode(r, dns.RcodeRefused)
+		s.write(w, msg)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	domain, err := s.domains.GetByName(ctx, strings.TrimSuffix(name, "."))
+	switch {
+	case err == nil:
+	case s.domains.IsNoRows(err):
+		msg.SetRcode(r, dns.RcodeNameError)
+		s.write(w, msg)
+		return
+	default:
+		log.Err(err).Str("name", name).Msg("DNS resolve")
+		msg.SetRcode(r, dns.RcodeServerFailure)
+		s.write(w, msg)
+		return
+	}
+
+	// the same condition as in `actual_domains` view
+	if !domain.EffectiveExpiry.After(time.Now()) || len(domain.AddressHash) == 0 {
+		msg.SetRcode(r, dns.RcodeNameError)
+		s.write(w, msg)
+		return
+	}
+
+	if question.Qtype == dns.TypeTXT || question.Qtype == dns.TypeANY {
+		msg.Answer = append(msg.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   question.Name,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+				Ttl:    s.ttl,
+			},
+			Txt: txtRecords(domain),
+		})
+	}
+
+	s.write(w, msg)
+}
+
+func (s *DnsServer) write(w dns.ResponseWriter, msg *dns.Msg) {
+	if err := w.WriteMsg(msg); err != nil {
+		log.Err(err).Msg("DNS write")
+	}
+}
+
+// txtRecords - returns strings of TXT record in `key=value` format (RFC 1464)
+func txtRecords(domain storage.Domain) []string {
+	records := make([]string, 0, 3)
+	if len(domain.AddressHash) > 0 {
+		records = append(records, "address="+encoding.EncodeHex(domain.AddressHash))
+	}
+	if !domain.Owner.IsZero() {
+		records = append(records, fmt.Sprintf("starknet_id=%s", domain.Owner.String()))
+	}
+	records = append(records, fmt.Sprintf("expiry=%s", domain.EffectiveExpiry.UTC().Format(time.RFC3339)))
+	return records
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+type testDomains struct {
+	storage.IDomain
+
+	domains map[string]storage.Domain
+}
+
+func (td testDomains) GetByName(ctx context.Context, name string) (storage.Domain, error) {
+	domain, ok := td.domains[name]
+	if !ok {
+		return storage.Domain{}, sql.ErrNoRows
+	}
+	return domain, nil
+}
+
+func (td testDomains) Count(ctx context.Context) (int, error) {
+	return len(td.domains), nil
+}
+
+func (td testDomains) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func TestDnsServer(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	domains := testDomains{
+		domains: map[string]storage.Domain{
+			"fricoben.stark": {
+				Domain:          "fricoben.stark",
+				AddressHash:     encoding.MustDecodeHex("0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8"),
+				Owner:           decimal.NewFromInt(1),
+				Expiry:          expiry,
+				EffectiveExpiry: expiry,
+			},
+			"deployer.fricoben.stark": {
+				Domain:          "deployer.fricoben.stark",
+				AddressHash:     []byte{0x02},
+				EffectiveExpiry: expiry,
+			},
+			"cleared.stark": {
+				Domain:          "cleared.stark",
+				Owner:           decimal.NewFromInt(3),
+				Expiry:          expiry,
+				EffectiveExpiry: expiry,
+			},
+			"expired.stark": {
+				Domain:          "expired.stark",
+				AddressHash:     []byte{0x01},
+				Owner:           decimal.NewFromInt(2),
+				Expiry:          time.Now().Add(-time.Hour),
+				EffectiveExpiry: time.Now().Add(-time.Hour),
+			},
+		},
+	}
+
+	address := freeAddress(t)
+	server := NewDnsServer(DnsConfig{Bind: address}, domains, "stark")
+	require.NoError(t, server.Start())
+	defer func() {
+		require.NoError(t, server.Close())
+	}()
+
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   uint16
+		net     string
+		rcode   int
+		answers []string
+	}{
+		{
+			name:  "resolve over udp",
+			qname: "fricoben.stark.",
+			qtype: dns.TypeTXT,
+			net:   "udp",
+			rcode: dns.RcodeSuccess,
+			answers: []string{
+				"address=0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8",
+				"starknet_id=1",
+				"expiry=" + expiry.Format(time.RFC3339),
+			},
+		}, {
+			name:  "resolve over tcp",
+			qname: "FriCoBen.stark.",
+			qtype: dns.TypeTXT,
+			net:   "tcp",
+			rcode: dns.RcodeSuccess,
+			answers: []string{
+				"address=0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8",
+				"starknet_id=1",
+				"expiry=" + expiry.Format(time.RFC3339),
+			},
+		}, {
+			name:  "other record type",
+			qname: "fricoben.stark.",
+			qtype: dns.TypeA,
+			net:   "udp",
+			rcode: dns.RcodeSuccess,
+		}, {
+			name:  "subdomain expires with parent",
+			qname: "deployer.fricoben.stark.",
+			qtype: dns.TypeTXT,
+			net:   "udp",
+			rcode: dns.RcodeSuccess,
+			answers: []string{
+				"address=0x02",
+				"expiry=" + expiry.Format(time.RFC3339),
+			},
+		}, {
+			name:  "expired",
+			qname: "expired.stark.",
+			qtype: dns.TypeTXT,
+			net:   "udp",
+			rcode: dns.RcodeNameError,
+		}, {
+			name:  "cleared address",
+			qname: "cleared.stark.",
+			qtype: dns.TypeTXT,
+			net:   "udp",
+			rcode: dns.RcodeNameError,
+		}, {
+			name:  "unknown",
+			qname: "unknown.stark.",
+			qtype: dns.TypeTXT,
+			net:   "udp",
+			rcode: dns.RcodeNameError,
+		}, {
+			name:  "other zone",
+			qname: "example.com.",
+			qtype: dns.TypeTXT,
+			net:   "udp",
+			rcode: dns.RcodeRefused,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := new(dns.Msg)
+			msg.SetQuestion(tt.qname, tt.qtype)
+
+			client := &dns.Client{Net: tt.net, Timeout: time.Second}
+			response, _, err := client.Exchange(msg, address)
+			require.NoError(t, err)
+			require.Equal(t, tt.rcode, response.Rcode)
+
+			if len(tt.answers) == 0 {
+				require.Empty(t, response.Answer)
+				return
+			}
+			require.Len(t, response.Answer, 1)
+			txt, ok := response.Answer[0].(*dns.TXT)
+			require.True(t, ok)
+			require.Equal(t, tt.answers, txt.Txt)
+			require.Equal(t, tt.qname, txt.Hdr.Name)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/fakeindexer"
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/stretchr/testify/suite"
+)
+
+// E2ETestSuite - runs the network wiring of the indexer against fake starknet-indexer
+type E2ETestSuite struct {
+	suite.Suite
+	psqlContainer *database.PostgreSQLContainer
+	database      config.Database
+	server        *fakeindexer.Server
+}
+
+// SetupSuite -
+func (s *E2ETestSuite) SetupSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	psqlContainer, cfg, err := newPostgresContainer(ctx)
+	s.Require().NoError(err)
+	s.psqlContainer = psqlContainer
+	s.database = cfg
+
+	server, err := fakeindexer.New()
+	s.Require().NoError(err)
+	s.server = server
+}
+
+// TearDownSuite -
+func (s *E2ETestSuite) TearDownSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	s.Require().NoError(s.server.Close())
+	s.Require().NoError(s.psqlContainer.Terminate(ctx))
+}
+
+func TestSuiteE2E_Run(t *testing.T) {
+	suite.Run(t, new(E2ETestSuite))
+}
+
+func (s *E2ETestSuite) TestIndexing() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cfg := Config{
+		GRPC: &grpc.ClientConfig{
+			ServerAddress: s.server.Address(),
+		},
+		Network: &NetworkConfig{
+			Preset: "mainnet",
+		},
+	}
+	cfg.Database = s.database
+	instances, err := cfg.instances()
+	s.Require().NoError(err)
+	s.Require().Len(instances, 1)
+
+	runner, err := newNetworkRunner(ctx, cfg, instances[0], nil)
+	s.Require().NoError(err)
+
+	errs := make(chan error, 1)
+	s.Require().NoError(runner.Start(ctx, errs))
+
+	sub, err := s.server.WaitSubscription(ctx)
+	s.Require().NoError(err)
+	s.Require().True(sub.Request.Head)
+	s.Require().Len(sub.Request.Events, len(instances[0].Network.List()))
+
+	messages, err := stream.Load("testdata/replay/handlers/stream.yml")
+	s.Require().NoError(err)
+	s.Require().NoError(s.server.Send(sub.Id, messages...))
+
+	s.waitHeight(ctx, runner, 100)
+	domain, err := runner.db.Backend().Domains.GetByName(ctx, "fricoben.stark")
+	s.Require().NoError(err)
+	s.Require().Equal("1", domain.Owner.String())
+
+	// indexer resubscribes from the last saved block and address after reconnect
+	s.Require().NoError(s.server.Disconnect())
+	resubscribed, err := s.server.WaitSubscription(ctx)
+	s.Require().NoError(err)
+	s.Require().EqualValues(100, resubscribed.Request.Events[0].Height.GetGt())
+	s.Require().EqualValues(16, resubscribed.Request.Addresses[0].Id.GetGt())
+
+	s.Require().NoError(s.server.Send(resubscribed.Id,
+		&pb.Subscription{
+			Event: &pb.Event{
+				Id:         7,
+				Height:     101,
+				Time:       1700000100,
+				Name:       "domain_transfer",
+				ParsedData: []byte(`{"domain_len":"0x1","domain":["0x15d246f6c1b"],"prev_owner":"0x1","new_owner":"0x3"}`),
+			},
+		},
+		&pb.Subscription{
+			EndOfBlock: &pb.EndOfBlock{Height: 101},
+		},
+	))
+
+	s.waitHeight(ctx, runner, 101)
+	domain, err = runner.db.Backend().Domains.GetByName(ctx, "fricoben.stark")
+	s.Require().NoError(err)
+	s.Require().Equal("3", domain.Owner.String())
+	s.Require().False(runner.IsFailed(defaultNetworkChannel))
+
+	s.Require().NoError(runner.Stop(ctx))
+	s.Require().Equal([]uint64{resubscribed.Id}, s.server.Unsubscribed())
+
+	cancel()
+	s.Require().NoError(runner.Close())
+	s.Require().Empty(errs)
+}
+
+func (s *E2ETestSuite) waitHeight(ctx context.Context, runner *networkRunner, height uint64) {
+	s.Require().Eventually(func() bool {
+		state, err := runner.db.Backend().State.ByName(ctx, defaultNetworkChannel)
+		return err == nil && state.LastHeight >= height
+	}, 30*time.Second, 100*time.Millisecond)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"slices"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// decodeField - sets normalized value of well-known field. Only verifiers' data is well-known: user data with the same name
+// is arbitrary, so it's kept raw.
+func decodeField(field *storage.Field) error {
+	if field.Namespace != storage.FieldNamespaceVerifier {
+		return nil
+	}
+	value, ok, err := starknetid.DecodeField(field.Name, field.Value)
+	if err != nil {
+		return errors.Wrapf(err, "decoding field %s", field.Name)
+	}
+	if ok {
+		field.TextValue = value.Text
+		field.NumericValue = value.Numeric
+	}
+	return nil
+}
+
+// decodeStoredFields - decodes well-known fields which were indexed before their decoders were added. Names of decoded fields
+// are stored, so fields are scanned only on the first start with a new decoder.
+func decodeStoredFields(ctx context.Context, fields storage.IField) error {
+	decoded, err := fields.ListDecoded(ctx)
+	if err != nil {
+		return errors.Wrap(err, "receiving decoded fields")
+	}
+	pending := make([]string, 0)
+	for _, name := range starknetid.FieldDecoderNames() {
+		if !slices.Contains(decoded, name) {
+			pending = append(pending, name)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var (
+		lastId  uint64
+		updated int
+	)
+	for {
+		items, err := fields.CursorList(ctx, lastId, 1000, sdk.SortOrderAsc, sdk.ComparatorGt)
+		if err != nil {
+			return errors.Wrap(err, "receiving fields")
+		}
+		for i := range items {
+			lastId = items[i].Id
+			if items[i].Namespace != storage.FieldNamespaceVerifier || items[i].TextValue != "" || !slices.Contains(pending, items[i].Name) {
+				continue
+			}
+			if err := decodeField(items[i]); err != nil {
+				return err
+			}
+			if err := fields.Update(ctx, items[i]); err != nil {
+				return errors.Wrap(err, "updating field")
+			}
+			updated++
+		}
+		if len(items) < 1000 {
+			break
+		}
+	}
+	if updated > 0 {
+		log.Info().Int("count", updated).Strs("fields", pending).Msg("decoded stored fields")
+	}
+	return fields.MarkDecoded(ctx, pending...)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeStoredFields(t *testing.T) {
+	ctx := context.Background()
+	strg := memory.New()
+	owner := decimal.NewFromInt(1)
+	saveBlock(t, strg, func(tx storage.Transaction) error {
+		for _, field := range []*storage.Field{
+			{OwnerId: owner, Namespace: storage.FieldNamespaceVerifier, Name: starknetid.FieldGithub, Value: []byte{0x04, 0xd2}},
+			{OwnerId: owner, Namespace: storage.FieldNamespaceUser, Name: "avatar", Value: []byte{0x01}},
+			{OwnerId: owner, Namespace: storage.FieldNamespaceUser, Name: starknetid.FieldGithub, Value: []byte("fricoben")},
+		} {
+			if err := tx.SaveField(ctx, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	require.NoError(t, decodeStoredFields(ctx, strg.Fields))
+
+	ids, err := strg.Fields.ListStarknetIdsByValue(ctx, starknetid.FieldGithub, "1234")
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+
+	// user data with name of well-known field isn't decoded
+	fields, err := strg.Fields.ListByOwner(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+	for i := range fields {
+		if fields[i].Namespace == storage.FieldNamespaceUser {
+			require.Empty(t, fields[i].TextValue, fields[i].Name)
+			require.False(t, fields[i].NumericValue.Valid, fields[i].Name)
+		}
+	}
+
+	decoded, err := strg.Fields.ListDecoded(ctx)
+	require.NoError(t, err)
+	require.Equal(t, starknetid.FieldDecoderNames(), decoded)
+
+	// fields of decoded names aren't decoded again
+	saveBlock(t, strg, func(tx storage.Transaction) error {
+		return tx.SaveField(ctx, &storage.Field{OwnerId: decimal.NewFromInt(2), Namespace: storage.FieldNamespaceVerifier, Name: starknetid.FieldGithub, Value: []byte{0x01}})
+	})
+	require.NoError(t, decodeStoredFields(ctx, strg.Fields))
+
+	ids, err = strg.Fields.ListStarknetIdsByValue(ctx, starknetid.FieldGithub, "1")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
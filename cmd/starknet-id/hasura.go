@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/go-lib/hasura"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// trackNetwork - adds hasura source of the network. Source is named after the network and reads the network schema,
+// its root fields are namespaced and type names are prefixed by the network name, so sources of several networks
+// don't conflict in one GraphQL schema.
+func trackNetwork(ctx context.Context, cfg *config.Hasura, inst instance, views []string) error {
+	api := hasura.New(cfg.URL, cfg.Secret)
+	for api.Health(ctx) != nil {
+		log.Info().Str("network", inst.Name).Msg("waiting hasura is up and running")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	for _, req := range hasuraSourceRequests(cfg, inst, views) {
+		if err := api.CustomConfiguration(ctx, req); err != nil && !isHasuraNoop(err) {
+			return errors.Wrapf(err, "%s %s", inst.Name, req.Type)
+		}
+	}
+	return nil
+}
+
+// isHasuraNoop - returns true if metadata already has the object or the dropped permission doesn't exist
+func isHasuraNoop(err error) bool {
+	e, ok := err.(hasura.APIError)
+	if !ok {
+		return false
+	}
+	return e.AlreadyExists() || e.PermissionDenied() || e.Code == "already-tracked" || strings.Contains(e.Text, "already tracked")
+}
+
+// hasuraSourceRequests - returns metadata requests which add the source of the network, track its tables, views and
+// search functions and grant select permissions to unauthorized role
+func hasuraSourceRequests(cfg *config.Hasura, inst instance, views []string) []hasura.Request {
+	source := inst.Name
+	schema := inst.Database.SchemaName
+	if schema == "" {
+		schema = "public"
+	}
+
+	isolationLevel := "read-committed"
+	usePreparedStatements := false
+	if cfg.Source != nil {
+		if cfg.Source.IsolationLevel != "" {
+			isolationLevel = cfg.Source.IsolationLevel
+		}
+		usePreparedStatements = cfg.Source.UsePreparedStatements
+	}
+
+	requests := []hasura.Request{
+		{
+			Type: "pg_add_source",
+			Args: map[string]any{
+				"name": source,
+				"configuration": hasura.Configuration{
+					ConnectionInfo: hasura.ConnectionInfo{
+						DatabaseUrl:           hasura.DatabaseUrl(hasuraDatabaseUrl(cfg, inst.Database)),
+						UsePreparedStatements: usePreparedStatements,
+						IsolationLevel:        isolationLevel,
+					},
+				},
+				"customization": map[string]any{
+					"root_fields": map[string]string{
+						"namespace": source,
+					},
+					"type_names": map[string]string{
+						"prefix": source + "_",
+					},
+				},
+				"replace_configuration": true,
+			},
+		},
+	}
+
+	tables := make([]string, 0, len(storage.Models)+len(views))
+	for i := range storage.Models {
+		tables = append(tables, storage.Models[i].TableName())
+	}
+	tables = append(tables, views...)
+
+	for _, table := range tables {
+		name := map[string]string{
+			"schema": schema,
+			"name":   table,
+		}
+		requests = append(requests,
+			hasura.Request{
+				Type: "pg_track_table",
+				Args: map[string]any{
+					"source": source,
+					"table":  name,
+				},
+			},
+			hasura.Request{
+				Type: "pg_drop_select_permission",
+				Args: map[string]any{
+					"source": source,
+					"table":  name,
+					"role":   cfg.UnauthorizedRole,
+				},
+			},
+			hasura.Request{
+				Type: "pg_create_select_permission",
+				Args: map[string]any{
+					"source": source,
+					"table":  name,
+					"role":   cfg.UnauthorizedRole,
+					"permission": hasura.Permission{
+						Columns:   hasura.Columns{"*"},
+						Limit:     cfg.RowsLimit,
+						AllowAggs: cfg.EnableAggregations,
+						Filter:    map[string]any{},
+					},
+				},
+			},
+		)
+	}
+
+	return append(requests, trackFunctions(source, schema)...)
+}
+
+// hasuraDatabaseUrl - returns database URL used by hasura. Schema of the network is set as the first one in search path.
+func hasuraDatabaseUrl(cfg *config.Hasura, database config.Database) string {
+	host := database.Host
+	if cfg.Source != nil && cfg.Source.DatabaseHost != "" {
+		host = cfg.Source.DatabaseHost
+	}
+
+	dsn := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(database.User, database.Password),
+		Host:   fmt.Sprintf("%s:%d", host, database.Port),
+		Path:   database.Database,
+	}
+	if database.SchemaName != "" && database.SchemaName != "public" {
+		params := url.Values{}
+		params.Set("options", fmt.Sprintf("-csearch_path=%s,public", database.SchemaName))
+		dsn.RawQuery = params.Encode()
+	}
+	return dsn.String()
+}
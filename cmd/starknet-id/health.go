@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+)
+
+// failureChecker -
+type failureChecker interface {
+	IsFailed(name string) bool
+}
+
+// Health - liveness and readiness probes
+type Health struct {
+	states  storage.IState
+	ping    func(ctx context.Context) error
+	checker failureChecker
+	maxLag  time.Duration
+}
+
+// NewHealth - creates probes. If maxLag is 0 lag is not checked.
+func NewHealth(states storage.IState, ping func(ctx context.Context) error, checker failureChecker, maxLag time.Duration) Health {
+	return Health{
+		states:  states,
+		ping:    ping,
+		checker: checker,
+		maxLag:  maxLag,
+	}
+}
+
+// ChannelHealth -
+type ChannelHealth struct {
+	Name          string    `json:"name"`
+	LastHeight    uint64    `json:"last_height"`
+	LastTime      time.Time `json:"last_time"`
+	LastBlockTime time.Time `json:"last_block_time"`
+	Lag           int64     `json:"lag"`
+	Failed        bool      `json:"failed"`
+	Ready         bool      `json:"ready"`
+}
+
+// Readiness -
+type Readiness struct {
+	Ready    bool            `json:"ready"`
+	Database string          `json:"database"`
+	Channels []ChannelHealth `json:"channels"`
+}
+
+// Live - liveness probe. Returns 200 while process is able to serve requests.
+func (h Health) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Ready - readiness probe. Returns 503 if database is unavailable, any channel failed or lags behind more than configured threshold.
+func (h Health) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	readiness := h.check(ctx, time.Now().UTC())
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, readiness)
+}
+
+func (h Health) check(ctx context.Context, now time.Time) Readiness {
+	readiness := Readiness{
+		Ready:    true,
+		Database: "ok",
+		Channels: make([]ChannelHealth, 0),
+	}
+
+	if err := h.ping(ctx); err != nil {
+		readiness.Ready = false
+		readiness.Database = err.Error()
+		return readiness
+	}
+
+	states, err := h.states.List(ctx, 100, 0, sdk.SortOrderAsc)
+	if err != nil && !h.states.IsNoRows(err) {
+		readiness.Ready = false
+		readiness.Database = err.Error()
+		return readiness
+	}
+
+	for i := range states {
+		ch := ChannelHealth{
+			Name:          states[i].Name,
+			LastHeight:    states[i].LastHeight,
+			LastTime:      states[i].LastTime,
+			LastBlockTime: states[i].LastBlockTime,
+			Failed:        h.checker.IsFailed(states[i].Name),
+			Ready:         true,
+		}
+		if !ch.LastBlockTime.IsZero() {
+			ch.Lag = int64(now.Sub(ch.LastBlockTime).Seconds())
+		}
+
+		lagging := h.maxLag > 0 && (ch.LastBlockTime.IsZero() || now.Sub(ch.LastBlockTime) > h.maxLag)
+		if ch.Failed || lagging {
+			ch.Ready = false
+			readiness.Ready = false
+		}
+		readiness.Channels = append(readiness.Channels, ch)
+	}
+
+	return readiness
+}
+
+// NetworkReadiness - readiness of the network hosted by the process
+type NetworkReadiness struct {
+	Network string `json:"network"`
+	Readiness
+}
+
+// GroupReadiness -
+type GroupReadiness struct {
+	Ready    bool               `json:"ready"`
+	Networks []NetworkReadiness `json:"networks"`
+}
+
+// HealthGroup - readiness probe of several networks hosted by one process. It's ready if all networks are ready.
+type HealthGroup struct {
+	networks []string
+	probes   []Health
+}
+
+// NewHealthGroup -
+func NewHealthGroup() *HealthGroup {
+	return &HealthGroup{
+		networks: make([]string, 0),
+		probes:   make([]Health, 0),
+	}
+}
+
+// Add - adds probe of the network. Should be called before serving requests.
+func (g *HealthGroup) Add(network string, health Health) {
+	g.networks = append(g.networks, network)
+	g.probes = append(g.probes, health)
+}
+
+// Ready - readiness probe. Returns 503 if any network is not ready.
+func (g *HealthGroup) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	readiness := g.check(ctx, time.Now().UTC())
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, readiness)
+}
+
+func (g *HealthGroup) check(ctx context.Context, now time.Time) GroupReadiness {
+	readiness := GroupReadiness{
+		Ready:    true,
+		Networks: make([]NetworkReadiness, 0, len(g.probes)),
+	}
+	for i := range g.probes {
+		network := NetworkReadiness{
+			Network:   g.networks[i],
+			Readiness: g.probes[i].check(ctx, now),
+		}
+		if !network.Ready {
+			readiness.Ready = false
+		}
+		readiness.Networks = append(readiness.Networks, network)
+	}
+	return readiness
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type testStates struct {
+	storage.IState
+
+	states []*storage.State
+}
+
+func (ts testStates) List(ctx context.Context, limit, offset uint64, order sdk.SortOrder) ([]*storage.State, error) {
+	if len(ts.states) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return ts.states, nil
+}
+
+func (ts testStates) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+func (ts testStates) ByName(ctx context.Context, name string) (storage.State, error) {
+	for i := range ts.states {
+		if ts.states[i].Name == name {
+			return *ts.states[i], nil
+		}
+	}
+	return storage.State{}, sql.ErrNoRows
+}
+
+type testChecker map[string]bool
+
+func (tc testChecker) IsFailed(name string) bool {
+	return tc[name]
+}
+
+func okPing(ctx context.Context) error { return nil }
+
+func TestHealth_Ready(t *testing.T) {
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		states   []*storage.State
+		ping     func(ctx context.Context) error
+		failed   testChecker
+		maxLag   time.Duration
+		want     bool
+		database string
+	}{
+		{
+			name: "ready",
+			states: []*storage.State{
+				{Name: "starknet_id", LastHeight: 100, LastBlockTime: now.Add(-time.Minute)},
+			},
+			ping:     okPing,
+			maxLag:   15 * time.Minute,
+			want:     true,
+			database: "ok",
+		}, {
+			name:     "empty state",
+			ping:     okPing,
+			maxLag:   15 * time.Minute,
+			want:     true,
+			database: "ok",
+		}, {
+			name: "database unavailable",
+			ping: func(ctx context.Context) error {
+				return errors.New("connection refused")
+			},
+			maxLag:   15 * time.Minute,
+			want:     false,
+			database: "connection refused",
+		}, {
+			name: "failed channel",
+			states: []*storage.State{
+				{Name: "starknet_id", LastHeight: 100, LastBlockTime: now.Add(-time.Minute)},
+			},
+			ping:     okPing,
+			failed:   testChecker{"starknet_id": true},
+			maxLag:   15 * time.Minute,
+			want:     false,
+			database: "ok",
+		}, {
+			name: "stale block time",
+			states: []*storage.State{
+				{Name: "starknet_id", LastHeight: 100, LastTime: now, LastBlockTime: now.Add(-time.Hour)},
+			},
+			ping:     okPing,
+			maxLag:   15 * time.Minute,
+			want:     false,
+			database: "ok",
+		}, {
+			name: "unknown block time",
+			states: []*storage.State{
+				{Name: "starknet_id", LastHeight: 100, LastTime: now},
+			},
+			ping:     okPing,
+			maxLag:   15 * time.Minute,
+			want:     false,
+			database: "ok",
+		}, {
+			name: "lag check disabled",
+			states: []*storage.State{
+				{Name: "starknet_id", LastHeight: 100, LastBlockTime: now.Add(-time.Hour)},
+			},
+			ping:     okPing,
+			want:     true,
+			database: "ok",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealth(testStates{states: tt.states}, tt.ping, tt.failed, tt.maxLag)
+			got := health.check(context.Background(), now)
+			require.Equal(t, tt.want, got.Ready)
+			require.Equal(t, tt.database, got.Database)
+		})
+	}
+}
+
+func TestHealth_Handlers(t *testing.T) {
+	health := NewHealth(testStates{
+		states: []*storage.State{
+			{Name: "starknet_id", LastHeight: 100, LastBlockTime: time.Now().Add(-time.Hour)},
+		},
+	}, okPing, testChecker{}, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	health.Live(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	health.Ready(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	var readiness Readiness
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&readiness))
+	require.False(t, readiness.Ready)
+	require.Len(t, readiness.Channels, 1)
+	require.Equal(t, "starknet_id", readiness.Channels[0].Name)
+	require.GreaterOrEqual(t, readiness.Channels[0].Lag, int64(3600))
+}
+
+func TestHealthGroup_Ready(t *testing.T) {
+	group := NewHealthGroup()
+	group.Add("mainnet", NewHealth(testStates{
+		states: []*storage.State{
+			{Name: "starknet_id", LastHeight: 100, LastBlockTime: time.Now()},
+		},
+	}, okPing, testChecker{}, time.Minute))
+	group.Add("sepolia", NewHealth(testStates{
+		states: []*storage.State{
+			{Name: "starknet_id", LastHeight: 10, LastBlockTime: time.Now()},
+		},
+	}, okPing, testChecker{"starknet_id": true}, time.Minute))
+
+	recorder := httptest.NewRecorder()
+	group.Ready(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	var readiness GroupReadiness
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&readiness))
+	require.False(t, readiness.Ready)
+	require.Len(t, readiness.Networks, 2)
+	require.Equal(t, "mainnet", readiness.Networks[0].Network)
+	require.True(t, readiness.Networks[0].Ready)
+	require.Equal(t, "sepolia", readiness.Networks[1].Network)
+	require.False(t, readiness.Networks[1].Ready)
+	require.True(t, readiness.Networks[1].Channels[0].Failed)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/identity"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	identityPath = "/identity/"
+	lookupPath   = "/lookup"
+)
+
+// IdentityHandler - serves profiles of starknet ids by `/identity/{starknet_id|address}`
+type IdentityHandler struct {
+	service identity.Service
+}
+
+// NewIdentityHandler -
+func NewIdentityHandler(service identity.Service) IdentityHandler {
+	return IdentityHandler{service}
+}
+
+// Get -
+func (h IdentityHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method is not allowed"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	param := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, identityPath), "/"))
+
+	var (
+		profile identity.Profile
+		err     error
+	)
+	switch {
+	case param == "":
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "starknet id or address is required"})
+		return
+	case strings.HasPrefix(param, "0x"):
+		address, decodeErr := decodeAddress(param)
+		if decodeErr != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address"})
+			return
+		}
+		profile, err = h.service.ByAddress(ctx, address)
+	default:
+		starknetId, parseErr := decimal.NewFromString(param)
+		if parseErr != nil || !starknetId.IsInteger() || starknetId.IsNegative() {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid starknet id"})
+			return
+		}
+		profile, err = h.service.ByStarknetId(ctx, starknetId)
+	}
+
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, profile)
+	case errors.Is(err, identity.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		log.Err(err).Str("param", param).Msg("identity request")
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
+
+// decodeAddress - decodes hex address to 32-byte hash as it's stored in the database
+func decodeAddress(s string) ([]byte, error) {
+	s = strings.TrimPrefix(s, "0x")
+	if len(s) == 0 || len(s) > 64 {
+		return nil, errors.Errorf("invalid address length: %d", len(s))
+	}
+	return hex.DecodeString(fmt.Sprintf("%064s", s))
+}
+
+// Lookup - returns starknet ids by verified field value: `/lookup?field=twitter&value=123`
+func (h IdentityHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method is not allowed"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	field := r.URL.Query().Get("field")
+	value := r.URL.Query().Get("value")
+	if field == "" || value == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "field and value are required"})
+		return
+	}
+
+	ids, err := h.service.StarknetIdsByField(ctx, field, value)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string][]string{"starknet_ids": ids})
+	case errors.Is(err, identity.ErrUnknownField), errors.Is(err, identity.ErrInvalidValue):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		log.Err(err).Str("field", field).Msg("lookup request")
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dipdup-io/starknet-id/internal/identity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeAddress(t *testing.T) {
+	address, err := decodeAddress("0x327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8")
+	require.NoError(t, err)
+	require.Len(t, address, 32)
+	require.Equal(t, byte(0x03), address[0])
+
+	_, err = decodeAddress("0x")
+	require.Error(t, err)
+
+	_, err = decodeAddress("0xzz")
+	require.Error(t, err)
+}
+
+func TestIdentityHandler_BadRequest(t *testing.T) {
+	handler := NewIdentityHandler(identity.Service{})
+
+	for _, path := range []string{"/identity/", "/identity/abc", "/identity/-1", "/identity/0xzz"} {
+		recorder := httptest.NewRecorder()
+		handler.Get(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusBadRequest, recorder.Code, path)
+	}
+}
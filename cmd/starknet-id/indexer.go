@@ -2,16 +2,17 @@ package main
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/dipdup-io/starknet-go-api/pkg/data"
-	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	models "github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
 	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
 	"github.com/dipdup-net/indexer-sdk/pkg/modules"
 	"github.com/dipdup-net/indexer-sdk/pkg/storage"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 )
 
 // variables
@@ -19,36 +20,45 @@ var (
 	ZeroAddress = data.Felt("0x0").Bytes()
 )
 
-// input name
+// input and output names
 const (
-	InputName = "input"
+	InputName  = "input"
+	OutputName = "output"
 )
 
 // Indexer -
 type Indexer struct {
 	modules.BaseModule
 
-	client         *grpc.Client
-	storage        postgres.Storage
+	source         DataSource
+	storage        models.Backend
 	channels       map[uint64]Channel
 	channelsByName map[string]Channel
 	subscriptions  map[string]grpc.Subscription
-	subdomains     map[string]string
+	network        starknetid.Network
+	metrics        *Metrics
+
+	// channels are locked while subscribing, so messages sent right after subscription aren't lost
+	mx *sync.RWMutex
 }
 
 // NewIndexer -
-func NewIndexer(pg postgres.Storage, client *grpc.Client, subdomains map[string]string) *Indexer {
+func NewIndexer(backend models.Backend, source DataSource, network starknetid.Network, metrics *Metrics) *Indexer {
 	indexer := &Indexer{
 		BaseModule:     modules.New("starknet_id_indexer"),
-		client:         client,
-		storage:        pg,
+		source:         source,
+		storage:        backend,
 		channels:       make(map[uint64]Channel),
 		channelsByName: make(map[string]Channel),
 		subscriptions:  make(map[string]grpc.Subscription),
-		subdomains:     subdomains,
+		network:        network,
+		metrics:        metrics,
+		mx:             new(sync.RWMutex),
 	}
+	indexer.Log = indexer.Log.With().Str("network", network.Name).Logger()
 
 	indexer.CreateInput(InputName)
+	indexer.CreateOutput(OutputName)
 
 	return indexer
 }
@@ -56,11 +66,11 @@ func NewIndexer(pg postgres.Storage, client *grpc.Client, subdomains map[string]
 // Start -
 func (indexer *Indexer) Start(ctx context.Context) {
 	if err := indexer.init(ctx); err != nil {
-		log.Err(err).Msg("state initialization")
+		indexer.Log.Err(err).Msg("state initialization")
 		return
 	}
 
-	indexer.client.Start(ctx)
+	indexer.source.Start(ctx)
 
 	indexer.G.GoCtx(ctx, indexer.reconnectThread)
 	indexer.G.GoCtx(ctx, indexer.listen)
@@ -73,7 +83,8 @@ func (indexer *Indexer) Subscribe(ctx context.Context, subscriptions map[string]
 	for name, sub := range subscriptions {
 		ch, ok := indexer.channelsByName[name]
 		if !ok {
-			ch = NewChannel(name, indexer.storage, indexer.subdomains)
+			ch = NewChannel(name, indexer.storage, indexer.network, indexer.MustOutput(OutputName), indexer.metrics)
+			indexer.channelsByName[name] = ch
 		}
 
 		ch.Start(ctx)
@@ -82,23 +93,32 @@ func (indexer *Indexer) Subscribe(ctx context.Context, subscriptions map[string]
 			return errors.Wrap(err, "filters modifying")
 		}
 
-		log.Info().Str("topic", name).Msg("subscribing...")
-		req := sub.ToGrpcFilter()
-		subId, err := indexer.client.Subscribe(ctx, req)
-		if err != nil {
+		indexer.Log.Info().Str("topic", name).Msg("subscribing...")
+		if err := indexer.subscribe(ctx, ch, sub); err != nil {
 			return errors.Wrap(err, "subscribing error")
 		}
-		indexer.channels[subId] = ch
 	}
 	return nil
 }
 
+func (indexer *Indexer) subscribe(ctx context.Context, ch Channel, sub grpc.Subscription) error {
+	indexer.mx.Lock()
+	defer indexer.mx.Unlock()
+
+	subId, err := indexer.source.Subscribe(ctx, sub.ToGrpcFilter())
+	if err != nil {
+		return err
+	}
+	indexer.channels[subId] = ch
+	return nil
+}
+
 func (indexer *Indexer) init(ctx context.Context) error {
 	states, err := indexer.storage.State.List(ctx, 10, 0, storage.SortOrderAsc)
 	switch {
 	case err == nil:
 		for i := range states {
-			ch := NewChannel(states[i].Name, indexer.storage, indexer.subdomains)
+			ch := NewChannel(states[i].Name, indexer.storage, indexer.network, indexer.MustOutput(OutputName), indexer.metrics)
 			ch.blockCtx.state = states[i]
 			indexer.channelsByName[states[i].Name] = ch
 		}
@@ -116,7 +136,7 @@ func (indexer *Indexer) listen(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info().Msg("close listen thread")
+			indexer.Log.Info().Msg("close listen thread")
 			return
 
 		case msg, ok := <-input.Listen():
@@ -126,14 +146,16 @@ func (indexer *Indexer) listen(ctx context.Context) {
 
 			switch typ := msg.(type) {
 			case *pb.Subscription:
+				indexer.mx.RLock()
 				channel, ok := indexer.channels[typ.Response.Id]
+				indexer.mx.RUnlock()
 				if !ok {
-					log.Error().Uint64("id", typ.Response.Id).Msg("unknown subscription")
+					indexer.Log.Error().Uint64("id", typ.Response.Id).Msg("unknown subscription")
 					continue
 				}
 				channel.Add(typ)
 			default:
-				log.Info().Msgf("unknown message: %T", typ)
+				indexer.Log.Info().Msgf("unknown message: %T", typ)
 			}
 		}
 	}
@@ -143,22 +165,24 @@ func (indexer *Indexer) reconnectThread(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info().Msg("close reconnect thread")
+			indexer.Log.Info().Msg("close reconnect thread")
 			return
-		case subscriptionId, ok := <-indexer.client.Reconnect():
+		case subscriptionId, ok := <-indexer.source.Reconnect():
 			if !ok {
 				continue
 			}
 
 			if err := indexer.resubscribe(ctx, subscriptionId); err != nil {
-				log.Err(err).Msg("resubscribe")
+				indexer.Log.Err(err).Msg("resubscribe")
 			}
 		}
 	}
 }
 
 func (indexer *Indexer) resubscribe(ctx context.Context, id uint64) error {
+	indexer.mx.RLock()
 	channel, ok := indexer.channels[id]
+	indexer.mx.RUnlock()
 	if !ok {
 		return errors.Errorf("unknown subscription: %d", id)
 	}
@@ -172,7 +196,9 @@ func (indexer *Indexer) resubscribe(ctx context.Context, id uint64) error {
 		}
 	}
 
+	indexer.mx.Lock()
 	delete(indexer.channels, id)
+	indexer.mx.Unlock()
 
 	sub, ok := indexer.subscriptions[channel.Name()]
 	if !ok {
@@ -183,22 +209,22 @@ func (indexer *Indexer) resubscribe(ctx context.Context, id uint64) error {
 		return errors.Wrap(err, "filters modifying")
 	}
 
-	log.Info().Str("topic", channel.Name()).Msg("resubscribing...")
-	req := sub.ToGrpcFilter()
-	subId, err := indexer.client.Subscribe(ctx, req)
-	if err != nil {
+	indexer.Log.Info().Str("topic", channel.Name()).Msg("resubscribing...")
+	if err := indexer.subscribe(ctx, channel, sub); err != nil {
 		return errors.Wrap(err, "resubscribing error")
 	}
-	indexer.channels[subId] = channel
-
 	return nil
 }
 
 func (indexer *Indexer) actualFilters(ctx context.Context, ch Channel, sub *grpc.Subscription) error {
 	if sub.EventFilter != nil {
+		height := ch.blockCtx.state.LastHeight
+		if start := indexer.network.StartBlock; start > 0 && height < start-1 {
+			height = start - 1
+		}
 		for i := range sub.EventFilter {
 			sub.EventFilter[i].Height = &grpc.IntegerFilter{
-				Gt: ch.blockCtx.state.LastHeight,
+				Gt: height,
 			}
 		}
 
@@ -220,11 +246,23 @@ func (indexer *Indexer) actualFilters(ctx context.Context, ch Channel, sub *grpc
 	return nil
 }
 
+// IsFailed - returns true if channel with the name stopped processing because of error
+func (indexer *Indexer) IsFailed(name string) bool {
+	ch, ok := indexer.channelsByName[name]
+	if !ok {
+		return false
+	}
+	return ch.IsFailed()
+}
+
 // Unsubscribe -
 func (indexer *Indexer) Unsubscribe(ctx context.Context) error {
+	indexer.mx.RLock()
+	defer indexer.mx.RUnlock()
+
 	for subId, channel := range indexer.channels {
-		log.Info().Str("subscription", channel.Name()).Uint64("id", subId).Msg("unsubscribing...")
-		if err := indexer.client.Unsubscribe(ctx, subId); err != nil {
+		indexer.Log.Info().Str("subscription", channel.Name()).Uint64("id", subId).Msg("unsubscribing...")
+		if err := indexer.source.Unsubscribe(ctx, subId); err != nil {
 			return errors.Wrap(err, "unsubscribing")
 		}
 
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// defaultTakeoverTimeout - default time in seconds in which a follower takes over after the leader died
+const defaultTakeoverTimeout = 15
+
+// HAConfig - high availability mode. Replicas elect the leader of every network, only the leader indexes.
+type HAConfig struct {
+	TakeoverTimeout uint64 `validate:"omitempty,min=3" yaml:"takeover_timeout"`
+}
+
+// interval - returns interval of lock retries and leader session keepalives. Dead leader's session is closed
+// in two intervals and the lock is acquired by a follower in the third one.
+func (cfg HAConfig) interval() time.Duration {
+	timeout := cfg.TakeoverTimeout
+	if timeout == 0 {
+		timeout = defaultTakeoverTimeout
+	}
+	return time.Duration(timeout) * time.Second / 3
+}
+
+// leaderLock - lock which is held by the only replica. It's implemented by postgres.LeaderLock.
+type leaderLock interface {
+	TryLock(ctx context.Context) (bool, error)
+	Check(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// Elector - campaigns for leadership and watches the held lock
+type Elector struct {
+	lock     leaderLock
+	interval time.Duration
+	leader   *atomic.Bool
+	log      zerolog.Logger
+}
+
+// NewElector -
+func NewElector(lock leaderLock, interval time.Duration, log zerolog.Logger) *Elector {
+	return &Elector{
+		lock:     lock,
+		interval: interval,
+		leader:   new(atomic.Bool),
+		log:      log,
+	}
+}
+
+// Campaign - blocks until the lock is acquired. Returns context error if context was cancelled before.
+func (e *Elector) Campaign(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		locked, err := e.lock.TryLock(ctx)
+		switch {
+		case err != nil:
+			e.log.Warn().Err(err).Msg("leader election")
+		case locked:
+			e.leader.Store(true)
+			e.log.Info().Msg("elected as leader")
+			return nil
+		default:
+			e.log.Debug().Msg("lock is held by another replica, staying follower")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Watch - blocks while the lock is held. Returns error when the lock is lost and nil when context is cancelled.
+func (e *Elector) Watch(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := e.lock.Check(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				e.leader.Store(false)
+				return errors.Wrap(err, "leadership is lost")
+			}
+		}
+	}
+}
+
+// IsLeader -
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Resign - releases the lock if it's held
+func (e *Elector) Resign(ctx context.Context) error {
+	if !e.leader.Swap(false) {
+		return nil
+	}
+	return e.lock.Unlock(ctx)
+}
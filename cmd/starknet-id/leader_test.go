@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// testLockServer - stands in for advisory locks of one database
+type testLockServer struct {
+	holder *testLock
+	mx     sync.Mutex
+}
+
+// testLock - session of a replica. Crashed session releases the lock like Postgres does for closed connections.
+type testLock struct {
+	server  *testLockServer
+	crashed atomic.Bool
+}
+
+func (s *testLockServer) session() *testLock {
+	return &testLock{server: s}
+}
+
+func (l *testLock) TryLock(ctx context.Context) (bool, error) {
+	if l.crashed.Load() {
+		return false, errors.New("connection refused")
+	}
+	l.server.mx.Lock()
+	defer l.server.mx.Unlock()
+
+	if l.server.holder == nil || l.server.holder.crashed.Load() {
+		l.server.holder = l
+	}
+	return l.server.holder == l, nil
+}
+
+func (l *testLock) Check(ctx context.Context) error {
+	if l.crashed.Load() {
+		return errors.New("connection reset by peer")
+	}
+	return nil
+}
+
+func (l *testLock) Unlock(ctx context.Context) error {
+	l.server.mx.Lock()
+	defer l.server.mx.Unlock()
+
+	if l.server.holder == l {
+		l.server.holder = nil
+	}
+	return nil
+}
+
+func TestElector_Takeover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := new(testLockServer)
+	leaderLock := server.session()
+	leader := NewElector(leaderLock, 10*time.Millisecond, zerolog.Nop())
+	follower := NewElector(server.session(), 10*time.Millisecond, zerolog.Nop())
+
+	require.NoError(t, leader.Campaign(ctx))
+	require.True(t, leader.IsLeader())
+
+	elected := make(chan error, 1)
+	go func() {
+		elected <- follower.Campaign(ctx)
+	}()
+
+	lost := make(chan error, 1)
+	go func() {
+		lost <- leader.Watch(ctx)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, follower.IsLeader())
+
+	// leader process dies: its session is closed and the lock is released
+	leaderLock.crashed.Store(true)
+
+	select {
+	case err := <-lost:
+		require.Error(t, err)
+		require.False(t, leader.IsLeader())
+	case <-time.After(time.Second):
+		t.Fatal("leader didn't notice lost lock")
+	}
+
+	select {
+	case err := <-elected:
+		require.NoError(t, err)
+		require.True(t, follower.IsLeader())
+	case <-time.After(time.Second):
+		t.Fatal("follower didn't take over")
+	}
+}
+
+func TestElector_Resign(t *testing.T) {
+	server := new(testLockServer)
+	first := NewElector(server.session(), 10*time.Millisecond, zerolog.Nop())
+	second := NewElector(server.session(), 10*time.Millisecond, zerolog.Nop())
+
+	require.NoError(t, first.Campaign(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, second.Campaign(ctx), context.DeadlineExceeded)
+	require.False(t, second.IsLeader())
+
+	require.NoError(t, first.Resign(context.Background()))
+	require.False(t, first.IsLeader())
+	require.NoError(t, first.Resign(context.Background()))
+
+	require.NoError(t, second.Campaign(context.Background()))
+	require.True(t, second.IsLeader())
+}
+
+func TestHAConfig_Interval(t *testing.T) {
+	require.Equal(t, 5*time.Second, HAConfig{}.interval())
+	require.Equal(t, 10*time.Second, HAConfig{TakeoverTimeout: 30}.interval())
+}
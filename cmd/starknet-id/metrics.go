@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+const metricsNamespace = "starknet_id"
+
+// entity names of count metric
+const (
+	entityDomain     = "domain"
+	entityStarknetId = "starknet_id"
+	entityField      = "field"
+)
+
+// Metrics - prometheus instrumentation of the indexer. All methods are safe to call on nil receiver, so metrics can be turned off by config.
+// Values are labelled by network, metrics of a network are returned by Network.
+type Metrics struct {
+	registry *prometheus.Registry
+	server   *http.Server
+	network  string
+	storages *syncMap[string, storage.Backend]
+	interval time.Duration
+
+	headHeight    *prometheus.GaugeVec
+	headTime      *prometheus.GaugeVec
+	events        *prometheus.CounterVec
+	handlerErrors *prometheus.CounterVec
+	saveDuration  *prometheus.HistogramVec
+	channelBuffer *prometheus.GaugeVec
+	cache         *prometheus.CounterVec
+	entities      *prometheus.GaugeVec
+	leader        *prometheus.GaugeVec
+
+	lags *syncMap[lagKey, time.Time]
+	wg   *sync.WaitGroup
+}
+
+type lagKey struct {
+	network string
+	channel string
+}
+
+// NewMetrics - creates metrics if prometheus section is set in config. Otherwise returns nil.
+func NewMetrics(cfg *config.Prometheus) *Metrics {
+	if cfg == nil || cfg.URL == "" {
+		return nil
+	}
+
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		storages: newSyncMap[string, storage.Backend](),
+		interval: time.Minute,
+		headHeight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "head_height",
+			Help:      "Last indexed block height",
+		}, []string{"network", "channel"}),
+		headTime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "head_timestamp_seconds",
+			Help:      "Timestamp of last indexed block",
+		}, []string{"network", "channel"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "events_total",
+			Help:      "Count of processed events by name",
+		}, []string{"network", "channel", "name"}),
+		handlerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "handler_errors_total",
+			Help:      "Count of errors returned by event handlers",
+		}, []string{"network", "channel", "name"}),
+		saveDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "save_duration_seconds",
+			Help:      "Duration of block data saving",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"network", "channel"}),
+		channelBuffer: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "channel_buffer_size",
+			Help:      "Count of messages waiting in channel buffer",
+		}, []string{"network", "channel"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_requests_total",
+			Help:      "Count of cache requests by result: hit or miss",
+		}, []string{"network", "cache", "result"}),
+		entities: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "entities",
+			Help:      "Count of stored entities",
+		}, []string{"network", "entity"}),
+		leader: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "leader",
+			Help:      "1 if the replica is the leader of the network in HA mode",
+		}, []string{"network"}),
+		lags: newSyncMap[lagKey, time.Time](),
+		wg:   new(sync.WaitGroup),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.headHeight, m.headTime, m.events, m.handlerErrors, m.saveDuration,
+		m.channelBuffer, m.cache, m.entities, m.leader, lagCollector{m.lags},
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	m.server = &http.Server{
+		Addr:              cfg.URL,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return m
+}
+
+// Network - returns metrics labelled by the network. Entities of the storage are counted under the network label.
+func (m *Metrics) Network(name string, backend storage.Backend) *Metrics {
+	if m == nil {
+		return nil
+	}
+	m.storages.Set(name, backend)
+
+	network := *m
+	network.network = name
+	return &network
+}
+
+var lagDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(metricsNamespace, "", "head_lag_seconds"),
+	"Difference between current time and timestamp of last indexed block",
+	[]string{"network", "channel"}, nil,
+)
+
+// lagCollector - computes lag on scrape, so it grows when indexer is stuck
+type lagCollector struct {
+	lags *syncMap[lagKey, time.Time]
+}
+
+// Describe -
+func (c lagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lagDesc
+}
+
+// Collect -
+func (c lagCollector) Collect(ch chan<- prometheus.Metric) {
+	_ = c.lags.Range(func(key lagKey, blockTime time.Time) (bool, error) {
+		ch <- prometheus.MustNewConstMetric(lagDesc, prometheus.GaugeValue, time.Since(blockTime).Seconds(), key.network, key.channel)
+		return false, nil
+	})
+}
+
+// Start -
+func (m *Metrics) Start(ctx context.Context) {
+	if m == nil {
+		return
+	}
+
+	m.wg.Add(2)
+	go func() {
+		defer m.wg.Done()
+
+		log.Info().Str("url", m.server.Addr).Msg("starting metrics server...")
+		if err := m.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Err(err).Msg("metrics server")
+		}
+	}()
+	go m.countEntities(ctx)
+}
+
+// Close -
+func (m *Metrics) Close() error {
+	if m == nil {
+		return nil
+	}
+
+	if err := m.server.Close(); err != nil {
+		return err
+	}
+	m.wg.Wait()
+	return nil
+}
+
+func (m *Metrics) countEntities(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.updateEntities(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Metrics) updateEntities(ctx context.Context) {
+	_ = m.storages.Range(func(network string, backend storage.Backend) (bool, error) {
+		m.countEntity(ctx, network, entityDomain, backend.Domains)
+		m.countEntity(ctx, network, entityStarknetId, backend.StarknetIds)
+		m.countEntity(ctx, network, entityField, backend.Fields)
+		return false, nil
+	})
+}
+
+type counter interface {
+	Count(ctx context.Context) (int, error)
+}
+
+func (m *Metrics) countEntity(ctx context.Context, network, entity string, table counter) {
+	if table == nil {
+		return
+	}
+	count, err := table.Count(ctx)
+	if err != nil {
+		log.Err(err).Str("network", network).Str("entity", entity).Msg("count entities")
+		return
+	}
+	m.entities.WithLabelValues(network, entity).Set(float64(count))
+}
+
+// SetHead -
+func (m *Metrics) SetHead(channel string, height uint64, blockTime time.Time) {
+	if m == nil {
+		return
+	}
+	m.headHeight.WithLabelValues(m.network, channel).Set(float64(height))
+	if !blockTime.IsZero() {
+		m.headTime.WithLabelValues(m.network, channel).Set(float64(blockTime.Unix()))
+		m.lags.Set(lagKey{m.network, channel}, blockTime)
+	}
+}
+
+// IncEvent -
+func (m *Metrics) IncEvent(channel, name string) {
+	if m == nil {
+		return
+	}
+	m.events.WithLabelValues(m.network, channel, name).Inc()
+}
+
+// IncHandlerError -
+func (m *Metrics) IncHandlerError(channel, name string) {
+	if m == nil {
+		return
+	}
+	m.handlerErrors.WithLabelValues(m.network, channel, name).Inc()
+}
+
+// ObserveSave -
+func (m *Metrics) ObserveSave(channel string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.saveDuration.WithLabelValues(m.network, channel).Observe(duration.Seconds())
+}
+
+// SetChannelBuffer -
+func (m *Metrics) SetChannelBuffer(channel string, size int) {
+	if m == nil {
+		return
+	}
+	m.channelBuffer.WithLabelValues(m.network, channel).Set(float64(size))
+}
+
+// IncCacheRequest -
+func (m *Metrics) IncCacheRequest(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cache.WithLabelValues(m.network, cache, result).Inc()
+}
+
+// SetLeader -
+func (m *Metrics) SetLeader(leader bool) {
+	if m == nil {
+		return
+	}
+	var value float64
+	if leader {
+		value = 1
+	}
+	m.leader.WithLabelValues(m.network).Set(value)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/go-lib/config"
+	generalPB "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
+
+func scrape(url string) (string, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	return string(body), err
+}
+
+func TestMetrics_Disabled(t *testing.T) {
+	metrics := NewMetrics(nil).Network("mainnet", storage.Backend{})
+	require.Nil(t, metrics)
+
+	metrics.Start(context.Background())
+	metrics.IncEvent("test", "Transfer")
+	metrics.SetHead("test", 1, time.Now())
+	require.NoError(t, metrics.Close())
+}
+
+func TestMetrics_Scrape(t *testing.T) {
+	address := freeAddress(t)
+	root := NewMetrics(&config.Prometheus{URL: address})
+	require.NotNil(t, root)
+	metrics := root.Network("mainnet", storage.Backend{})
+	other := root.Network("sepolia", storage.Backend{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	root.Start(ctx)
+
+	channel := NewChannel("test", storage.Backend{}, starknetid.Mainnet, nil, metrics)
+	channel.Start(ctx)
+
+	response := &generalPB.SubscribeResponse{Id: 1}
+	channel.Add(&pb.Subscription{
+		Response: response,
+		Event: &pb.Event{
+			Height:     100,
+			Time:       1700000000,
+			Name:       "starknet_id_update",
+			ParsedData: []byte(`{"domain_len":"0x1","domain":["0x15d246f6c1b"],"owner":"0x1","expiry":"0x64b3b2d0"}`),
+		},
+	})
+	channel.Add(&pb.Subscription{
+		Response: response,
+		Event: &pb.Event{
+			Height:     100,
+			Time:       1700000000,
+			Name:       "starknet_id_update",
+			ParsedData: []byte(`{"domain_len":"0x1","domain":["0x1c81fe3d15f"],"owner":"0x2","expiry":"0x64b3b2d0"}`),
+		},
+	})
+	channel.Add(&pb.Subscription{
+		Response: response,
+		Event: &pb.Event{
+			Height: 100,
+			Time:   1700000000,
+			Name:   "unknown_event",
+		},
+	})
+
+	channel.blockCtx.cache.SetSubdomain(1, "braavos")
+	_, err := channel.blockCtx.cache.GetSubdomain(ctx, 1)
+	require.NoError(t, err)
+
+	cache := NewCache(memory.New().Subdomains, "stark", metrics)
+	for i := 0; i < 2; i++ {
+		_, err = cache.GetSubdomain(ctx, 2)
+		require.NoError(t, err)
+	}
+
+	metrics.ObserveSave("test", 15*time.Millisecond)
+	metrics.SetHead("test", 100, time.Unix(1700000000, 0))
+	other.SetHead("test", 10, time.Unix(1700000000, 0))
+
+	url := "http://" + address + "/metrics"
+	var body string
+	require.Eventually(t, func() bool {
+		body, err = scrape(url)
+		return err == nil && strings.Contains(body, `starknet_id_handler_errors_total{channel="test",name="unknown_event",network="mainnet"} 1`)
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.Contains(t, body, `starknet_id_events_total{channel="test",name="starknet_id_update",network="mainnet"} 2`)
+	require.Contains(t, body, `starknet_id_events_total{channel="test",name="unknown_event",network="mainnet"} 1`)
+	require.Contains(t, body, `starknet_id_head_height{channel="test",network="mainnet"} 100`)
+	require.Contains(t, body, `starknet_id_head_height{channel="test",network="sepolia"} 10`)
+	require.Contains(t, body, `starknet_id_head_timestamp_seconds{channel="test",network="mainnet"} 1.7e+09`)
+	require.Contains(t, body, `starknet_id_head_lag_seconds{channel="test",network="mainnet"}`)
+	require.Contains(t, body, `starknet_id_save_duration_seconds_count{channel="test",network="mainnet"} 1`)
+	require.Contains(t, body, `starknet_id_channel_buffer_size{channel="test",network="mainnet"}`)
+	require.Contains(t, body, `starknet_id_cache_requests_total{cache="subdomain",network="mainnet",result="hit"} 2`)
+	require.Contains(t, body, `starknet_id_cache_requests_total{cache="subdomain",network="mainnet",result="miss"} 1`)
+
+	domain, ok := channel.blockCtx.domains.Get("fricoben.stark")
+	require.True(t, ok)
+	require.Equal(t, "fricoben.stark", domain.Domain)
+
+	cancel()
+	require.NoError(t, channel.Close())
+	require.NoError(t, root.Close())
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// schemaMigrator - subset of postgres.Migrator used by commands
+type schemaMigrator interface {
+	Latest() int
+	Current(ctx context.Context) (int, error)
+	Status(ctx context.Context) ([]postgres.MigrationStatus, error)
+	Up(ctx context.Context) (int, error)
+	DownTo(ctx context.Context, version int) (int, error)
+}
+
+type migratorConnector func(cmd *cobra.Command) (schemaMigrator, func() error, error)
+
+func newMigrateCmd(connect migratorConnector) *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database schema migrations",
+	}
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, closeFn, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			count, err := migrator.Up(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "applied %d migration(s), schema version %d\n", count, migrator.Latest())
+			return nil
+		},
+	})
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Print applied and pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, closeFn, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			status, err := migrator.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			current, err := migrator.Current(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printMigrationStatus(cmd.OutOrStdout(), status, current, migrator.Latest())
+		},
+	})
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "down-to VERSION",
+		Short: "Revert migrations down to the version (0 reverts all)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < 0 {
+				return fmt.Errorf("invalid version: %s", args[0])
+			}
+
+			migrator, closeFn, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			count, err := migrator.DownTo(cmd.Context(), version)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "reverted %d migration(s), schema version %d\n", count, version)
+			return nil
+		},
+	})
+
+	return migrateCmd
+}
+
+func printMigrationStatus(w io.Writer, status []postgres.MigrationStatus, current, latest int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT\n")
+	for i := range status {
+		state := "pending"
+		appliedAt := ""
+		if status[i].Applied {
+			state = "applied"
+			appliedAt = status[i].AppliedAt.UTC().Format(time.RFC3339)
+		}
+		if status[i].Version > latest {
+			state = "unknown"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", status[i].Version, status[i].Name, state, appliedAt)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "database version: %d, binary version: %d\n", current, latest)
+	return err
+}
+
+// connectMigrator - connects to the database of the network from config without applying migrations. Schema of the network is created if it doesn't exist.
+func connectMigrator(cmd *cobra.Command) (schemaMigrator, func() error, error) {
+	inst, err := loadInstance(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if inst.Database.Kind == config.DBKindSqlite {
+		return nil, nil, errors.New("migrations are applied to postgres database only, SQLite schema is created on startup")
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), time.Minute)
+	defer cancel()
+
+	pg, err := postgres.Open(ctx, inst.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := postgres.CreateSchema(ctx, pg.Connection(), inst.Database.SchemaName); err != nil {
+		return nil, nil, err
+	}
+	migrator, err := postgres.NewMigrator(pg.Connection())
+	if err != nil {
+		return nil, nil, err
+	}
+	return migrator, pg.Close, nil
+}
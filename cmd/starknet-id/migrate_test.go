@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+type testMigrator struct {
+	latest  int
+	current int
+	closed  bool
+}
+
+func (tm *testMigrator) Latest() int { return tm.latest }
+
+func (tm *testMigrator) Current(ctx context.Context) (int, error) { return tm.current, nil }
+
+func (tm *testMigrator) Status(ctx context.Context) ([]postgres.MigrationStatus, error) {
+	status := make([]postgres.MigrationStatus, 0, tm.latest)
+	for i := 1; i <= tm.latest; i++ {
+		s := postgres.MigrationStatus{Version: i, Name: "m"}
+		if i <= tm.current {
+			s.Applied = true
+			s.AppliedAt = time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+func (tm *testMigrator) Up(ctx context.Context) (int, error) {
+	count := tm.latest - tm.current
+	tm.current = tm.latest
+	return count, nil
+}
+
+func (tm *testMigrator) DownTo(ctx context.Context, version int) (int, error) {
+	count := tm.current - version
+	tm.current = version
+	return count, nil
+}
+
+func executeMigrate(t *testing.T, migrator *testMigrator, args ...string) (string, error) {
+	cmd := newMigrateCmd(func(cmd *cobra.Command) (schemaMigrator, func() error, error) {
+		return migrator, func() error {
+			migrator.closed = true
+			return nil
+		}, nil
+	})
+	output := new(bytes.Buffer)
+	cmd.SetOut(output)
+	cmd.SetErr(output)
+	cmd.SetArgs(args)
+	err := cmd.ExecuteContext(context.Background())
+	return output.String(), err
+}
+
+func TestMigrateCmd_Up(t *testing.T) {
+	migrator := &testMigrator{latest: 4, current: 1}
+	output, err := executeMigrate(t, migrator, "up")
+	require.NoError(t, err)
+	require.Contains(t, output, "applied 3 migration(s), schema version 4")
+	require.Equal(t, 4, migrator.current)
+	require.True(t, migrator.closed)
+}
+
+func TestMigrateCmd_Status(t *testing.T) {
+	migrator := &testMigrator{latest: 2, current: 1}
+	output, err := executeMigrate(t, migrator, "status")
+	require.NoError(t, err)
+	require.Contains(t, output, "1        m     applied  2023-11-01T00:00:00Z")
+	require.Contains(t, output, "2        m     pending")
+	require.Contains(t, output, "database version: 1, binary version: 2")
+}
+
+func TestMigrateCmd_DownTo(t *testing.T) {
+	migrator := &testMigrator{latest: 4, current: 4}
+	output, err := executeMigrate(t, migrator, "down-to", "2")
+	require.NoError(t, err)
+	require.Contains(t, output, "reverted 2 migration(s), schema version 2")
+	require.Equal(t, 2, migrator.current)
+
+	_, err = executeMigrate(t, migrator, "down-to", "-1")
+	require.Error(t, err)
+
+	_, err = executeMigrate(t, migrator, "down-to")
+	require.Error(t, err)
+}
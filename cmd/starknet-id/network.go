@@ -0,0 +1,283 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/archive"
+	"github.com/dipdup-io/starknet-id/internal/jsonrpc"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/pkg/errors"
+)
+
+// defaultNetworkChannel - name of subscription generated from network contracts
+const defaultNetworkChannel = "starknet_id"
+
+// NetworkConfig - Starknet ID deployment to index. Fields set explicitly override the preset.
+type NetworkConfig struct {
+	Preset     string               `validate:"omitempty,oneof=mainnet sepolia" yaml:"preset"`
+	Channel    string               `validate:"omitempty"                       yaml:"channel"`
+	StartBlock uint64               `validate:"omitempty"                       yaml:"start_block"`
+	RootDomain string               `validate:"omitempty"                       yaml:"root_domain"`
+	Contracts  starknetid.Contracts `validate:"omitempty"                       yaml:"contracts"`
+}
+
+// network - returns network to index. Without `network` section mainnet root domain is used and
+// events are filtered by `grpc.subscriptions` only. Resolvers from `subdomains` are added in both cases.
+func (c Config) network() (starknetid.Network, error) {
+	network := starknetid.Network{
+		Name:       "custom",
+		RootDomain: starknetid.Mainnet.RootDomain,
+	}
+
+	if c.Network != nil {
+		if c.Network.Preset != "" {
+			preset, ok := starknetid.Preset(c.Network.Preset)
+			if !ok {
+				return network, errors.Errorf("unknown network preset: %s", c.Network.Preset)
+			}
+			network = preset
+		}
+		network = network.Override(starknetid.Network{
+			StartBlock: c.Network.StartBlock,
+			RootDomain: c.Network.RootDomain,
+			Contracts:  c.Network.Contracts,
+		})
+	}
+
+	if len(c.Subdomains) > 0 {
+		resolvers := make(map[string]data.Felt, len(c.Subdomains))
+		for address, subdomain := range c.Subdomains {
+			resolvers[subdomain] = data.Felt(encoding.AddHexPrefix(strings.TrimPrefix(address, "0x")))
+		}
+		network = network.Override(starknetid.Network{
+			Contracts: starknetid.Contracts{Resolvers: resolvers},
+		})
+	}
+
+	if c.Network != nil {
+		if err := network.Validate(); err != nil {
+			return network, errors.Wrap(err, "network")
+		}
+	}
+	return network, nil
+}
+
+// subscriptions - returns configured gRPC subscriptions and the one generated from network contracts.
+// JSON-RPC and archive sources are subscribed to network contracts only.
+func (c Config) subscriptions(network starknetid.Network) (map[string]grpc.Subscription, error) {
+	subscriptions := make(map[string]grpc.Subscription)
+	if c.GRPC != nil {
+		for name, sub := range c.GRPC.Subscriptions {
+			// block time of channel without head advances only on events, so quiet channel would be reported as lagging
+			if !sub.Head && c.Api != nil && c.Api.MaxLag > 0 {
+				return nil, errors.Errorf("subscription %s: api.max_lag requires head: true", name)
+			}
+			subscriptions[name] = sub
+		}
+	}
+	if c.Network == nil {
+		if c.RPC != nil {
+			return nil, errors.New("network section is required by rpc data source")
+		}
+		if c.Archive != nil {
+			return nil, errors.New("network section is required by archive data source")
+		}
+		return subscriptions, nil
+	}
+
+	channel := c.Network.Channel
+	if channel == "" {
+		channel = defaultNetworkChannel
+	}
+	if _, ok := subscriptions[channel]; ok {
+		return nil, errors.Errorf("subscription %s is generated from network contracts and can't be set in grpc section", channel)
+	}
+	subscriptions[channel] = networkSubscription(network)
+	return subscriptions, nil
+}
+
+// networkSubscription - subscribes to head, events of network contracts by their roles and Starknet addresses
+func networkSubscription(network starknetid.Network) grpc.Subscription {
+	contracts := network.List()
+	sub := grpc.Subscription{
+		Head:        true,
+		EventFilter: make([]*grpc.EventFilter, 0, len(contracts)),
+		AddressFilter: []*grpc.AddressFilter{
+			{OnlyStarknet: true},
+		},
+	}
+
+	for _, contract := range contracts {
+		events := contract.Role.Events()
+		filter := &grpc.EventFilter{
+			Contract: &grpc.BytesFilter{
+				Eq: contract.Address.Bytes(),
+			},
+			Name: new(grpc.StringFilter),
+		}
+		if len(events) == 1 {
+			filter.Name.Eq = events[0]
+		} else {
+			filter.Name.In = events
+		}
+		sub.EventFilter = append(sub.EventFilter, filter)
+	}
+	return sub
+}
+
+// InstanceConfig - network indexed by the same process in its own database schema
+type InstanceConfig struct {
+	Name       string             `validate:"required"  yaml:"name"`
+	Schema     string             `validate:"omitempty" yaml:"schema"`
+	GRPC       *grpc.ClientConfig `validate:"omitempty" yaml:"grpc"`
+	RPC        *jsonrpc.Config    `validate:"omitempty" yaml:"rpc"`
+	Archive    *archive.Config    `validate:"omitempty" yaml:"archive"`
+	Network    *NetworkConfig     `validate:"omitempty" yaml:"network"`
+	Subdomains map[string]string  `validate:"omitempty" yaml:"subdomains"`
+}
+
+// instance - network with its database, data source and subscription settings
+type instance struct {
+	Name          string
+	Database      config.Database
+	GRPC          grpc.ClientConfig
+	RPC           *jsonrpc.Config // nil if the network isn't indexed from Starknet node
+	Archive       *archive.Config // nil if the network isn't indexed from archive file
+	Network       starknetid.Network
+	Subscriptions map[string]grpc.Subscription
+}
+
+var identifierRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// instances - returns networks indexed by the process. Without `networks` section it's the only network
+// configured by top-level data source (`grpc`, `rpc` or `archive`), `network` and `subdomains` sections.
+func (c Config) instances() ([]instance, error) {
+	if len(c.Networks) == 0 {
+		if err := c.checkDataSource(); err != nil {
+			return nil, err
+		}
+		network, err := c.network()
+		if err != nil {
+			return nil, err
+		}
+		subscriptions, err := c.subscriptions(network)
+		if err != nil {
+			return nil, err
+		}
+		return []instance{
+			c.newInstance(network.Name, c.Database, network, subscriptions),
+		}, nil
+	}
+
+	if c.GRPC != nil || c.RPC != nil || c.Archive != nil || c.Network != nil || len(c.Subdomains) > 0 {
+		return nil, errors.New("grpc, rpc, archive, network and subdomains sections should be set per network if networks section is used")
+	}
+
+	instances := make([]instance, 0, len(c.Networks))
+	names := make(map[string]struct{}, len(c.Networks))
+	schemas := make(map[string]string, len(c.Networks))
+	for _, cfg := range c.Networks {
+		if !identifierRegexp.MatchString(cfg.Name) {
+			return nil, errors.Errorf("invalid network name %q: lowercase letters, digits and underscores are allowed", cfg.Name)
+		}
+		if _, ok := names[cfg.Name]; ok {
+			return nil, errors.Errorf("duplicate network name: %s", cfg.Name)
+		}
+		names[cfg.Name] = struct{}{}
+
+		schema := cfg.Schema
+		if schema == "" {
+			schema = cfg.Name
+		}
+		if !identifierRegexp.MatchString(schema) {
+			return nil, errors.Errorf("invalid schema name %q of network %s", schema, cfg.Name)
+		}
+		if other, ok := schemas[schema]; ok {
+			return nil, errors.Errorf("networks %s and %s use the same schema %s", other, cfg.Name, schema)
+		}
+		schemas[schema] = cfg.Name
+
+		instanceCfg := Config{
+			GRPC:       cfg.GRPC,
+			RPC:        cfg.RPC,
+			Archive:    cfg.Archive,
+			Network:    cfg.Network,
+			Subdomains: cfg.Subdomains,
+			Api:        c.Api,
+		}
+		if err := instanceCfg.checkDataSource(); err != nil {
+			return nil, errors.Wrap(err, cfg.Name)
+		}
+		network, err := instanceCfg.network()
+		if err != nil {
+			return nil, errors.Wrap(err, cfg.Name)
+		}
+		network.Name = cfg.Name
+
+		subscriptions, err := instanceCfg.subscriptions(network)
+		if err != nil {
+			return nil, errors.Wrap(err, cfg.Name)
+		}
+
+		database := c.Database
+		database.SchemaName = schema
+
+		instances = append(instances, instanceCfg.newInstance(cfg.Name, database, network, subscriptions))
+	}
+	return instances, nil
+}
+
+// checkDataSource - checks that exactly one of `grpc`, `rpc` and `archive` sections is set
+func (c Config) checkDataSource() error {
+	var count int
+	for _, isSet := range []bool{c.GRPC != nil, c.RPC != nil, c.Archive != nil} {
+		if isSet {
+			count++
+		}
+	}
+	switch count {
+	case 0:
+		return errors.New("one of grpc, rpc and archive sections is required")
+	case 1:
+		return nil
+	default:
+		return errors.New("only one of grpc, rpc and archive sections can be used")
+	}
+}
+
+func (c Config) newInstance(name string, database config.Database, network starknetid.Network, subscriptions map[string]grpc.Subscription) instance {
+	inst := instance{
+		Name:          name,
+		Database:      database,
+		RPC:           c.RPC,
+		Archive:       c.Archive,
+		Network:       network,
+		Subscriptions: subscriptions,
+	}
+	if c.GRPC != nil {
+		inst.GRPC = *c.GRPC
+	}
+	return inst
+}
+
+// instance - returns network by name. If name is empty the first configured network is returned.
+func (c Config) instance(name string) (instance, error) {
+	instances, err := c.instances()
+	if err != nil {
+		return instance{}, err
+	}
+	if name == "" {
+		return instances[0], nil
+	}
+	for i := range instances {
+		if instances[i].Name == name {
+			return instances[i], nil
+		}
+	}
+	return instance{}, errors.Errorf("unknown network: %s", name)
+}
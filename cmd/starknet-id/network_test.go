@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/dipdup-io/starknet-id/internal/archive"
+	"github.com/dipdup-io/starknet-id/internal/jsonrpc"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Network(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            Config
+		wantName       string
+		wantRoot       string
+		wantStart      uint64
+		wantSubdomains map[string]string
+		wantErr        bool
+	}{
+		{
+			name: "without network section",
+			cfg: Config{
+				Subdomains: map[string]string{
+					"03448896d4a0df143f98c9eeccc7e279bf3c2008bda2ad2759f5b20ed263585f": "braavos",
+				},
+			},
+			wantName: "custom",
+			wantRoot: "stark",
+			wantSubdomains: map[string]string{
+				"03448896d4a0df143f98c9eeccc7e279bf3c2008bda2ad2759f5b20ed263585f": "braavos",
+			},
+		}, {
+			name: "mainnet preset",
+			cfg: Config{
+				Network: &NetworkConfig{Preset: "mainnet", StartBlock: 10},
+			},
+			wantName:  "mainnet",
+			wantRoot:  "stark",
+			wantStart: 10,
+			wantSubdomains: map[string]string{
+				"03448896d4a0df143f98c9eeccc7e279bf3c2008bda2ad2759f5b20ed263585f": "braavos",
+				"04942ebdc9fc996a42adb4a825e9070737fe68cef32a64a616ba5528d457812e": "xplorer",
+			},
+		}, {
+			name: "custom network",
+			cfg: Config{
+				Network: &NetworkConfig{
+					RootDomain: "test",
+					Contracts: starknetid.Contracts{
+						StarknetId: "0x01",
+						Naming:     "0x02",
+					},
+				},
+				Subdomains: map[string]string{
+					"0x03": "sub",
+				},
+			},
+			wantName: "custom",
+			wantRoot: "test",
+			wantSubdomains: map[string]string{
+				"0000000000000000000000000000000000000000000000000000000000000003": "sub",
+			},
+		}, {
+			name: "custom network without contracts",
+			cfg: Config{
+				Network: &NetworkConfig{RootDomain: "test"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, err := tt.cfg.network()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantName, network.Name)
+			require.Equal(t, tt.wantRoot, network.RootDomain)
+			require.Equal(t, tt.wantStart, network.StartBlock)
+			require.Equal(t, tt.wantSubdomains, network.Subdomains())
+		})
+	}
+}
+
+func TestConfig_Subscriptions(t *testing.T) {
+	bytesFilter := func(address data.Felt) *grpc.BytesFilter {
+		return &grpc.BytesFilter{Eq: address.Bytes()}
+	}
+
+	// filters which were written by hand in build/dipdup.yml before network presets
+	expected := grpc.Subscription{
+		Head: true,
+		EventFilter: []*grpc.EventFilter{
+			{
+				Contract: bytesFilter(starknetid.AddressStarknetId),
+				Name: &grpc.StringFilter{In: []string{
+					"Transfer", "VerifierDataUpdate", "UserDataUpdate", "on_inft_equipped",
+				}},
+			}, {
+				Contract: bytesFilter(starknetid.AddressNaming),
+				Name: &grpc.StringFilter{In: []string{
+					"domain_to_addr_update", "addr_to_domain_update", "starknet_id_update", "domain_transfer", "domain_to_resolver_update",
+				}},
+			}, {
+				Contract: bytesFilter(starknetid.AddressBraavos),
+				Name:     &grpc.StringFilter{Eq: "domain_to_addr_update"},
+			}, {
+				Contract: bytesFilter(starknetid.AddressXplorer),
+				Name:     &grpc.StringFilter{Eq: "domain_to_addr_update"},
+			},
+		},
+		AddressFilter: []*grpc.AddressFilter{
+			{OnlyStarknet: true},
+		},
+	}
+
+	cfg := Config{
+		GRPC: &grpc.ClientConfig{
+			Subscriptions: map[string]grpc.Subscription{
+				"other": {Head: true},
+			},
+		},
+		Network: &NetworkConfig{Preset: "mainnet"},
+	}
+	network, err := cfg.network()
+	require.NoError(t, err)
+
+	subscriptions, err := cfg.subscriptions(network)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 2)
+	require.Contains(t, subscriptions, "other")
+	require.Equal(t, expected, subscriptions[defaultNetworkChannel])
+
+	cfg.Network.Channel = "other"
+	_, err = cfg.subscriptions(network)
+	require.Error(t, err)
+
+	cfg.Network = nil
+	subscriptions, err = cfg.subscriptions(network)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+}
+
+func TestConfig_SubscriptionsMaxLag(t *testing.T) {
+	tests := []struct {
+		name    string
+		sub     grpc.Subscription
+		api     *ApiConfig
+		wantErr bool
+	}{
+		{
+			name:    "quiet channel without head",
+			sub:     grpc.Subscription{EventFilter: []*grpc.EventFilter{{Name: &grpc.StringFilter{Eq: "Transfer"}}}},
+			api:     &ApiConfig{Bind: "0.0.0.0:9876", MaxLag: 900},
+			wantErr: true,
+		}, {
+			name: "without head and lag check",
+			sub:  grpc.Subscription{EventFilter: []*grpc.EventFilter{{Name: &grpc.StringFilter{Eq: "Transfer"}}}},
+			api:  &ApiConfig{Bind: "0.0.0.0:9876"},
+		}, {
+			name: "without head and api",
+			sub:  grpc.Subscription{EventFilter: []*grpc.EventFilter{{Name: &grpc.StringFilter{Eq: "Transfer"}}}},
+		}, {
+			name: "with head",
+			sub:  grpc.Subscription{Head: true},
+			api:  &ApiConfig{Bind: "0.0.0.0:9876", MaxLag: 900},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{
+				GRPC: &grpc.ClientConfig{
+					Subscriptions: map[string]grpc.Subscription{"other": tt.sub},
+				},
+				Api: tt.api,
+			}
+			_, err := cfg.subscriptions(starknetid.Mainnet)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestIndexer_StartBlock(t *testing.T) {
+	network := starknetid.Mainnet
+	network.StartBlock = 100
+	indexer := NewIndexer(storage.Backend{}, nil, network, nil)
+
+	tests := []struct {
+		name       string
+		lastHeight uint64
+		want       uint64
+	}{
+		{name: "before start block", lastHeight: 0, want: 99},
+		{name: "after start block", lastHeight: 150, want: 150},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := NewChannel("test", storage.Backend{}, network, nil, nil)
+			ch.blockCtx.state = &storage.State{LastHeight: tt.lastHeight}
+
+			sub := networkSubscription(network)
+			sub.AddressFilter = nil
+			require.NoError(t, indexer.actualFilters(context.Background(), ch, &sub))
+			for i := range sub.EventFilter {
+				require.Equal(t, tt.want, sub.EventFilter[i].Height.Gt)
+			}
+
+			req := sub.ToGrpcFilter()
+			require.IsType(t, &pb.IntegerFilter_Gt{}, req.Events[0].Height.Filter)
+		})
+	}
+}
+
+func TestConfig_Instances(t *testing.T) {
+	database := config.Database{
+		Kind:     config.DBKindPostgres,
+		Host:     "db",
+		Port:     5432,
+		User:     "dipdup",
+		Database: "starknet_id",
+	}
+	grpcConfig := &grpc.ClientConfig{ServerAddress: "127.0.0.1:7779"}
+	rpcConfig := &jsonrpc.Config{URL: "http://127.0.0.1:9545"}
+	archiveConfig := &archive.Config{Path: "events.ndjson.gz"}
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantNames   []string
+		wantSchemas []string
+		wantErr     bool
+	}{
+		{
+			name: "single network",
+			cfg: Config{
+				Config:  config.Config{Database: database},
+				GRPC:    grpcConfig,
+				Network: &NetworkConfig{Preset: "mainnet"},
+			},
+			wantNames:   []string{"mainnet"},
+			wantSchemas: []string{""},
+		}, {
+			name: "several networks",
+			cfg: Config{
+				Config: config.Config{Database: database},
+				Networks: []InstanceConfig{
+					{Name: "mainnet", Schema: "public", GRPC: grpcConfig, Network: &NetworkConfig{Preset: "mainnet"}},
+					{Name: "sepolia", GRPC: grpcConfig, Network: &NetworkConfig{Preset: "sepolia"}},
+				},
+			},
+			wantNames:   []string{"mainnet", "sepolia"},
+			wantSchemas: []string{"public", "sepolia"},
+		}, {
+			name: "duplicate name",
+			cfg: Config{
+				Networks: []InstanceConfig{
+					{Name: "mainnet", GRPC: grpcConfig},
+					{Name: "mainnet", Schema: "other", GRPC: grpcConfig},
+				},
+			},
+			wantErr: true,
+		}, {
+			name: "duplicate schema",
+			cfg: Config{
+				Networks: []InstanceConfig{
+					{Name: "mainnet", GRPC: grpcConfig},
+					{Name: "sepolia", Schema: "mainnet", GRPC: grpcConfig},
+				},
+			},
+			wantErr: true,
+		}, {
+			name: "invalid name",
+			cfg: Config{
+				Networks: []InstanceConfig{
+					{Name: "Main-net", GRPC: grpcConfig},
+				},
+			},
+			wantErr: true,
+		}, {
+			name: "top-level grpc with networks",
+			cfg: Config{
+				GRPC: grpcConfig,
+				Networks: []InstanceConfig{
+					{Name: "mainnet", GRPC: grpcConfig},
+				},
+			},
+			wantErr: true,
+		}, {
+			name: "invalid network",
+			cfg: Config{
+				Networks: []InstanceConfig{
+					{Name: "test", GRPC: grpcConfig, Network: &NetworkConfig{RootDomain: "test"}},
+				},
+			},
+			wantErr: true,
+		}, {
+			name: "json-rpc data source",
+			cfg: Config{
+				Config:  config.Config{Database: database},
+				RPC:     rpcConfig,
+				Network: &NetworkConfig{Preset: "mainnet"},
+			},
+			wantNames:   []string{"mainnet"},
+			wantSchemas: []string{""},
+		}, {
+			name: "grpc and json-rpc data sources",
+			cfg: Config{
+				GRPC:    grpcConfig,
+				RPC:     rpcConfig,
+				Network: &NetworkConfig{Preset: "mainnet"},
+			},
+			wantErr: true,
+		}, {
+			name: "without data source",
+			cfg: Config{
+				Networks: []InstanceConfig{
+					{Name: "mainnet", Network: &NetworkConfig{Preset: "mainnet"}},
+				},
+			},
+			wantErr: true,
+		}, {
+			name: "json-rpc without network",
+			cfg: Config{
+				RPC: rpcConfig,
+			},
+			wantErr: true,
+		}, {
+			name: "archive data source",
+			cfg: Config{
+				Config: config.Config{Database: database},
+				Networks: []InstanceConfig{
+					{Name: "mainnet", Archive: archiveConfig, Network: &NetworkConfig{Preset: "mainnet"}},
+				},
+			},
+			wantNames:   []string{"mainnet"},
+			wantSchemas: []string{"mainnet"},
+		}, {
+			name: "archive and grpc data sources",
+			cfg: Config{
+				GRPC:    grpcConfig,
+				Archive: archiveConfig,
+				Network: &NetworkConfig{Preset: "mainnet"},
+			},
+			wantErr: true,
+		}, {
+			name: "archive without network",
+			cfg: Config{
+				Archive: archiveConfig,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := tt.cfg.instances()
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, instances, len(tt.wantNames))
+			for i := range instances {
+				require.Equal(t, tt.wantNames[i], instances[i].Name)
+				require.Equal(t, tt.wantNames[i], instances[i].Network.Name)
+				require.Equal(t, tt.wantSchemas[i], instances[i].Database.SchemaName)
+				require.Equal(t, database.Host, instances[i].Database.Host)
+				require.Contains(t, instances[i].Subscriptions, defaultNetworkChannel)
+			}
+		})
+	}
+}
+
+func TestConfig_Instance(t *testing.T) {
+	grpcConfig := &grpc.ClientConfig{ServerAddress: "127.0.0.1:7779"}
+	cfg := Config{
+		Networks: []InstanceConfig{
+			{Name: "mainnet", GRPC: grpcConfig, Network: &NetworkConfig{Preset: "mainnet"}},
+			{Name: "sepolia", GRPC: grpcConfig, Network: &NetworkConfig{Preset: "sepolia"}},
+		},
+	}
+
+	inst, err := cfg.instance("")
+	require.NoError(t, err)
+	require.Equal(t, "mainnet", inst.Name)
+
+	inst, err = cfg.instance("sepolia")
+	require.NoError(t, err)
+	require.Equal(t, starknetid.Sepolia.Contracts.Naming, inst.Network.Contracts.Naming)
+
+	_, err = cfg.instance("goerli")
+	require.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// rewinder - rolls back indexed data, see postgres.Storage.Rewind
+type rewinder interface {
+	Rewind(ctx context.Context, channels []string, height uint64) error
+}
+
+func newReindexCmd(connect storageConnector) *cobra.Command {
+	var (
+		from    uint64
+		channel string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Rewind indexer state to reindex data from the height. The indexer has to be stopped.",
+		Long: `Rewind indexer state to reindex data from the height. The indexer has to be stopped.
+
+Derived data is shared by channels, so all channels are rewound together:
+--channel is accepted only if it's the only indexed channel.
+With --from 0 all derived data is truncated and states of channels are removed.
+With --from HEIGHT states of channels are rewound to HEIGHT-1 and subdomains registered since HEIGHT are removed.
+Other data isn't rolled back, it's overwritten during reindexing: domains, fields and starknet ids
+minted since HEIGHT are replaced by replayed events.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strg, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer strg.Close()
+
+			channels, err := reindexChannels(cmd.Context(), strg, channel, from)
+			if err != nil {
+				return err
+			}
+			if err := strg.Rewinder.Rewind(cmd.Context(), channels, from); err != nil {
+				return errors.Wrap(err, "rewind")
+			}
+
+			if from == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "derived data is truncated, all channels will be reindexed from the beginning")
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "channels %v will be reindexed from height %d\n", channels, from)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint64Var(&from, "from", 0, "height to reindex from, 0 truncates all derived data")
+	cmd.Flags().StringVar(&channel, "channel", "", "name of the channel to rewind, all channels if empty")
+	_ = cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+// reindexChannels - validates request and returns names of channels to rewind
+func reindexChannels(ctx context.Context, strg commandStorage, channel string, from uint64) ([]string, error) {
+	states, err := strg.States.List(ctx, 100, 0, sdk.SortOrderAsc)
+	if err != nil && !strg.States.IsNoRows(err) {
+		return nil, err
+	}
+
+	if channel != "" {
+		found := false
+		for i := range states {
+			found = found || states[i].Name == channel
+		}
+		if !found {
+			return nil, errors.Errorf("unknown channel: %s", channel)
+		}
+		// derived tables are shared by channels, so single channel can't be rewound without losing data of others
+		if len(states) > 1 {
+			return nil, errors.Wrap(storage.ErrPartialRewind, "remove --channel")
+		}
+	}
+
+	if len(states) == 0 {
+		return nil, errors.New("nothing to reindex: there are no indexed channels")
+	}
+	channels := make([]string, len(states))
+	for i := range states {
+		if from > states[i].LastHeight+1 {
+			return nil, errors.Errorf("channel %s is indexed up to %d: can't reindex from %d", states[i].Name, states[i].LastHeight, from)
+		}
+		channels[i] = states[i].Name
+	}
+	return channels, nil
+}
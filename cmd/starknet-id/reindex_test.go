@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/sqlite"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReindexCmd(t *testing.T) {
+	states := testStates{
+		states: []*storage.State{
+			{Name: "naming", LastHeight: 50},
+			{Name: "starknet_id", LastHeight: 100},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		states       []*storage.State
+		args         []string
+		wantErr      bool
+		wantChannels []string
+		wantHeight   uint64
+	}{
+		{
+			name:         "the only channel",
+			states:       []*storage.State{{Name: "starknet_id", LastHeight: 100}},
+			args:         []string{"--from", "90", "--channel", "starknet_id"},
+			wantChannels: []string{"starknet_id"},
+			wantHeight:   90,
+		}, {
+			name:    "one of channels",
+			args:    []string{"--from", "90", "--channel", "starknet_id"},
+			wantErr: true,
+		}, {
+			name:         "all channels",
+			args:         []string{"--from", "40"},
+			wantChannels: []string{"naming", "starknet_id"},
+			wantHeight:   40,
+		}, {
+			name:         "full reindex",
+			args:         []string{"--from", "0"},
+			wantChannels: []string{"naming", "starknet_id"},
+			wantHeight:   0,
+		}, {
+			name:    "full reindex of one channel",
+			args:    []string{"--from", "0", "--channel", "starknet_id"},
+			wantErr: true,
+		}, {
+			name:    "unknown channel",
+			args:    []string{"--from", "10", "--channel", "unknown"},
+			wantErr: true,
+		}, {
+			name:    "height is ahead of channel",
+			args:    []string{"--from", "90"},
+			wantErr: true,
+		}, {
+			name:    "without height",
+			args:    []string{"--channel", "starknet_id"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewinder := new(testRewinder)
+			cmdStates := states
+			if tt.states != nil {
+				cmdStates = testStates{states: tt.states}
+			}
+			cmd := newReindexCmd(testConnector(commandStorage{
+				States:   cmdStates,
+				Rewinder: rewinder,
+			}))
+
+			_, err := executeCommand(cmd, tt.args...)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Zero(t, rewinder.calls)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 1, rewinder.calls)
+			require.Equal(t, tt.wantChannels, rewinder.channels)
+			require.Equal(t, tt.wantHeight, rewinder.height)
+		})
+	}
+}
+
+func TestReindexCmd_NoChannels(t *testing.T) {
+	rewinder := new(testRewinder)
+	cmd := newReindexCmd(testConnector(commandStorage{
+		States:   testStates{},
+		Rewinder: rewinder,
+	}))
+
+	_, err := executeCommand(cmd, "--from", "0")
+	require.Error(t, err)
+	require.Zero(t, rewinder.calls)
+}
+
+func TestReindexCmd_ReplayMint(t *testing.T) {
+	ctx := context.Background()
+	strg, err := sqlite.Create(ctx, sqliteDatabase(t, "starknet-id.db"))
+	require.NoError(t, err)
+	defer strg.Close()
+
+	var (
+		alice = []byte{0xa1}
+		bob   = []byte{0xb0}
+		store = NewStore(strg, zerolog.Nop())
+	)
+	index := func() {
+		blockCtx := newBlockContext(strg.Subdomains, strg.Addresses, starknetid.Mainnet, nil)
+		blockCtx.starknetIds.Set("1", NewTypeWithAction(&storage.StarknetId{StarknetId: decimal.NewFromInt(1), OwnerAddress: alice}, ActionInsert))
+		blockCtx.updateState("starknet_id", 100)
+		require.NoError(t, store.Save(ctx, blockCtx))
+
+		blockCtx.starknetIds.Set("1", NewTypeWithAction(&storage.StarknetId{StarknetId: decimal.NewFromInt(1), OwnerAddress: bob}, ActionUpdate))
+		blockCtx.updateState("starknet_id", 110)
+		require.NoError(t, store.Save(ctx, blockCtx))
+	}
+	index()
+
+	cmd := newReindexCmd(testConnector(commandStorage{
+		States:   strg.State,
+		Rewinder: strg,
+	}))
+	_, err = executeCommand(cmd, "--from", "100")
+	require.NoError(t, err)
+
+	// the mint is replayed over the existing starknet id
+	index()
+
+	state, err := strg.State.ByName(ctx, "starknet_id")
+	require.NoError(t, err)
+	require.EqualValues(t, 110, state.LastHeight)
+
+	id, err := strg.StarknetIds.GetByStarknetId(ctx, decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Equal(t, bob, id.OwnerAddress)
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/dipdup-io/starknet-id/internal/storage/sqlite"
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/go-lib/database"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/schema"
+	"gopkg.in/yaml.v3"
+)
+
+const replayDirectory = "testdata/replay"
+
+// replayTables - tables which are compared with golden files and ordering of their rows.
+// Table without golden file is expected to be empty.
+var replayTables = map[string]string{
+	"address":     "id",
+	"starknet_id": "starknet_id",
+	"domain":      "domain",
+	"subdomain":   "subdomain",
+	"field":       "owner_id, namespace, name",
+	"state":       "name",
+}
+
+// ReplayTestSuite - replays recorded subscription streams and compares database state with golden files
+type ReplayTestSuite struct {
+	suite.Suite
+	psqlContainer *database.PostgreSQLContainer
+	storage       postgres.Storage
+	db            *sql.DB
+}
+
+// SetupSuite -
+func (s *ReplayTestSuite) SetupSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	psqlContainer, cfg, err := newPostgresContainer(ctx)
+	s.Require().NoError(err)
+	s.psqlContainer = psqlContainer
+
+	storage, err := postgres.Create(ctx, cfg)
+	s.Require().NoError(err)
+	s.storage = storage
+
+	db, err := sql.Open("postgres", s.psqlContainer.GetDSN())
+	s.Require().NoError(err)
+	s.db = db
+}
+
+// newPostgresContainer - starts database for tests which run the indexer
+func newPostgresContainer(ctx context.Context) (*database.PostgreSQLContainer, config.Database, error) {
+	psqlContainer, err := database.NewPostgreSQLContainer(ctx, database.PostgreSQLContainerConfig{
+		User:     "user",
+		Password: "password",
+		Database: "db_test",
+		Port:     5432,
+		Image:    "postgres:15",
+	})
+	if err != nil {
+		return nil, config.Database{}, err
+	}
+	return psqlContainer, config.Database{
+		Kind:     config.DBKindPostgres,
+		User:     psqlContainer.Config.User,
+		Database: psqlContainer.Config.Database,
+		Password: psqlContainer.Config.Password,
+		Host:     psqlContainer.Config.Host,
+		Port:     psqlContainer.MappedPort().Int(),
+	}, nil
+}
+
+// TearDownSuite -
+func (s *ReplayTestSuite) TearDownSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	s.Require().NoError(s.db.Close())
+	s.Require().NoError(s.storage.Close())
+	s.Require().NoError(s.psqlContainer.Terminate(ctx))
+}
+
+func TestSuiteReplay_Run(t *testing.T) {
+	suite.Run(t, new(ReplayTestSuite))
+}
+
+func (s *ReplayTestSuite) TestReplay() {
+	replayScenarios(s.T(), func(t *testing.T, dir string) {
+		s.resetDatabase(t)
+		replay(t, s.storage.Backend(), dir)
+		assertGolden(t, filepath.Join(dir, "golden"), func(table, orderBy string) []map[string]any {
+			return s.tableRows(t, table, orderBy)
+		})
+	})
+}
+
+// TestReplayMemory - replays scenarios through in-memory storage, so handlers are checked without database
+func TestReplayMemory(t *testing.T) {
+	replayScenarios(t, func(t *testing.T, dir string) {
+		strg := memory.New()
+		replay(t, strg.Backend(), dir)
+		assertGolden(t, filepath.Join(dir, "golden"), func(table, orderBy string) []map[string]any {
+			return backendRows(t, strg.Backend(), table, orderBy)
+		})
+	})
+}
+
+// TestReplaySQLite - replays scenarios through SQLite storage, so upserts are checked by the database engine without Docker
+func TestReplaySQLite(t *testing.T) {
+	replayScenarios(t, func(t *testing.T, dir string) {
+		strg, err := sqlite.Create(context.Background(), config.Database{
+			Kind: config.DBKindSqlite,
+			Path: filepath.Join(t.TempDir(), "replay.db"),
+		})
+		require.NoError(t, err)
+		defer strg.Close()
+
+		replay(t, strg.Backend(), dir)
+		assertGolden(t, filepath.Join(dir, "golden"), func(table, orderBy string) []map[string]any {
+			return backendRows(t, strg.Backend(), table, orderBy)
+		})
+	})
+}
+
+func replayScenarios(t *testing.T, run func(t *testing.T, dir string)) {
+	scenarios, err := os.ReadDir(replayDirectory)
+	require.NoError(t, err)
+
+	for _, scenario := range scenarios {
+		if !scenario.IsDir() {
+			continue
+		}
+		dir := filepath.Join(replayDirectory, scenario.Name())
+		t.Run(scenario.Name(), func(t *testing.T) {
+			run(t, dir)
+		})
+	}
+}
+
+func (s *ReplayTestSuite) resetDatabase(t *testing.T) {
+	tables := make([]string, 0, len(replayTables))
+	for table := range replayTables {
+		tables = append(tables, table)
+	}
+	_, err := s.db.Exec(fmt.Sprintf("TRUNCATE %s RESTART IDENTITY", strings.Join(tables, ", ")))
+	require.NoError(t, err)
+}
+
+func replay(t *testing.T, backend storage.Backend, dir string) {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	files, err := filepath.Glob(filepath.Join(dir, "stream.*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1, "scenario should contain single stream file")
+
+	messages, err := stream.Load(files[0])
+	require.NoError(t, err)
+
+	channel := NewChannel("replay", backend, starknetid.Mainnet, nil, nil)
+	for i := range messages {
+		require.NoError(t, channel.process(ctx, messages[i]), "message %d", i)
+	}
+	require.False(t, channel.IsFailed())
+	require.NoError(t, channel.Close())
+}
+
+func assertGolden(t *testing.T, dir string, tableRows func(table, orderBy string) []map[string]any) {
+	for table, orderBy := range replayTables {
+		expected, err := loadGolden(filepath.Join(dir, table+".yml"))
+		require.NoError(t, err, table)
+
+		actual := tableRows(table, orderBy)
+		require.Len(t, actual, len(expected), "rows count of %s", table)
+
+		for i := range expected {
+			for column, value := range expected[i] {
+				actualValue, ok := actual[i][column]
+				require.True(t, ok, "unknown column %s.%s", table, column)
+				require.True(t, equalGoldenValue(value, actualValue), "%s[%d].%s: expected %v, got %v", table, i, column, value, actualValue)
+			}
+		}
+	}
+}
+
+func (s *ReplayTestSuite) tableRows(t *testing.T, table, orderBy string) []map[string]any {
+	rows, err := s.db.Query(fmt.Sprintf("SELECT row_to_json(t)::text FROM %s t ORDER BY %s", table, orderBy))
+	require.NoError(t, err)
+	defer rows.Close()
+
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		var raw string
+		require.NoError(t, rows.Scan(&raw))
+
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.UseNumber()
+		row := make(map[string]any)
+		require.NoError(t, decoder.Decode(&row))
+		result = append(result, row)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+// backendRows - returns rows of the table read by the backend repositories in the form of Postgres row_to_json: columns are named
+// as bun does, bytea is hex with \x prefix and zero values of nullzero columns are null
+func backendRows(t *testing.T, strg storage.Backend, table, orderBy string) []map[string]any {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	var (
+		models []any
+		err    error
+	)
+	switch table {
+	case "address":
+		models, err = listModels(ctx, strg.Addresses)
+	case "starknet_id":
+		models, err = listModels(ctx, strg.StarknetIds)
+	case "domain":
+		models, err = listModels(ctx, strg.Domains)
+	case "subdomain":
+		models, err = listModels(ctx, strg.Subdomains)
+	case "field":
+		models, err = listModels(ctx, strg.Fields)
+	case "state":
+		models, err = listModels(ctx, strg.State)
+	default:
+		t.Fatalf("unknown table %s", table)
+	}
+	require.NoError(t, err)
+
+	result := make([]map[string]any, len(models))
+	for i := range models {
+		result[i] = modelRow(models[i])
+	}
+
+	columns := strings.Split(orderBy, ",")
+	sort.SliceStable(result, func(i, j int) bool {
+		for _, column := range columns {
+			column = strings.TrimSpace(column)
+			if c := compareColumn(result[i][column], result[j][column]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	return result
+}
+
+func listModels[M sdk.Model](ctx context.Context, table sdk.Table[M]) ([]any, error) {
+	models, err := table.List(ctx, math.MaxInt32, 0, sdk.SortOrderAsc)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]any, len(models))
+	for i := range models {
+		result[i] = models[i]
+	}
+	return result, nil
+}
+
+var (
+	decimalType     = reflect.TypeOf(decimal.Decimal{})
+	nullDecimalType = reflect.TypeOf(decimal.NullDecimal{})
+	timeType        = reflect.TypeOf(time.Time{})
+)
+
+var bunTables = schema.NewTables(pgdialect.New())
+
+func modelRow(model any) map[string]any {
+	value := reflect.Indirect(reflect.ValueOf(model))
+	row := make(map[string]any)
+	for _, field := range bunTables.Get(value.Type()).Fields {
+		fieldValue := value.FieldByIndex(field.Index)
+		if (field.IsPtr && fieldValue.IsNil()) || (field.NullZero && field.IsZero(fieldValue)) {
+			row[field.Name] = nil
+			continue
+		}
+		row[field.Name] = columnValue(reflect.Indirect(fieldValue))
+	}
+	return row
+}
+
+func columnValue(value reflect.Value) any {
+	switch {
+	case value.Type() == decimalType:
+		return value.Interface().(decimal.Decimal).String()
+	case value.Type() == nullDecimalType:
+		nullDecimal := value.Interface().(decimal.NullDecimal)
+		if !nullDecimal.Valid {
+			return nil
+		}
+		return nullDecimal.Decimal.String()
+	case value.Type() == timeType:
+		return value.Interface().(time.Time).Format(time.RFC3339Nano)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		return `\x` + hex.EncodeToString(value.Bytes())
+	default:
+		return value.Interface()
+	}
+}
+
+// compareColumn - compares numbers numerically and other values as strings
+func compareColumn(a, b any) int {
+	left, leftErr := decimal.NewFromString(fmt.Sprint(a))
+	right, rightErr := decimal.NewFromString(fmt.Sprint(b))
+	if leftErr == nil && rightErr == nil {
+		return left.Cmp(right)
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// goldenValue - value of the golden file column. Text of the scalar is kept as is, so felts and big numbers aren't
+// converted by YAML.
+type goldenValue struct {
+	Text string
+	Null bool
+}
+
+func (v goldenValue) String() string {
+	if v.Null {
+		return "null"
+	}
+	return v.Text
+}
+
+// loadGolden - reads rows of the table from the file in testfixtures format. Missing file means empty table.
+// Only columns present in the file are compared, so columns depending on processing order (e.g. ids) may be omitted.
+func loadGolden(path string) ([]map[string]goldenValue, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+	list := node.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s: golden file should contain list of rows", path)
+	}
+
+	rows := make([]map[string]goldenValue, 0, len(list.Content))
+	for _, item := range list.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s: row at line %d should be a mapping", path, item.Line)
+		}
+		row := make(map[string]goldenValue, len(item.Content)/2)
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			value := item.Content[i+1]
+			row[item.Content[i].Value] = goldenValue{
+				Text: value.Value,
+				Null: value.ShortTag() == "!!null",
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// equalGoldenValue - compares golden value with the column value received as JSON from Postgres:
+// bytea is compared as 0x-prefixed hex and timestamps are compared as instants.
+func equalGoldenValue(expected goldenValue, actual any) bool {
+	if actual == nil {
+		return expected.Null
+	}
+	if expected.Null {
+		return false
+	}
+
+	var value string
+	switch typed := actual.(type) {
+	case string:
+		value = typed
+		if strings.HasPrefix(value, `\x`) {
+			return strings.EqualFold("0x"+value[2:], expected.Text)
+		}
+	default:
+		value = fmt.Sprint(typed)
+	}
+
+	if expectedTime, err := time.Parse(time.RFC3339, expected.Text); err == nil {
+		actualTime, err := time.Parse(time.RFC3339, value)
+		return err == nil && expectedTime.Equal(actualTime)
+	}
+	return value == expected.Text
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newResolveCmd(connect storageConnector) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resolve <domain|address>",
+		Short: "Resolve domain to address or address to domains using the database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strg, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer strg.Close()
+
+			query := strings.ToLower(strings.TrimSpace(args[0]))
+
+			var domains []storage.Domain
+			if strings.HasPrefix(query, "0x") {
+				address, err := decodeAddress(query)
+				if err != nil {
+					return errors.Wrap(err, "invalid address")
+				}
+				domains, err = strg.Domains.ListByAddress(cmd.Context(), address)
+				if err != nil {
+					return err
+				}
+			} else {
+				if !strings.HasSuffix(query, "."+strg.RootDomain) {
+					query = fmt.Sprintf("%s.%s", query, strg.RootDomain)
+				}
+				domain, err := strg.Domains.GetByName(cmd.Context(), query)
+				switch {
+				case err == nil:
+					// domain cleared by zero address doesn't resolve
+					if len(domain.AddressHash) > 0 {
+						domains = append(domains, domain)
+					}
+				case strg.Domains.IsNoRows(err):
+				default:
+					return err
+				}
+			}
+
+			if len(domains) == 0 {
+				return errors.Errorf("nothing found: %s", args[0])
+			}
+			return printDomains(cmd.OutOrStdout(), domains, time.Now())
+		},
+	}
+}
+
+func printDomains(w io.Writer, domains []storage.Domain, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "DOMAIN\tADDRESS\tSTARKNET ID\tEXPIRY\tSTATUS\n")
+	for i := range domains {
+		status := "active"
+		if !domains[i].EffectiveExpiry.After(now) {
+			status = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			domains[i].Domain,
+			encoding.EncodeHex(domains[i].AddressHash),
+			domains[i].Owner.String(),
+			domains[i].EffectiveExpiry.UTC().Format(time.RFC3339),
+			status,
+		)
+	}
+	return tw.Flush()
+}
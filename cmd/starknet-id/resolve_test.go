@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func (td testDomains) ListByAddress(ctx context.Context, address []byte) ([]storage.Domain, error) {
+	result := make([]storage.Domain, 0)
+	for _, domain := range td.domains {
+		if bytes.Equal(domain.AddressHash, address) {
+			result = append(result, domain)
+		}
+	}
+	return result, nil
+}
+
+func TestResolveCmd(t *testing.T) {
+	address := encoding.MustDecodeHex("0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8")
+	domains := testDomains{
+		domains: map[string]storage.Domain{
+			"fricoben.stark": {
+				Domain:          "fricoben.stark",
+				AddressHash:     address,
+				Owner:           decimal.NewFromInt(1),
+				Expiry:          time.Now().Add(time.Hour),
+				EffectiveExpiry: time.Now().Add(time.Hour),
+			},
+			"expired.stark": {
+				Domain:          "expired.stark",
+				AddressHash:     []byte{0x01},
+				Owner:           decimal.NewFromInt(2),
+				Expiry:          time.Now().Add(-time.Hour),
+				EffectiveExpiry: time.Now().Add(-time.Hour),
+			},
+			"cleared.stark": {
+				Domain:          "cleared.stark",
+				Owner:           decimal.NewFromInt(3),
+				Expiry:          time.Now().Add(time.Hour),
+				EffectiveExpiry: time.Now().Add(time.Hour),
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		arg      string
+		contains []string
+		wantErr  bool
+	}{
+		{
+			name:     "by domain",
+			arg:      "fricoben.stark",
+			contains: []string{"fricoben.stark", encoding.EncodeHex(address), "active"},
+		}, {
+			name:     "without root domain",
+			arg:      "FriCoBen",
+			contains: []string{"fricoben.stark"},
+		}, {
+			name:     "expired",
+			arg:      "expired.stark",
+			contains: []string{"expired.stark", "expired"},
+		}, {
+			name:     "by short address",
+			arg:      "0x327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8",
+			contains: []string{"fricoben.stark"},
+		}, {
+			name:    "cleared domain",
+			arg:     "cleared.stark",
+			wantErr: true,
+		}, {
+			name:    "unknown domain",
+			arg:     "unknown.stark",
+			wantErr: true,
+		}, {
+			name:    "unknown address",
+			arg:     "0x02",
+			wantErr: true,
+		}, {
+			name:    "invalid address",
+			arg:     "0xzz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newResolveCmd(testConnector(commandStorage{Domains: domains, RootDomain: "stark"}))
+			output, err := executeCommand(cmd, tt.arg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				require.Contains(t, output, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/dipdup-io/starknet-id/internal/fakenode"
+	"github.com/dipdup-io/starknet-id/internal/jsonrpc"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexingFromJsonRpc(t *testing.T) {
+	const alice = "0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8"
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	node := fakenode.New()
+	defer node.Close()
+
+	// proxies don't declare events, so they are decoded by the embedded ABI
+	for _, contract := range []data.Felt{starknetid.AddressStarknetId, starknetid.AddressNaming} {
+		node.SetAbi(contract.String(), []byte(`[]`))
+	}
+	node.AddBlocks(fakenode.Block{
+		Number:       100,
+		Timestamp:    1700000000,
+		Transactions: []string{"0xa"},
+		Events: []fakenode.Event{
+			{
+				Contract:    starknetid.AddressStarknetId.String(),
+				Name:        starknetid.EventTransfer,
+				Data:        []string{"0x0", alice, "0x1", "0x0"},
+				Transaction: "0xa",
+			}, {
+				Contract:    starknetid.AddressNaming.String(),
+				Name:        starknetid.EventStarknetIdUpdate,
+				Data:        []string{"0x1", "0x15d246f6c1b", "0x1", "0x7fffffff"},
+				Transaction: "0xa",
+			}, {
+				Contract:    starknetid.AddressNaming.String(),
+				Name:        starknetid.EventDomainToAddrUpdate,
+				Data:        []string{"0x1", "0x15d246f6c1b", alice},
+				Transaction: "0xa",
+			},
+		},
+	})
+
+	cfg := Config{
+		RPC: &jsonrpc.Config{
+			URL:          node.URL(),
+			PollInterval: 1,
+		},
+		Network: &NetworkConfig{
+			Preset: "mainnet",
+		},
+	}
+	cfg.Database = sqliteDatabase(t, "starknet-id.db")
+	instances, err := cfg.instances()
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	runner, err := newNetworkRunner(ctx, cfg, instances[0], nil)
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	require.NoError(t, runner.Start(ctx, errs))
+
+	waitRunnerHeight(t, ctx, runner, 100)
+
+	domain, err := runner.db.Backend().Domains.GetByName(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	require.Equal(t, "1", domain.Owner.String())
+	require.Equal(t, data.Felt(alice).Bytes(), domain.AddressHash)
+	// the first id is assigned to the contract of Transfer event
+	require.EqualValues(t, 2, domain.AddressId)
+
+	// new blocks are polled
+	node.AddBlocks(fakenode.Block{
+		Number:       101,
+		Timestamp:    1700000100,
+		Transactions: []string{"0xb"},
+		Events: []fakenode.Event{
+			{
+				Contract:    starknetid.AddressNaming.String(),
+				Name:        starknetid.EventDomainTransfer,
+				Data:        []string{"0x1", "0x15d246f6c1b", "0x1", "0x3"},
+				Transaction: "0xb",
+			},
+		},
+	})
+	waitRunnerHeight(t, ctx, runner, 101)
+
+	domain, err = runner.db.Backend().Domains.GetByName(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	require.Equal(t, "3", domain.Owner.String())
+	require.False(t, runner.IsFailed(defaultNetworkChannel))
+
+	require.NoError(t, runner.Stop(ctx))
+	cancel()
+	require.NoError(t, runner.Close())
+	require.Empty(t, errs)
+}
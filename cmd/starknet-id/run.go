@@ -0,0 +1,468 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/archive"
+	"github.com/dipdup-io/starknet-id/internal/identity"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-net/go-lib/hasura"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func newRunCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run",
+		Short: "Run the indexer (default command)",
+		Args:  cobra.NoArgs,
+		RunE:  runE,
+	}
+}
+
+func runE(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	runIndexer(cfg, nil)
+	return nil
+}
+
+// recording - network whose data source messages are written to archive file while indexing
+type recording struct {
+	Network string
+	Path    string
+}
+
+// runIndexer - runs the indexer until SIGINT or SIGTERM. If recording is set, messages of the network data source
+// are written to archive file.
+func runIndexer(cfg Config, rec *recording) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	instances, err := cfg.instances()
+	if err != nil {
+		log.Panic().Err(err).Msg("network configuration")
+		return
+	}
+
+	metrics := NewMetrics(cfg.Prometheus)
+
+	networks := make([]*networkRunner, 0, len(instances))
+	for i := range instances {
+		network, err := newNetworkRunner(ctx, cfg, instances[i], metrics)
+		if err != nil {
+			log.Panic().Err(err).Str("network", instances[i].Name).Msg("network initialization")
+			return
+		}
+		networks = append(networks, network)
+	}
+
+	if rec != nil {
+		network, err := findNetwork(networks, rec.Network)
+		if err != nil {
+			log.Panic().Err(err).Msg("archive recording")
+			return
+		}
+		if err := network.record(rec.Path); err != nil {
+			log.Panic().Err(err).Str("network", network.Name).Msg("archive recording")
+			return
+		}
+	}
+
+	metrics.Start(ctx)
+
+	errs := make(chan error, len(networks))
+	for i := range networks {
+		if err := networks[i].Start(ctx, errs); err != nil {
+			log.Panic().Err(err).Str("network", networks[i].Name).Msg("network start")
+			return
+		}
+	}
+
+	var api *Api
+	if cfg.Api != nil {
+		api = NewApi(*cfg.Api)
+		maxLag := time.Duration(cfg.Api.MaxLag) * time.Second
+		api.Handle("/healthz", Health{}.Live)
+
+		if len(cfg.Networks) == 0 {
+			network := networks[0]
+			api.Handle("/readyz", network.Health(maxLag).Ready)
+			profiles := network.Profiles()
+			api.Handle(identityPath, profiles.Get)
+			api.Handle(lookupPath, profiles.Lookup)
+		} else {
+			group := NewHealthGroup()
+			for _, network := range networks {
+				group.Add(network.Name, network.Health(maxLag))
+
+				prefix := "/" + network.Name
+				profiles := network.Profiles()
+				api.Handle(prefix+identityPath, http.StripPrefix(prefix, http.HandlerFunc(profiles.Get)).ServeHTTP)
+				api.Handle(prefix+lookupPath, http.StripPrefix(prefix, http.HandlerFunc(profiles.Lookup)).ServeHTTP)
+			}
+			api.Handle("/readyz", group.Ready)
+		}
+		api.Start()
+	}
+
+	var dnsServer *DnsServer
+	if cfg.Dns != nil {
+		network, err := findNetwork(networks, cfg.Dns.Network)
+		if err != nil {
+			log.Panic().Err(err).Msg("DNS server")
+			return
+		}
+		dnsServer = NewDnsServer(*cfg.Dns, network.db.Backend().Domains, network.Network.RootDomain)
+		if err := dnsServer.Start(); err != nil {
+			log.Panic().Err(err).Msg("start DNS server")
+			return
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case <-signals:
+	case err := <-errs:
+		// the replica stops and is restarted as a follower, so a leader which lost the lock never writes again
+		log.Error().Err(err).Msg("stopping indexer")
+	}
+
+	for i := range networks {
+		if err := networks[i].Stop(ctx); err != nil {
+			log.Panic().Err(err).Str("network", networks[i].Name).Msg("unsubscribe")
+			return
+		}
+	}
+
+	cancel()
+
+	if dnsServer != nil {
+		if err := dnsServer.Close(); err != nil {
+			log.Panic().Err(err).Msg("closing DNS server")
+		}
+	}
+	if api != nil {
+		if err := api.Close(); err != nil {
+			log.Panic().Err(err).Msg("closing HTTP API")
+		}
+	}
+	for i := range networks {
+		if err := networks[i].Close(); err != nil {
+			log.Panic().Err(err).Str("network", networks[i].Name).Msg("closing network")
+		}
+	}
+	if err := metrics.Close(); err != nil {
+		log.Panic().Err(err).Msg("closing metrics server")
+	}
+
+	close(signals)
+}
+
+// networkRunner - database, data source and modules of the network indexed by the process. In HA mode the network
+// is indexed only if the replica is elected as leader.
+type networkRunner struct {
+	instance
+
+	cfg           Config
+	db            Database
+	pg            *postgres.Storage // nil if the network is stored in SQLite
+	source        DataSource
+	recorder      *archive.Recorder // nil if messages of data source aren't recorded
+	indexer       *Indexer
+	changesLogger *ChangesLogger
+	metrics       *Metrics
+	elector       *Elector
+
+	indexing bool
+	stopped  bool
+	mx       *sync.RWMutex
+	wg       *sync.WaitGroup
+}
+
+// newNetworkRunner - connects to the database and connects modules of the network. Without HA mode the network is prepared
+// for indexing immediately, in HA mode it's done by the elected leader and followers don't write.
+func newNetworkRunner(ctx context.Context, cfg Config, inst instance, metrics *Metrics) (*networkRunner, error) {
+	db, pg, err := openDatabase(ctx, cfg, inst)
+	if err != nil {
+		return nil, errors.Wrap(err, "database creation")
+	}
+
+	metrics = metrics.Network(inst.Name, db.Backend())
+
+	source, err := newDataSource(inst, db.Backend().Addresses)
+	if err != nil {
+		return nil, errors.Wrap(err, "data source creation")
+	}
+	// blocks of the leader are written only while it holds the lock
+	backend := db.Backend()
+	var lock *postgres.LeaderLock
+	if cfg.HA != nil {
+		lock = postgres.NewLeaderLock(pg.Connection(), cfg.HA.interval())
+		backend = pg.LeaderBackend(lock)
+	}
+	indexer := NewIndexer(backend, source, inst.Network, metrics)
+
+	if err := modules.Connect(source, indexer, grpc.OutputMessages, InputName); err != nil {
+		return nil, errors.Wrap(err, "module connect")
+	}
+
+	changesLogger := NewChangesLogger(inst.Name)
+	if err := modules.Connect(indexer, changesLogger, OutputName, InputName); err != nil {
+		return nil, errors.Wrap(err, "module connect")
+	}
+
+	n := &networkRunner{
+		instance:      inst,
+		cfg:           cfg,
+		db:            db,
+		pg:            pg,
+		source:        source,
+		indexer:       indexer,
+		changesLogger: changesLogger,
+		metrics:       metrics,
+		mx:            new(sync.RWMutex),
+		wg:            new(sync.WaitGroup),
+	}
+
+	if lock != nil {
+		n.elector = NewElector(
+			lock,
+			cfg.HA.interval(),
+			log.With().Str("network", inst.Name).Logger(),
+		)
+		return n, nil
+	}
+
+	if err := n.setup(ctx); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// setup - applies migrations in HA mode, decodes stored fields and creates views and hasura metadata.
+// SQLite storage creates its views itself.
+func (n *networkRunner) setup(ctx context.Context) error {
+	if n.elector != nil {
+		if err := postgres.Migrate(ctx, n.pg.Connection(), n.Database.SchemaName); err != nil {
+			return errors.Wrap(err, "database migration")
+		}
+	}
+	if err := decodeStoredFields(ctx, n.db.Backend().Fields); err != nil {
+		return errors.Wrap(err, "decoding stored fields")
+	}
+	if n.pg == nil {
+		return nil
+	}
+	views, changedViews, err := createViews(ctx, *n.pg, n.cfg.Views)
+	if err != nil {
+		return errors.Wrap(err, "create views")
+	}
+
+	if n.cfg.Hasura == nil {
+		return nil
+	}
+
+	if len(n.cfg.Networks) > 0 {
+		untrackViews(ctx, n.cfg.Hasura, n.Name, n.Database.SchemaName, changedViews)
+
+		if err := trackNetwork(ctx, n.cfg.Hasura, n.instance, append(views, postgres.SearchResultView)); err != nil {
+			return errors.Wrap(err, "hasura initialization")
+		}
+		return nil
+	}
+
+	untrackViews(ctx, n.cfg.Hasura, n.cfg.Hasura.Source.Name, "", changedViews)
+
+	models := make([]any, len(storage.Models))
+	for i := range storage.Models {
+		models[i] = storage.Models[i]
+	}
+
+	if err := hasura.Create(ctx, hasura.GenerateArgs{
+		Config:               n.cfg.Hasura,
+		DatabaseConfig:       n.Database,
+		Models:               models,
+		Views:                append(views, postgres.SearchResultView),
+		CustomConfigurations: trackFunctions(n.cfg.Hasura.Source.Name, n.Database.SchemaName),
+	}); err != nil {
+		return errors.Wrap(err, "hasura initialization")
+	}
+	return nil
+}
+
+// Start - connects to the data source and starts indexing. In HA mode the replica campaigns for leadership in background
+// and starts indexing when it's elected. Errors of the leader and lost leadership are sent to errs.
+func (n *networkRunner) Start(ctx context.Context, errs chan<- error) error {
+	log.Info().Str("network", n.Name).Str("source", n.source.Name()).Msg("connecting to data source...")
+	if err := n.source.Connect(ctx); err != nil {
+		return errors.Wrap(err, "data source connect")
+	}
+	log.Info().Str("network", n.Name).Msg("connected")
+
+	if n.elector == nil {
+		return n.index(ctx)
+	}
+
+	n.metrics.SetLeader(false)
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+
+		if err := n.elector.Campaign(ctx); err != nil {
+			return
+		}
+		n.metrics.SetLeader(true)
+
+		if err := n.setup(ctx); err != nil {
+			errs <- errors.Wrap(err, n.Name)
+			return
+		}
+		if err := n.index(ctx); err != nil {
+			errs <- errors.Wrap(err, n.Name)
+			return
+		}
+		if err := n.elector.Watch(ctx); err != nil {
+			n.metrics.SetLeader(false)
+			errs <- errors.Wrap(err, n.Name)
+		}
+	}()
+	return nil
+}
+
+// record - tees messages of the data source to archive file. Archive keeps messages of one subscription,
+// so only the subscription generated from network contracts can be recorded.
+func (n *networkRunner) record(path string) error {
+	if n.Archive != nil {
+		return errors.New("network is indexed from archive already")
+	}
+	if len(n.Subscriptions) != 1 {
+		return errors.New("only network section subscription can be recorded, remove grpc subscriptions")
+	}
+
+	recorder, err := archive.NewRecorder(path)
+	if err != nil {
+		return err
+	}
+	if err := modules.Connect(n.source, recorder, grpc.OutputMessages, archive.InputMessages); err != nil {
+		return errors.Wrap(err, "module connect")
+	}
+	n.recorder = recorder
+	return nil
+}
+
+// index - starts modules and subscribes to the network events
+func (n *networkRunner) index(ctx context.Context) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if n.stopped {
+		return nil
+	}
+
+	n.source.Start(ctx)
+	if n.recorder != nil {
+		n.recorder.Start(ctx)
+	}
+	n.changesLogger.Start(ctx)
+	n.indexer.Start(ctx)
+	n.indexing = true
+
+	if err := n.indexer.Subscribe(ctx, n.Subscriptions); err != nil {
+		return errors.Wrap(err, "subscribe")
+	}
+	return nil
+}
+
+// Stop - unsubscribes from the network events. Indexing can't be started after stop.
+func (n *networkRunner) Stop(ctx context.Context) error {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.stopped = true
+	if !n.indexing {
+		return nil
+	}
+	return n.indexer.Unsubscribe(ctx)
+}
+
+// IsFailed - returns true if channel of the network stopped processing because of error. Channels of followers never fail.
+func (n *networkRunner) IsFailed(name string) bool {
+	n.mx.RLock()
+	defer n.mx.RUnlock()
+
+	if !n.indexing {
+		return false
+	}
+	return n.indexer.IsFailed(name)
+}
+
+// Health - returns probes of the network
+func (n *networkRunner) Health(maxLag time.Duration) Health {
+	return NewHealth(n.db.Backend().State, n.db.Ping, n, maxLag)
+}
+
+// Profiles - returns handler of identity profiles of the network
+func (n *networkRunner) Profiles() IdentityHandler {
+	backend := n.db.Backend()
+	return NewIdentityHandler(identity.NewService(backend.StarknetIds, backend.Domains, backend.Fields))
+}
+
+// Close - stops modules, resigns leadership and closes the database connection
+func (n *networkRunner) Close() error {
+	n.wg.Wait()
+
+	if err := n.indexer.Close(); err != nil {
+		return errors.Wrap(err, "closing indexer")
+	}
+	if err := n.changesLogger.Close(); err != nil {
+		return errors.Wrap(err, "closing changes logger")
+	}
+	if err := n.source.Close(); err != nil {
+		return errors.Wrap(err, "closing data source")
+	}
+	if n.recorder != nil {
+		if err := n.recorder.Close(); err != nil {
+			return errors.Wrap(err, "closing archive recorder")
+		}
+	}
+	if n.elector != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := n.elector.Resign(ctx); err != nil {
+			return errors.Wrap(err, "resign leadership")
+		}
+	}
+	if err := n.db.Close(); err != nil {
+		return errors.Wrap(err, "closing database connection")
+	}
+	return nil
+}
+
+// findNetwork - returns network by name. If name is empty the first network is returned.
+func findNetwork(networks []*networkRunner, name string) (*networkRunner, error) {
+	if name == "" {
+		return networks[0], nil
+	}
+	for i := range networks {
+		if networks[i].Name == name {
+			return networks[i], nil
+		}
+	}
+	return nil, errors.Errorf("unknown network: %s", name)
+}
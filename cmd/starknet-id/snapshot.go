@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dipdup-io/starknet-id/internal/snapshot"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var errSnapshotsUnsupported = errors.New("snapshots are supported by postgres database only")
+
+func newSnapshotCmd(connect storageConnector) *cobra.Command {
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export and import indexed data for fast bootstrap",
+	}
+
+	snapshotCmd.AddCommand(&cobra.Command{
+		Use:   "export FILE",
+		Short: "Write all indexed data and channel states to compressed archive",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strg, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer strg.Close()
+
+			if strg.Snapshots == nil {
+				return errSnapshotsUnsupported
+			}
+			manifest, err := exportSnapshot(cmd, strg.Snapshots, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "exported %d rows of %d tables at height %d, schema version %d\n",
+				totalRows(manifest), len(manifest.Tables), manifest.LastHeight(), manifest.SchemaVersion)
+			return nil
+		},
+	})
+
+	var force bool
+	importCmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Replace indexed data with archive content. The indexer has to be stopped.",
+		Long: `Replace indexed data with archive content. The indexer has to be stopped.
+
+Data is restored in one transaction: nothing is changed if the archive is broken or was exported
+from the database with another schema version. Apply migrations with 'migrate up' before importing
+into a new database. The indexer continues from the heights of channels stored in the snapshot.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strg, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer strg.Close()
+
+			if strg.Snapshots == nil {
+				return errSnapshotsUnsupported
+			}
+			if !force {
+				states, err := strg.States.List(cmd.Context(), 1, 0, sdk.SortOrderAsc)
+				if err != nil && !strg.States.IsNoRows(err) {
+					return err
+				}
+				if len(states) > 0 {
+					return errors.New("database already contains indexed data, use --force to replace it")
+				}
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			manifest, err := snapshot.Import(cmd.Context(), strg.Snapshots, storage.Models, file)
+			if err != nil {
+				return errors.Wrap(err, "import")
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "imported %d rows of %d tables, indexing continues from height %d\n",
+				totalRows(manifest), len(manifest.Tables), manifest.LastHeight())
+			return nil
+		},
+	}
+	importCmd.Flags().BoolVar(&force, "force", false, "replace data of non-empty database")
+	snapshotCmd.AddCommand(importCmd)
+
+	return snapshotCmd
+}
+
+// exportSnapshot - writes archive to temporary file in the target directory and renames it, so the archive either is complete or doesn't exist
+func exportSnapshot(cmd *cobra.Command, db snapshot.Database, path string) (snapshot.Manifest, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return snapshot.Manifest{}, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	manifest, err := snapshot.Export(cmd.Context(), db, storage.Models, file)
+	if err != nil {
+		return manifest, errors.Wrap(err, "export")
+	}
+	if err := file.Sync(); err != nil {
+		return manifest, err
+	}
+	if err := file.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, os.Rename(file.Name(), path)
+}
+
+func totalRows(manifest snapshot.Manifest) int64 {
+	var rows int64
+	for i := range manifest.Tables {
+		rows += manifest.Tables[i].Rows
+	}
+	return rows
+}
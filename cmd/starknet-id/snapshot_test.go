@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dipdup-io/starknet-id/internal/snapshot"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+type testSnapshots struct {
+	tables map[string][]sdk.Model
+}
+
+func (ts *testSnapshots) SchemaVersion(ctx context.Context) (int, error) {
+	return 5, nil
+}
+
+func (ts *testSnapshots) Dump(ctx context.Context, model sdk.Model, fn func(row sdk.Model) error) error {
+	for _, row := range ts.tables[model.TableName()] {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ts *testSnapshots) Truncate(ctx context.Context, models ...sdk.Model) error {
+	ts.tables = make(map[string][]sdk.Model)
+	return nil
+}
+
+func (ts *testSnapshots) Insert(ctx context.Context, rows []sdk.Model) error {
+	for _, row := range rows {
+		ts.tables[row.TableName()] = append(ts.tables[row.TableName()], row)
+	}
+	return nil
+}
+
+func (ts *testSnapshots) ReadSnapshot(ctx context.Context, fn func(tx snapshot.ReadTx) error) error {
+	return fn(ts)
+}
+
+func (ts *testSnapshots) RestoreSnapshot(ctx context.Context, fn func(tx snapshot.WriteTx) error) error {
+	return fn(ts)
+}
+
+func TestSnapshotCmd_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	source := &testSnapshots{
+		tables: map[string][]sdk.Model{
+			"state": {
+				&storage.State{ID: 1, Name: "starknet_id", LastHeight: 120},
+			},
+			"domain": {
+				&storage.Domain{Id: 1, Domain: "fricoben.stark"},
+				&storage.Domain{Id: 2, Domain: "test.stark"},
+			},
+		},
+	}
+
+	output, err := executeCommand(newSnapshotCmd(testConnector(commandStorage{Snapshots: source})), "export", path)
+	require.NoError(t, err)
+	require.Contains(t, output, "exported 3 rows")
+	require.Contains(t, output, "at height 120")
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary file has to be removed")
+
+	target := &testSnapshots{tables: make(map[string][]sdk.Model)}
+	output, err = executeCommand(newSnapshotCmd(testConnector(commandStorage{
+		States:    testStates{},
+		Snapshots: target,
+	})), "import", path)
+	require.NoError(t, err)
+	require.Contains(t, output, "imported 3 rows")
+	require.Contains(t, output, "continues from height 120")
+	require.Len(t, target.tables["domain"], 2)
+	require.Equal(t, "fricoben.stark", target.tables["domain"][0].(*storage.Domain).Domain)
+	require.EqualValues(t, 120, target.tables["state"][0].(*storage.State).LastHeight)
+}
+
+func TestSnapshotCmd_ImportNotEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	_, err := executeCommand(newSnapshotCmd(testConnector(commandStorage{
+		Snapshots: &testSnapshots{},
+	})), "export", path)
+	require.NoError(t, err)
+
+	target := &testSnapshots{
+		tables: map[string][]sdk.Model{
+			"domain": {&storage.Domain{Id: 1, Domain: "fricoben.stark"}},
+		},
+	}
+	states := testStates{
+		states: []*storage.State{{Name: "starknet_id", LastHeight: 10}},
+	}
+
+	_, err = executeCommand(newSnapshotCmd(testConnector(commandStorage{
+		States:    states,
+		Snapshots: target,
+	})), "import", path)
+	require.Error(t, err)
+	require.Len(t, target.tables["domain"], 1)
+
+	_, err = executeCommand(newSnapshotCmd(testConnector(commandStorage{
+		States:    states,
+		Snapshots: target,
+	})), "import", path, "--force")
+	require.NoError(t, err)
+	require.Empty(t, target.tables["domain"])
+}
+
+func TestSnapshotCmd_ImportMissingFile(t *testing.T) {
+	_, err := executeCommand(newSnapshotCmd(testConnector(commandStorage{
+		States:    testStates{},
+		Snapshots: &testSnapshots{},
+	})), "import", filepath.Join(t.TempDir(), "unknown.tar.gz"))
+	require.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd(connect storageConnector) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Print state and lag of every channel",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			strg, err := connect(cmd)
+			if err != nil {
+				return err
+			}
+			defer strg.Close()
+
+			states, err := strg.States.List(cmd.Context(), 100, 0, sdk.SortOrderAsc)
+			if err != nil && !strg.States.IsNoRows(err) {
+				return err
+			}
+			return printStatus(cmd.OutOrStdout(), states, time.Now().UTC())
+		},
+	}
+}
+
+func printStatus(w io.Writer, states []*storage.State, now time.Time) error {
+	if len(states) == 0 {
+		_, err := fmt.Fprintln(w, "no indexed channels")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CHANNEL\tLAST HEIGHT\tLAST TIME\tLAST BLOCK TIME\tLAG\n")
+	for i := range states {
+		lastBlockTime := "unknown"
+		lag := "unknown"
+		if !states[i].LastBlockTime.IsZero() {
+			lastBlockTime = states[i].LastBlockTime.UTC().Format(time.RFC3339)
+			lag = now.Sub(states[i].LastBlockTime).Truncate(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n",
+			states[i].Name,
+			states[i].LastHeight,
+			states[i].LastTime.UTC().Format(time.RFC3339),
+			lastBlockTime,
+			lag,
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusCmd(t *testing.T) {
+	blockTime := time.Now().Add(-time.Hour)
+	cmd := newStatusCmd(testConnector(commandStorage{
+		States: testStates{
+			states: []*storage.State{
+				{Name: "starknet_id", LastHeight: 100, LastBlockTime: blockTime},
+				{Name: "naming", LastHeight: 50},
+			},
+		},
+	}))
+
+	output, err := executeCommand(cmd)
+	require.NoError(t, err)
+	require.Contains(t, output, "CHANNEL")
+	require.Contains(t, output, "starknet_id")
+	require.Contains(t, output, blockTime.UTC().Format(time.RFC3339))
+	require.Contains(t, output, "1h0m")
+	require.Contains(t, output, "naming")
+	require.Contains(t, output, "unknown")
+}
+
+func TestStatusCmd_Empty(t *testing.T) {
+	cmd := newStatusCmd(testConnector(commandStorage{
+		States: testStates{},
+	}))
+
+	output, err := executeCommand(cmd)
+	require.NoError(t, err)
+	require.Contains(t, output, "no indexed channels")
+}
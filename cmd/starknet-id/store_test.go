@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Save(t *testing.T) {
+	ctx := context.Background()
+	strg := memory.New()
+	store := NewStore(strg, zerolog.Nop())
+	blockCtx := newBlockContext(strg.Subdomains, strg.Addresses, starknetid.Mainnet, nil)
+
+	var (
+		alice = []byte{0xa1}
+		bob   = []byte{0xb0}
+	)
+	starknetId := func(id int64, owner []byte, action Action) *TypeWithAction[*storage.StarknetId] {
+		return NewTypeWithAction(&storage.StarknetId{StarknetId: decimal.NewFromInt(id), OwnerAddress: owner}, action)
+	}
+
+	blockCtx.starknetIds.Set("1", starknetId(1, alice, ActionInsert))
+	blockCtx.starknetIds.Set("2", starknetId(2, alice, ActionInsert))
+	blockCtx.updateState("starknet_id", 100)
+	require.NoError(t, store.Save(ctx, blockCtx))
+	require.True(t, blockCtx.isEmpty())
+
+	blockCtx.starknetIds.Set("1", starknetId(1, bob, ActionUpdate))
+	blockCtx.starknetIds.Set("2", starknetId(2, alice, ActionDelete))
+	blockCtx.starknetIds.Set("5", starknetId(5, bob, ActionUpdate))
+	blockCtx.updateState("starknet_id", 101)
+	require.NoError(t, store.Save(ctx, blockCtx))
+
+	owned, err := strg.StarknetIds.ListByOwner(ctx, bob)
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	require.Equal(t, "1", owned[0].StarknetId.String())
+
+	count, err := strg.StarknetIds.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	// mints are replayed after reindex: already minted starknet id is replaced
+	blockCtx.starknetIds.Set("1", starknetId(1, alice, ActionInsert))
+	blockCtx.starknetIds.Set("3", starknetId(3, alice, ActionInsert))
+	blockCtx.updateState("starknet_id", 102)
+	require.NoError(t, store.Save(ctx, blockCtx))
+
+	state, err := strg.State.ByName(ctx, "starknet_id")
+	require.NoError(t, err)
+	require.EqualValues(t, 102, state.LastHeight)
+
+	owned, err = strg.StarknetIds.ListByOwner(ctx, alice)
+	require.NoError(t, err)
+	require.Len(t, owned, 2)
+}
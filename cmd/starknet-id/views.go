@@ -2,36 +2,147 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"embed"
+	"io/fs"
 	"os"
+	"path"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/dipdup-io/starknet-id/internal/storage/postgres"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/go-lib/hasura"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
-func createViews(ctx context.Context, strg postgres.Storage) ([]string, error) {
-	files, err := os.ReadDir("views")
+//go:embed views/*.sql
+var embeddedViews embed.FS
+
+// loadViews - returns embedded views and views from the directory if it's set. Views from the directory replace embedded ones with the same name.
+// Views are sorted by name, so dependent views should have names after their dependencies.
+func loadViews(dir string) ([]postgres.View, error) {
+	byName := make(map[string]postgres.View)
+
+	if err := readViews(embeddedViews, "views", byName); err != nil {
+		return nil, errors.Wrap(err, "embedded views")
+	}
+	if dir != "" {
+		if err := readViews(os.DirFS(dir), ".", byName); err != nil {
+			return nil, errors.Wrapf(err, "views from %s", dir)
+		}
+	}
+
+	views := make([]postgres.View, 0, len(byName))
+	for _, view := range byName {
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Name < views[j].Name
+	})
+	return views, nil
+}
+
+func readViews(fsys fs.FS, dir string, views map[string]postgres.View) error {
+	files, err := fs.ReadDir(fsys, dir)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	views := make([]string, 0)
 	for i := range files {
-		if files[i].IsDir() {
+		if files[i].IsDir() || !strings.HasSuffix(files[i].Name(), ".sql") {
 			continue
 		}
 
-		path := fmt.Sprintf("views/%s", files[i].Name())
-		raw, err := os.ReadFile(path)
+		raw, err := fs.ReadFile(fsys, path.Join(dir, files[i].Name()))
 		if err != nil {
-			return nil, err
+			return err
+		}
+		name := strings.TrimSuffix(files[i].Name(), ".sql")
+		views[name] = postgres.View{
+			Name:       name,
+			Definition: string(raw),
 		}
+	}
+	return nil
+}
 
-		if _, err := strg.Connection().DB().Exec(string(raw)); err != nil {
-			return nil, err
+// createViews - creates views and returns names of all views and names of created or recreated ones
+func createViews(ctx context.Context, strg postgres.Storage, dir string) ([]string, []string, error) {
+	views, err := loadViews(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changed, err := postgres.CreateViews(ctx, strg.Connection(), views)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, len(views))
+	for i := range views {
+		names[i] = views[i].Name
+	}
+	return names, changed, nil
+}
+
+// untrackViews - removes recreated views from hasura metadata, they are tracked again with actual columns by hasura initialization.
+// If schema is empty views are referenced by name only as default source does.
+func untrackViews(ctx context.Context, cfg *config.Hasura, source, schema string, views []string) {
+	if cfg == nil || len(views) == 0 {
+		return
+	}
+
+	api := hasura.New(cfg.URL, cfg.Secret)
+	for api.Health(ctx) != nil {
+		log.Info().Msg("waiting hasura is up and running to untrack changed views")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
 		}
-		views = append(views, strings.Split(files[i].Name(), ".")[0])
 	}
 
-	return views, nil
+	for i := range views {
+		var table any = views[i]
+		if schema != "" {
+			table = map[string]string{
+				"schema": schema,
+				"name":   views[i],
+			}
+		}
+		if err := api.CustomConfiguration(ctx, hasura.Request{
+			Type: "pg_untrack_table",
+			Args: map[string]any{
+				"table":   table,
+				"source":  source,
+				"cascade": true,
+			},
+		}); err != nil {
+			log.Warn().Err(err).Str("view", views[i]).Msg("untrack view")
+		}
+	}
+}
+
+// trackFunctions - returns hasura requests which track domain search functions
+func trackFunctions(source, schema string) []hasura.Request {
+	if schema == "" {
+		schema = "public"
+	}
+
+	requests := make([]hasura.Request, 0, len(postgres.SearchFunctions))
+	for _, name := range postgres.SearchFunctions {
+		requests = append(requests, hasura.Request{
+			Type: "pg_track_function",
+			Args: map[string]any{
+				"source": source,
+				"function": map[string]string{
+					"schema": schema,
+					"name":   name,
+				},
+			},
+		})
+	}
+	return requests
 }
@@ -5,8 +5,9 @@ SELECT
     domain.domain,
     domain.expiry,
     starknet_id.starknet_id,
-    starknet_id.owner_address
+    starknet_id.owner_address,
+    domain.effective_expiry
 FROM
     domain
 left join starknet_id on owner = starknet_id.starknet_id
-where expiry > current_timestamp;
\ No newline at end of file
+where effective_expiry > current_timestamp and domain.address_hash is not null;
@@ -3,9 +3,11 @@ SELECT
     name,
     CASE
         WHEN last_time < NOW() - interval '15 minutes' THEN 'OUTDATED'
+        WHEN last_block_time < NOW() - interval '15 minutes' THEN 'OUTDATED'
         ELSE 'OK'
     END AS status,
     last_time,
-    last_height
+    last_height,
+    last_block_time
 FROM
     state;
\ No newline at end of file
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadViews(t *testing.T) {
+	views, err := loadViews("")
+	require.NoError(t, err)
+	require.NotEmpty(t, views)
+
+	names := make([]string, len(views))
+	for i := range views {
+		names[i] = views[i].Name
+		require.NotEmpty(t, views[i].Definition)
+	}
+	require.Contains(t, names, "actual_domains")
+	require.Contains(t, names, "dipdup_head_status")
+	require.IsIncreasing(t, names)
+}
+
+func TestLoadViews_ExtraDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "actual_domains.sql"), []byte("CREATE VIEW actual_domains AS SELECT 1;"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "top_owners.sql"), []byte("CREATE VIEW top_owners AS SELECT 2;"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a view"), 0o600))
+
+	embedded, err := loadViews("")
+	require.NoError(t, err)
+
+	views, err := loadViews(dir)
+	require.NoError(t, err)
+	require.Len(t, views, len(embedded)+1)
+
+	byName := make(map[string]string)
+	for i := range views {
+		byName[views[i].Name] = views[i].Definition
+	}
+	require.Equal(t, "CREATE VIEW actual_domains AS SELECT 1;", byName["actual_domains"])
+	require.Equal(t, "CREATE VIEW top_owners AS SELECT 2;", byName["top_owners"])
+
+	_, err = loadViews(filepath.Join(dir, "unknown"))
+	require.Error(t, err)
+}
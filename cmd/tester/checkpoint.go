@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint - progress of the check. It's saved after every page, so interrupted check is resumed from the next page.
+type Checkpoint struct {
+	// Cursor - id of the last checked domain
+	Cursor uint64 `json:"cursor"`
+	Report Report `json:"report"`
+}
+
+// loadCheckpoint - reads checkpoint. Returns empty checkpoint if the file doesn't exist.
+func loadCheckpoint(path string) (Checkpoint, error) {
+	checkpoint := Checkpoint{
+		Report: NewReport(),
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkpoint, nil
+		}
+		return checkpoint, errors.Wrap(err, "read checkpoint")
+	}
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return checkpoint, errors.Wrapf(err, "decode checkpoint %s", path)
+	}
+	if checkpoint.Report.Checks == nil {
+		checkpoint.Report.Checks = make(map[Category]int)
+	}
+	if checkpoint.Report.Mismatches == nil {
+		checkpoint.Report.Mismatches = make(map[Category][]Mismatch)
+	}
+	// checkpoints of previous versions have no failure counters
+	if checkpoint.Report.Failures == nil {
+		checkpoint.Report.Failures = make(map[Category]int)
+		for category, mismatches := range checkpoint.Report.Mismatches {
+			checkpoint.Report.Failures[category] = len(mismatches)
+		}
+	}
+	return checkpoint, nil
+}
+
+// save - writes checkpoint to temporary file and renames it, so the checkpoint isn't broken if the tester is killed
+func (c Checkpoint) save(path string) error {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "create checkpoint")
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "write checkpoint")
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "write checkpoint")
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,13 +1,34 @@
 package main
 
-import starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+import (
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-net/go-lib/config"
+)
 
 // Config -
 type Config struct {
 	StarknetId starknetid.ApiConfig `validate:"required"                                                yaml:"starknet_id"`
 	GraphQlApi GraphQlApiConfig     `validate:"required"                                                yaml:"graphql"`
 	LogLevel   string               `validate:"omitempty,oneof=debug trace info warn error fatal panic" yaml:"log_level"`
-	Parts      int                  `validate:"omitempty,min=0"                                         yaml:"parts"`
+	PageSize   int                  `validate:"omitempty,min=1,max=1000"                                yaml:"page_size"`
+	Workers    int                  `validate:"omitempty,min=1"                                         yaml:"workers"`
+	Checkpoint string               `validate:"omitempty"                                               yaml:"checkpoint"`
+	Report     ReportConfig         `validate:"omitempty"                                               yaml:"report"`
+	Fixtures   *FixturesConfig      `validate:"omitempty"                                               yaml:"fixtures"`
+	Daemon     *DaemonConfig        `validate:"omitempty"                                               yaml:"daemon"`
+	Prometheus *config.Prometheus   `validate:"omitempty"                                               yaml:"prometheus"`
+}
+
+// ReportConfig - paths of machine-readable reports. Report is not written if its path is empty.
+type ReportConfig struct {
+	JSON  string `validate:"omitempty" yaml:"json"`
+	JUnit string `validate:"omitempty" yaml:"junit"`
+}
+
+// FixturesConfig - record responses of both APIs to the file or replay them from it without network access
+type FixturesConfig struct {
+	Mode string `validate:"required,oneof=record replay" yaml:"mode"`
+	Path string `validate:"required"                     yaml:"path"`
 }
 
 // Substitute -
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -22,6 +23,12 @@ type ActualDomainsResponse struct {
 	Data struct {
 		ActualDomains []ActualDomain `json:"actual_domains"`
 	} `json:"data"`
+	Errors []GraphQlError `json:"errors,omitempty"`
+}
+
+// GraphQlError -
+type GraphQlError struct {
+	Message string `json:"message"`
 }
 
 // GraphQlRequest -
@@ -33,10 +40,21 @@ type GraphQlRequest struct {
 
 // ActualDomain -
 type ActualDomain struct {
-	ID      string    `json:"id"`
-	Domain  string    `json:"domain"`
-	Address string    `json:"address"`
-	Expiry  time.Time `json:"expiry"`
+	ID      json.Number `json:"id"`
+	Domain  string      `json:"domain"`
+	Address string      `json:"address"`
+	Expiry  time.Time   `json:"expiry"`
+	// EffectiveExpiry - subdomains expire together with their nearest ancestor which has expiry
+	EffectiveExpiry time.Time `json:"effective_expiry"`
+}
+
+// Uint64 - returns domain id
+func (d ActualDomain) Uint64() (uint64, error) {
+	id, err := strconv.ParseUint(d.ID.String(), 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid domain id: %s", d.ID)
+	}
+	return id, nil
 }
 
 // GraphQlApi -
@@ -65,6 +83,14 @@ func NewGraphQlApi(cfg GraphQlApiConfig) GraphQlApi {
 	return api
 }
 
+// WithTransport - returns API sending requests with the transport. It's used to record and replay fixtures.
+func (api GraphQlApi) WithTransport(transport http.RoundTripper) GraphQlApi {
+	api.client = &http.Client{
+		Transport: transport,
+	}
+	return api
+}
+
 func (api GraphQlApi) post(ctx context.Context, body GraphQlRequest, output any) error {
 	buf := new(bytes.Buffer)
 	if err := json.NewEncoder(buf).Encode(body); err != nil {
@@ -100,29 +126,141 @@ func (api GraphQlApi) post(ctx context.Context, body GraphQlRequest, output any)
 	return nil
 }
 
-const actualDomainsRequest = `query GetActualDomains ($limit: Int!, $offset:Int!) {
-    actual_domains(order_by: {id: asc}, limit: $limit, offset: $offset) {
+const actualDomainsRequest = `query GetActualDomains ($limit: Int!, $cursor: bigint!) {
+    actual_domains(where: {id: {_gt: $cursor}}, order_by: {id: asc}, limit: $limit) {
       id
       domain
       address
       expiry
+      effective_expiry
     }
   }
   `
 
-// ActualDomains -
-func (api GraphQlApi) ActualDomains(ctx context.Context, limit, offset int) ([]ActualDomain, error) {
+// ActualDomains - returns page of domains with id greater than cursor ordered by id
+func (api GraphQlApi) ActualDomains(ctx context.Context, cursor uint64, limit int) ([]ActualDomain, error) {
 	body := GraphQlRequest{
 		OperationName: "GetActualDomains",
 		Query:         actualDomainsRequest,
 		Variables: map[string]any{
 			"limit":  limit,
-			"offset": offset,
+			"cursor": cursor,
 		},
 	}
 	var response ActualDomainsResponse
 	if err := api.post(ctx, body, &response); err != nil {
 		return nil, err
 	}
+	if len(response.Errors) > 0 {
+		return nil, errors.Errorf("graphql: %s", response.Errors[0].Message)
+	}
 	return response.Data.ActualDomains, nil
 }
+
+const actualDomainRequest = `query GetActualDomain ($domain: String!) {
+    actual_domains(where: {domain: {_eq: $domain}}) {
+      id
+      domain
+      address
+      expiry
+      effective_expiry
+    }
+  }
+  `
+
+// ActualDomain - returns domain by name. Returns false if domain is not found or expired.
+func (api GraphQlApi) ActualDomain(ctx context.Context, domain string) (ActualDomain, bool, error) {
+	body := GraphQlRequest{
+		OperationName: "GetActualDomain",
+		Query:         actualDomainRequest,
+		Variables: map[string]any{
+			"domain": domain,
+		},
+	}
+	var response ActualDomainsResponse
+	if err := api.post(ctx, body, &response); err != nil {
+		return ActualDomain{}, false, err
+	}
+	if len(response.Errors) > 0 {
+		return ActualDomain{}, false, errors.Errorf("graphql: %s", response.Errors[0].Message)
+	}
+	if len(response.Data.ActualDomains) == 0 {
+		return ActualDomain{}, false, nil
+	}
+	return response.Data.ActualDomains[0], true, nil
+}
+
+const lastDomainRequest = `query GetLastDomain {
+    actual_domains(order_by: {id: desc}, limit: 1) {
+      id
+    }
+  }
+  `
+
+// LastDomainID - returns the greatest id of actual domains. Returns 0 if there are no domains.
+func (api GraphQlApi) LastDomainID(ctx context.Context) (uint64, error) {
+	body := GraphQlRequest{
+		OperationName: "GetLastDomain",
+		Query:         lastDomainRequest,
+		Variables:     map[string]any{},
+	}
+	var response ActualDomainsResponse
+	if err := api.post(ctx, body, &response); err != nil {
+		return 0, err
+	}
+	if len(response.Errors) > 0 {
+		return 0, errors.Errorf("graphql: %s", response.Errors[0].Message)
+	}
+	if len(response.Data.ActualDomains) == 0 {
+		return 0, nil
+	}
+	return response.Data.ActualDomains[0].Uint64()
+}
+
+// HeadStatusResponse -
+type HeadStatusResponse struct {
+	Data struct {
+		HeadStatus []HeadStatus `json:"dipdup_head_status"`
+	} `json:"data"`
+	Errors []GraphQlError `json:"errors,omitempty"`
+}
+
+// HeadStatus -
+type HeadStatus struct {
+	Name       string      `json:"name"`
+	LastHeight json.Number `json:"last_height"`
+}
+
+const headRequest = `query GetHead {
+    dipdup_head_status {
+      name
+      last_height
+    }
+  }
+  `
+
+// Head - returns the greatest block height indexed by the indexer
+func (api GraphQlApi) Head(ctx context.Context) (uint64, error) {
+	body := GraphQlRequest{
+		OperationName: "GetHead",
+		Query:         headRequest,
+		Variables:     map[string]any{},
+	}
+	var response HeadStatusResponse
+	if err := api.post(ctx, body, &response); err != nil {
+		return 0, err
+	}
+	if len(response.Errors) > 0 {
+		return 0, errors.Errorf("graphql: %s", response.Errors[0].Message)
+	}
+
+	var head uint64
+	for i := range response.Data.HeadStatus {
+		height, err := strconv.ParseUint(response.Data.HeadStatus[i].LastHeight.String(), 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid height of %s", response.Data.HeadStatus[i].Name)
+		}
+		head = max(head, height)
+	}
+	return head, nil
+}
@@ -64,11 +64,30 @@ func main() {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
 
-	<-signals
+	select {
+	case <-signals:
+	case <-tester.Done():
+	}
 
 	cancel()
 
 	if err := tester.Close(); err != nil {
 		log.Panic().Err(err).Msg("closing tester")
 	}
+
+	if err := tester.Err(); err != nil {
+		log.Panic().Err(err).Msg("check")
+	}
+
+	report := tester.Report()
+	event := log.Info().Int("processed", report.Processed).Int("failed", report.Failed())
+	for _, category := range categories {
+		event = event.Int(string(category), report.Count(category))
+	}
+	event.Msg("total")
+
+	// daemon is stopped by signal, mismatches are reported by its metrics
+	if cfg.Daemon == nil && report.Failed() > 0 {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	metricsNamespace = "starknet_id"
+	metricsSubsystem = "tester"
+
+	// categoryAny - label of the rate of domains with mismatch of any category
+	categoryAny = "any"
+
+	defaultWindow         = 1000
+	defaultMismatchesPath = "mismatches.ndjson"
+)
+
+// DaemonConfig - continuous sampling of random domains instead of the single check of all domains
+type DaemonConfig struct {
+	// Window - count of last checked domains in the rolling mismatch rate
+	Window int `validate:"omitempty,min=1" yaml:"window"`
+	// Mismatches - path of the file where every found mismatch is appended as JSON line
+	Mismatches string `validate:"omitempty" yaml:"mismatches"`
+}
+
+// rollingRate - share of domains with mismatches among the last checked ones
+type rollingRate struct {
+	window []map[Category]struct{}
+	next   int
+	full   bool
+}
+
+func newRollingRate(size int) *rollingRate {
+	return &rollingRate{
+		window: make([]map[Category]struct{}, size),
+	}
+}
+
+// add - adds result of a domain check: categories of found mismatches
+func (r *rollingRate) add(failed map[Category]struct{}) {
+	r.window[r.next] = failed
+	r.next += 1
+	if r.next == len(r.window) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+func (r *rollingRate) len() int {
+	if r.full {
+		return len(r.window)
+	}
+	return r.next
+}
+
+// rate - returns share of domains with mismatch of the category. Empty category means any one except errors:
+// failed requests aren't drift of the data.
+func (r *rollingRate) rate(category Category) float64 {
+	count := r.len()
+	if count == 0 {
+		return 0
+	}
+
+	var failed int
+	for i := 0; i < count; i++ {
+		if category == "" {
+			for failedCategory := range r.window[i] {
+				if failedCategory != CategoryError {
+					failed += 1
+					break
+				}
+			}
+			continue
+		}
+		if _, ok := r.window[i][category]; ok {
+			failed += 1
+		}
+	}
+	return float64(failed) / float64(count)
+}
+
+// mismatchRecord - line of mismatches file
+type mismatchRecord struct {
+	Time     time.Time `json:"time"`
+	Category Category  `json:"category"`
+	Mismatch
+}
+
+// Monitor - keeps rolling mismatch rate of the daemon, exposes it as metrics and writes mismatches to the file
+type Monitor struct {
+	registry *prometheus.Registry
+	server   *http.Server
+	file     *os.File
+	encoder  *json.Encoder
+	rate     *rollingRate
+	mx       sync.Mutex
+	wg       *sync.WaitGroup
+
+	domains      prometheus.Counter
+	checks       *prometheus.CounterVec
+	mismatches   *prometheus.CounterVec
+	mismatchRate *prometheus.GaugeVec
+	headHeight   prometheus.Gauge
+}
+
+// NewMonitor - creates monitor. Metrics server is started only if prometheus section is set in config.
+func NewMonitor(cfg DaemonConfig, prometheusCfg *config.Prometheus) (*Monitor, error) {
+	if cfg.Window == 0 {
+		cfg.Window = defaultWindow
+	}
+	if cfg.Mismatches == "" {
+		cfg.Mismatches = defaultMismatchesPath
+	}
+
+	file, err := os.OpenFile(cfg.Mismatches, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, errors.Wrap(err, "open mismatches file")
+	}
+
+	m := &Monitor{
+		registry: prometheus.NewRegistry(),
+		file:     file,
+		encoder:  json.NewEncoder(file),
+		rate:     newRollingRate(cfg.Window),
+		wg:       new(sync.WaitGroup),
+		domains: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "domains_total",
+			Help:      "Count of checked domains",
+		}),
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "checks_total",
+			Help:      "Count of checks by category",
+		}, []string{"category"}),
+		mismatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "mismatches_total",
+			Help:      "Count of found mismatches by category",
+		}, []string{"category"}),
+		mismatchRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "mismatch_rate",
+			Help:      "Share of domains with mismatches among the last checked ones by category",
+		}, []string{"category"}),
+		headHeight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "head_height",
+			Help:      "Block height of the indexer at the moment of the last comparison",
+		}),
+	}
+	m.registry.MustRegister(m.domains, m.checks, m.mismatches, m.mismatchRate, m.headHeight)
+
+	if prometheusCfg != nil && prometheusCfg.URL != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", m.Handler())
+		m.server = &http.Server{
+			Addr:              prometheusCfg.URL,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	return m, nil
+}
+
+// Handler - returns handler of metrics
+func (m *Monitor) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Start - starts metrics server
+func (m *Monitor) Start(ctx context.Context) {
+	if m.server == nil {
+		return
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		log.Info().Str("url", m.server.Addr).Msg("starting metrics server...")
+		if err := m.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Err(err).Msg("metrics server")
+		}
+	}()
+}
+
+// Observe - accounts report of a single domain check
+func (m *Monitor) Observe(report Report) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	m.domains.Add(float64(report.Processed))
+	m.headHeight.Set(float64(report.Height))
+	for category, count := range report.Checks {
+		m.checks.WithLabelValues(string(category)).Add(float64(count))
+	}
+
+	failed := make(map[Category]struct{})
+	now := time.Now().UTC()
+	for category, mismatches := range report.Mismatches {
+		if len(mismatches) == 0 {
+			continue
+		}
+		failed[category] = struct{}{}
+		m.mismatches.WithLabelValues(string(category)).Add(float64(len(mismatches)))
+
+		for _, mismatch := range mismatches {
+			if err := m.encoder.Encode(mismatchRecord{
+				Time:     now,
+				Category: category,
+				Mismatch: mismatch,
+			}); err != nil {
+				log.Err(err).Msg("write mismatch")
+			}
+		}
+	}
+
+	m.rate.add(failed)
+	m.mismatchRate.WithLabelValues(categoryAny).Set(m.rate.rate(""))
+	for _, category := range categories {
+		m.mismatchRate.WithLabelValues(string(category)).Set(m.rate.rate(category))
+	}
+}
+
+// Close -
+func (m *Monitor) Close() error {
+	if m.server != nil {
+		if err := m.server.Close(); err != nil {
+			return err
+		}
+	}
+	m.wg.Wait()
+	return m.file.Close()
+}
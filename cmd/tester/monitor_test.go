@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollingRate(t *testing.T) {
+	failed := func(categories ...Category) map[Category]struct{} {
+		result := make(map[Category]struct{})
+		for _, category := range categories {
+			result[category] = struct{}{}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		size    int
+		results []map[Category]struct{}
+		any     float64
+		forward float64
+		errors  float64
+	}{
+		{
+			name: "empty",
+			size: 4,
+		}, {
+			name:    "not full",
+			size:    4,
+			results: []map[Category]struct{}{failed(CategoryForward, CategoryExpiry), failed()},
+			any:     0.5,
+			forward: 0.5,
+		}, {
+			name: "errors are not drift",
+			size: 4,
+			results: []map[Category]struct{}{
+				failed(CategoryError), failed(CategoryReverse), failed(), failed(),
+			},
+			any:    0.25,
+			errors: 0.25,
+		}, {
+			name: "old results are dropped",
+			size: 2,
+			results: []map[Category]struct{}{
+				failed(CategoryForward), failed(CategoryForward), failed(), failed(CategoryForward),
+			},
+			any:     0.5,
+			forward: 0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := newRollingRate(tt.size)
+			for _, result := range tt.results {
+				rate.add(result)
+			}
+			require.InDelta(t, tt.any, rate.rate(""), 1e-9)
+			require.InDelta(t, tt.forward, rate.rate(CategoryForward), 1e-9)
+			require.InDelta(t, tt.errors, rate.rate(CategoryError), 1e-9)
+		})
+	}
+}
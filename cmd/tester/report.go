@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Category - kind of difference between the indexer and Starknet ID API
+type Category string
+
+// categories
+const (
+	// CategoryForward - domain is resolved to different address or is unknown to Starknet ID API
+	CategoryForward Category = "forward"
+	// CategoryExpiry - domain expires at different time
+	CategoryExpiry Category = "expiry"
+	// CategoryReverse - address is resolved by Starknet ID API to the domain which the indexer resolves to another address
+	CategoryReverse Category = "reverse"
+	// CategoryError - check could not be done
+	CategoryError Category = "error"
+)
+
+var categories = []Category{CategoryForward, CategoryExpiry, CategoryReverse, CategoryError}
+
+// Mismatch - single difference
+type Mismatch struct {
+	Domain     string `json:"domain,omitempty"`
+	Address    string `json:"address,omitempty"`
+	Indexer    string `json:"indexer,omitempty"`
+	StarknetId string `json:"starknet_id,omitempty"`
+	Message    string `json:"message"`
+	// Height - block height of the indexer at the moment of comparison
+	Height uint64 `json:"height,omitempty"`
+}
+
+// key - returns mismatch without height, the same difference found at other height is the same mismatch
+func (m Mismatch) key() Mismatch {
+	m.Height = 0
+	return m
+}
+
+// Report - results of the checks
+type Report struct {
+	// Processed - count of checked domains
+	Processed int `json:"processed"`
+	// Height - block height of the indexer at the moment of the last comparison
+	Height uint64 `json:"height"`
+	// Checks - count of checks by category
+	Checks map[Category]int `json:"checks"`
+	// Failures - count of mismatches by category. Mismatches aren't kept in daemon mode, so they are counted separately.
+	Failures map[Category]int `json:"failures"`
+	// Mismatches - differences by category
+	Mismatches map[Category][]Mismatch `json:"mismatches"`
+}
+
+// NewReport -
+func NewReport() Report {
+	return Report{
+		Checks:     make(map[Category]int),
+		Failures:   make(map[Category]int),
+		Mismatches: make(map[Category][]Mismatch),
+	}
+}
+
+func (r *Report) check(category Category) {
+	r.Checks[category] += 1
+}
+
+func (r *Report) fail(category Category, mismatch Mismatch) {
+	mismatch.Height = r.Height
+	r.Mismatches[category] = append(r.Mismatches[category], mismatch)
+	r.Failures[category] += 1
+}
+
+// Failed - returns count of mismatches of all categories
+func (r Report) Failed() int {
+	var count int
+	for _, failures := range r.Failures {
+		count += failures
+	}
+	return count
+}
+
+// Count - returns count of mismatches of the category
+func (r Report) Count(category Category) int {
+	return r.Failures[category]
+}
+
+// count - adds counters of other report without its mismatches. The same mismatch found twice is counted twice.
+func (r *Report) count(other Report) {
+	r.Processed += other.Processed
+	r.Height = max(r.Height, other.Height)
+	for category, count := range other.Checks {
+		r.Checks[category] += count
+	}
+	for category, count := range other.Failures {
+		r.Failures[category] += count
+	}
+}
+
+func (r *Report) merge(other Report) {
+	r.Processed += other.Processed
+	r.Height = max(r.Height, other.Height)
+	for category, count := range other.Checks {
+		r.Checks[category] += count
+	}
+	for category, mismatches := range other.Mismatches {
+		// addresses are checked again after resume, so the same mismatch may be found twice
+		known := make(map[Mismatch]struct{}, len(r.Mismatches[category]))
+		for _, mismatch := range r.Mismatches[category] {
+			known[mismatch.key()] = struct{}{}
+		}
+		for _, mismatch := range mismatches {
+			if _, ok := known[mismatch.key()]; ok {
+				continue
+			}
+			known[mismatch.key()] = struct{}{}
+			r.Mismatches[category] = append(r.Mismatches[category], mismatch)
+			r.Failures[category] += 1
+		}
+	}
+}
+
+// sort - orders mismatches, so reports of the same data are equal regardless of checks order
+func (r *Report) sort() {
+	for _, mismatches := range r.Mismatches {
+		sort.SliceStable(mismatches, func(i, j int) bool {
+			if mismatches[i].Domain != mismatches[j].Domain {
+				return mismatches[i].Domain < mismatches[j].Domain
+			}
+			return mismatches[i].Address < mismatches[j].Address
+		})
+	}
+}
+
+// WriteJSON -
+func (r Report) WriteJSON(path string) error {
+	raw, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit - writes report as JUnit XML: a test suite per category and a failed test case per mismatch.
+// Passed checks are only counted, they aren't listed.
+func (r Report) WriteJUnit(path string) error {
+	suites := junitTestSuites{
+		Name:   "starknet-id",
+		Suites: make([]junitTestSuite, 0, len(categories)),
+	}
+	for _, category := range categories {
+		mismatches := r.Mismatches[category]
+		suite := junitTestSuite{
+			Name:     string(category),
+			Tests:    max(r.Checks[category], len(mismatches)),
+			Failures: len(mismatches),
+			Cases:    make([]junitTestCase, 0, len(mismatches)),
+		}
+		for _, mismatch := range mismatches {
+			name := mismatch.Domain
+			if name == "" {
+				name = mismatch.Address
+			}
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      name,
+				ClassName: string(category),
+				Failure: &junitFailure{
+					Message: mismatch.Message,
+					Text:    fmt.Sprintf("indexer: %s\nstarknet_id: %s\nheight: %d", mismatch.Indexer, mismatch.StarknetId, mismatch.Height),
+				},
+			})
+		}
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	raw, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+	raw = append([]byte(xml.Header), raw...)
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport_WriteJUnit(t *testing.T) {
+	report := NewReport()
+	report.Processed = 3
+	for i := 0; i < 3; i++ {
+		report.check(CategoryForward)
+	}
+	report.check(CategoryReverse)
+	report.fail(CategoryForward, Mismatch{Domain: "bob.stark", Indexer: "0x2", StarknetId: "0x3", Message: "domain is resolved to different address"})
+	report.fail(CategoryReverse, Mismatch{Domain: "alice.stark", Address: "0xe", Message: "reverse domain is resolved to different address"})
+	report.fail(CategoryError, Mismatch{Address: "0xf", Message: "timeout"})
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	require.NoError(t, report.WriteJUnit(path))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(raw, &suites))
+	require.Equal(t, 5, suites.Tests)
+	require.Equal(t, 3, suites.Failures)
+	require.Len(t, suites.Suites, len(categories))
+
+	tests := []struct {
+		category Category
+		tests    int
+		cases    []string
+	}{
+		{CategoryForward, 3, []string{"bob.stark"}},
+		{CategoryExpiry, 0, []string{}},
+		{CategoryReverse, 1, []string{"alice.stark"}},
+		{CategoryError, 1, []string{"0xf"}},
+	}
+	for i, tt := range tests {
+		t.Run(string(tt.category), func(t *testing.T) {
+			suite := suites.Suites[i]
+			require.Equal(t, string(tt.category), suite.Name)
+			require.Equal(t, tt.tests, suite.Tests)
+			require.Equal(t, len(tt.cases), suite.Failures)
+
+			names := make([]string, 0, len(suite.Cases))
+			for _, testCase := range suite.Cases {
+				require.NotNil(t, testCase.Failure)
+				names = append(names, testCase.Name)
+			}
+			require.Equal(t, tt.cases, names)
+		})
+	}
+}
+
+func TestReport_MergeAndCount(t *testing.T) {
+	page := NewReport()
+	page.Processed = 2
+	page.check(CategoryForward)
+	page.check(CategoryForward)
+	page.fail(CategoryForward, Mismatch{Domain: "bob.stark", Message: "domain is resolved to different address"})
+
+	merged := NewReport()
+	merged.merge(page)
+	merged.merge(page)
+	require.Equal(t, 4, merged.Processed)
+	require.Equal(t, 4, merged.Checks[CategoryForward])
+	require.Len(t, merged.Mismatches[CategoryForward], 1, "the same mismatch is kept once")
+	require.Equal(t, 1, merged.Count(CategoryForward))
+
+	counted := NewReport()
+	counted.count(page)
+	counted.count(page)
+	require.Equal(t, 4, counted.Processed)
+	require.Equal(t, 4, counted.Checks[CategoryForward])
+	require.Empty(t, counted.Mismatches)
+	require.Equal(t, 2, counted.Count(CategoryForward))
+	require.Equal(t, 2, counted.Failed())
+}
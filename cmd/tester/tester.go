@@ -2,140 +2,532 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
 	"math/big"
-	"sort"
+	"math/rand"
+	"net/http"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/dipdup-io/starknet-id/internal/httpfixture"
 	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	"github.com/shopspring/decimal"
 )
 
-type testPart struct {
-	offset int
-	limit  int
+const (
+	defaultPageSize = 100
+	defaultWorkers  = 10
+
+	// lastDomainInterval - interval of the greatest domain id updates in daemon mode
+	lastDomainInterval = time.Minute
+	retryInterval      = 5 * time.Second
+)
+
+// Tester - compares all actual domains of the indexer with Starknet ID API
+type Tester struct {
+	starknetIdApi  starknetid.Api
+	graphQlApi     GraphQlApi
+	pageSize       int
+	workers        int
+	checkpointPath string
+	reportCfg      ReportConfig
+	recorder       *httpfixture.Recorder
+	fixturesPath   string
+	// monitor - is set in daemon mode
+	monitor *Monitor
+
+	checkpoint Checkpoint
+	// addresses - addresses whose reverse resolution is checked. It's nil in daemon mode, so addresses are checked again.
+	addresses map[string]struct{}
+	finished  bool
+	err       error
+	mx        sync.Mutex
+
+	done chan struct{}
+	wg   *sync.WaitGroup
 }
 
-func randomParts(count, end, limit int) ([]testPart, error) {
-	threasholds := make([]int, count)
-	for i := 0; i < count; i++ {
-		value, err := rand.Int(rand.Reader, big.NewInt(int64(end)))
+// NewTester -
+func NewTester(cfg Config) (*Tester, error) {
+	tester := &Tester{
+		starknetIdApi:  starknetid.NewApi(cfg.StarknetId),
+		graphQlApi:     NewGraphQlApi(cfg.GraphQlApi),
+		pageSize:       cfg.PageSize,
+		workers:        cfg.Workers,
+		checkpointPath: cfg.Checkpoint,
+		reportCfg:      cfg.Report,
+		checkpoint: Checkpoint{
+			Report: NewReport(),
+		},
+		addresses: make(map[string]struct{}),
+		done:      make(chan struct{}),
+		wg:        new(sync.WaitGroup),
+	}
+	if tester.pageSize == 0 {
+		tester.pageSize = defaultPageSize
+	}
+	if tester.workers == 0 {
+		tester.workers = defaultWorkers
+	}
+
+	if cfg.Fixtures != nil {
+		transport, err := tester.fixturesTransport(*cfg.Fixtures)
 		if err != nil {
 			return nil, err
 		}
-		threasholds[i] = int(value.Int64())
+		tester.starknetIdApi = tester.starknetIdApi.WithTransport(transport)
+		tester.graphQlApi = tester.graphQlApi.WithTransport(transport)
 	}
 
-	sort.Ints(threasholds)
+	if cfg.Daemon != nil {
+		monitor, err := NewMonitor(*cfg.Daemon, cfg.Prometheus)
+		if err != nil {
+			return nil, err
+		}
+		tester.monitor = monitor
+		tester.addresses = nil
+		// the whole data isn't checked in daemon mode, so there is nothing to resume
+		tester.checkpointPath = ""
+	}
 
-	parts := make([]testPart, count)
-	for i := 0; i < count; i++ {
-		parts[i].limit = limit
-		parts[i].offset = threasholds[i]
+	if tester.checkpointPath != "" {
+		checkpoint, err := loadCheckpoint(tester.checkpointPath)
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint.Cursor > 0 {
+			log.Info().Uint64("cursor", checkpoint.Cursor).Int("processed", checkpoint.Report.Processed).Msg("resuming from checkpoint")
+		}
+		tester.checkpoint = checkpoint
 	}
-	return parts, nil
-}
 
-// Tester -
-type Tester struct {
-	starknetIdApi starknetid.Api
-	graphQlApi    GraphQlApi
-	parts         []testPart
-	wg            *sync.WaitGroup
+	return tester, nil
 }
 
-// NewTester -
-func NewTester(cfg Config) (*Tester, error) {
-	tester := new(Tester)
-	tester.wg = new(sync.WaitGroup)
-	tester.starknetIdApi = starknetid.NewApi(cfg.StarknetId)
-	tester.graphQlApi = NewGraphQlApi(cfg.GraphQlApi)
+func (t *Tester) fixturesTransport(cfg FixturesConfig) (http.RoundTripper, error) {
+	switch cfg.Mode {
+	case "record":
+		t.recorder = httpfixture.NewRecorder(nil, httpfixture.NewStore())
+		t.fixturesPath = cfg.Path
+		return t.recorder, nil
+	case "replay":
+		store, err := httpfixture.Load(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		return httpfixture.NewReplayer(store), nil
+	default:
+		return nil, errors.Errorf("unknown fixtures mode: %s", cfg.Mode)
+	}
+}
 
-	parts, err := randomParts(cfg.Parts, 200000, 100)
-	if err != nil {
-		return nil, err
+// Start - starts the check of all domains or the sampling in daemon mode
+func (t *Tester) Start(ctx context.Context) {
+	t.wg.Add(1)
+	if t.monitor != nil {
+		t.monitor.Start(ctx)
+		go t.runDaemon(ctx)
+	} else {
+		go t.run(ctx)
 	}
-	tester.parts = parts
+}
 
-	return tester, nil
+// Done - returns channel which is closed when all domains are checked or the check is stopped.
+// In daemon mode it's closed only when the daemon is stopped.
+func (t *Tester) Done() <-chan struct{} {
+	return t.done
 }
 
-// Start -
-func (t *Tester) Start(ctx context.Context) {
-	for i := range t.parts {
-		t.wg.Add(1)
-		go t.work(ctx, t.parts[i])
+// Err - returns error which stopped the check
+func (t *Tester) Err() error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	return t.err
+}
+
+// Report - returns results of checked pages
+func (t *Tester) Report() Report {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	report := NewReport()
+	report.count(t.checkpoint.Report)
+	for category, mismatches := range t.checkpoint.Report.Mismatches {
+		report.Mismatches[category] = slices.Clone(mismatches)
 	}
+	report.sort()
+	return report
 }
 
-func (t *Tester) work(ctx context.Context, part testPart) {
+func (t *Tester) run(ctx context.Context) {
 	defer t.wg.Done()
+	defer close(t.done)
 
-	var (
-		processed = 0
-		failed    = 0
-	)
+	cursor := t.checkpoint.Cursor
+	for {
+		height := t.getHead(ctx)
+		domains, err := t.getActualDomains(ctx, cursor)
+		if err != nil {
+			if ctx.Err() == nil {
+				t.setError(errors.Wrapf(err, "receive domains after %d", cursor))
+			}
+			return
+		}
+		if len(domains) == 0 {
+			t.mx.Lock()
+			t.finished = true
+			t.mx.Unlock()
+			return
+		}
 
-	domains, err := t.getActualDomains(ctx, part.limit, part.offset)
-	if err != nil {
-		log.Err(err).Msg("graphql")
-		return
-	}
+		report := t.checkPage(ctx, domains, height)
+		if ctx.Err() != nil {
+			// partially checked page is dropped, it's checked again after resume
+			return
+		}
 
-	for i := range domains {
-		select {
-		case <-ctx.Done():
-			log.Info().Int("failed", failed).Int("processed", processed).Msg("report")
+		cursor, err = domains[len(domains)-1].Uint64()
+		if err != nil {
+			t.setError(err)
 			return
-		default:
 		}
 
-		log.Info().Str("domain", domains[i].Domain).Msg("check...")
-		resp, err := t.getDomain(ctx, domains[i].Domain)
+		t.mx.Lock()
+		t.checkpoint.Cursor = cursor
+		t.checkpoint.Report.merge(report)
+		checkpoint := t.checkpoint
+		err = nil
+		if t.checkpointPath != "" {
+			err = checkpoint.save(t.checkpointPath)
+		}
+		t.mx.Unlock()
+
 		if err != nil {
-			log.Err(err).Str("domain", domains[i].Domain).Msg("starknet id api")
-			continue
+			t.setError(err)
+			return
+		}
+		log.Info().Uint64("cursor", cursor).Int("processed", checkpoint.Report.Processed).Int("failed", checkpoint.Report.Failed()).Msg("page is checked")
+	}
+}
+
+// runDaemon - checks pages from random cursors until the context is cancelled. Requests are limited
+// by rate limits of the APIs, so the daemon samples domains continuously within the budgets.
+func (t *Tester) runDaemon(ctx context.Context) {
+	defer t.wg.Done()
+	defer close(t.done)
+
+	var (
+		lastID      uint64
+		lastUpdated time.Time
+	)
+	for ctx.Err() == nil {
+		if time.Since(lastUpdated) > lastDomainInterval {
+			id, err := t.getLastDomainID(ctx)
+			if err != nil {
+				log.Err(err).Msg("receive last domain")
+				t.pause(ctx)
+				continue
+			}
+			lastID, lastUpdated = id, time.Now()
 		}
-		if resp.Addr == "" {
-			log.Error().Str("domain", domains[i].Domain).Msg("unknown domain")
+		if lastID == 0 {
+			log.Warn().Msg("there are no domains to check")
+			t.pause(ctx)
+			lastUpdated = time.Time{}
 			continue
 		}
 
-		siAddr, err := decimal.NewFromString(resp.Addr)
+		cursor := uint64(rand.Int63n(int64(lastID)))
+		height := t.getHead(ctx)
+		domains, err := t.getActualDomains(ctx, cursor)
 		if err != nil {
-			log.Error().Str("starknet_id", resp.Addr).Msg("can't decode address")
+			log.Err(err).Uint64("cursor", cursor).Msg("receive domains")
+			t.pause(ctx)
 			continue
 		}
-		domainAddr := strings.TrimPrefix(domains[i].Address, "\\x")
-		gqAddr := decimal.NewFromInt(0)
-		if domainAddr != "" {
-			addVal, ok := big.NewInt(0).SetString(domainAddr, 16)
-			if !ok {
-				log.Error().Str("graphql", domains[i].Address).Msg("can't decode address")
-				continue
+
+		// mismatches are written to the file by the monitor, so only counters are kept
+		report := t.checkPage(ctx, domains, height)
+		t.mx.Lock()
+		t.checkpoint.Report.count(report)
+		t.mx.Unlock()
+	}
+}
+
+// pause - waits before retry of failed request
+func (t *Tester) pause(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryInterval):
+	}
+}
+
+func (t *Tester) setError(err error) {
+	log.Err(err).Msg("check is stopped")
+
+	t.mx.Lock()
+	t.err = err
+	t.mx.Unlock()
+}
+
+// checkPage - checks domains of the page by workers and returns merged report. Mismatches are labelled by the height.
+func (t *Tester) checkPage(ctx context.Context, domains []ActualDomain, height uint64) Report {
+	queue := make(chan ActualDomain)
+	reports := make(chan Report, t.workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < t.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			report := NewReport()
+			for domain := range queue {
+				domainReport := NewReport()
+				domainReport.Height = height
+				t.checkDomain(ctx, domain, &domainReport)
+				if ctx.Err() != nil {
+					continue
+				}
+				if t.monitor != nil {
+					t.monitor.Observe(domainReport)
+				}
+				report.merge(domainReport)
 			}
-			gqAddr = decimal.NewFromBigInt(addVal, 0)
-		}
+			reports <- report
+		}()
+	}
 
-		if !gqAddr.Equal(siAddr) {
-			log.Error().Str("domain", domains[i].Domain).Str("starknet_id", siAddr.String()).Str("graphql", gqAddr.String()).Msg("unequal")
-			failed += 1
+send:
+	for i := range domains {
+		select {
+		case <-ctx.Done():
+			break send
+		case queue <- domains[i]:
 		}
+	}
+	close(queue)
+	wg.Wait()
+	close(reports)
+
+	result := NewReport()
+	for report := range reports {
+		result.merge(report)
+	}
+	return result
+}
+
+// checkDomain - compares forward resolution and expiry of the domain and reverse resolution of its address
+func (t *Tester) checkDomain(ctx context.Context, domain ActualDomain, report *Report) {
+	log.Debug().Str("domain", domain.Domain).Msg("check...")
+	report.Processed += 1
+	report.check(CategoryForward)
+
+	indexerAddr, err := parseGraphQlAddress(domain.Address)
+	if err != nil {
+		report.fail(CategoryError, Mismatch{
+			Domain:  domain.Domain,
+			Indexer: domain.Address,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	resp, err := t.getDomain(ctx, domain.Domain)
+	switch {
+	case ctx.Err() != nil:
+		return
+	case starknetid.IsNotFound(err) || err == nil && resp.Addr == "":
+		report.fail(CategoryForward, Mismatch{
+			Domain:  domain.Domain,
+			Indexer: formatAddress(indexerAddr),
+			Message: "domain is unknown to Starknet ID API",
+		})
+		return
+	case err != nil:
+		report.fail(CategoryError, Mismatch{
+			Domain:  domain.Domain,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	siAddr, err := parseApiAddress(resp.Addr)
+	if err != nil {
+		report.fail(CategoryError, Mismatch{
+			Domain:     domain.Domain,
+			StarknetId: resp.Addr,
+			Message:    err.Error(),
+		})
+		return
+	}
+	if indexerAddr.Cmp(siAddr) != 0 {
+		log.Error().Str("domain", domain.Domain).Str("starknet_id", formatAddress(siAddr)).Str("graphql", formatAddress(indexerAddr)).Msg("unequal")
+		report.fail(CategoryForward, Mismatch{
+			Domain:     domain.Domain,
+			Indexer:    formatAddress(indexerAddr),
+			StarknetId: formatAddress(siAddr),
+			Message:    "domain is resolved to different address",
+		})
+	}
+
+	report.check(CategoryExpiry)
+	if expiry := time.Unix(int64(resp.DomainExpiry), 0).UTC(); !expiry.Equal(domain.EffectiveExpiry) {
+		report.fail(CategoryExpiry, Mismatch{
+			Domain:     domain.Domain,
+			Indexer:    domain.EffectiveExpiry.UTC().Format(time.RFC3339),
+			StarknetId: expiry.Format(time.RFC3339),
+			Message:    "domain expires at different time",
+		})
+	}
+
+	if indexerAddr.Sign() != 0 && t.claimAddress(formatAddress(indexerAddr)) {
+		t.checkReverse(ctx, indexerAddr, report)
+	}
+}
+
+// checkReverse - checks that the domain which Starknet ID API resolves the address to is resolved to the address by the indexer.
+// Addresses without reverse record are skipped: the indexer doesn't store reverse records.
+func (t *Tester) checkReverse(ctx context.Context, address *big.Int, report *Report) {
+	formatted := formatAddress(address)
+	report.check(CategoryReverse)
+
+	resp, err := t.getAddress(ctx, formatted)
+	switch {
+	case ctx.Err() != nil:
+		return
+	case starknetid.IsNotFound(err) || err == nil && resp.Domain == "":
+		return
+	case err != nil:
+		report.fail(CategoryError, Mismatch{
+			Address: formatted,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	indexed, ok, err := t.getActualDomain(ctx, resp.Domain)
+	switch {
+	case ctx.Err() != nil:
+		return
+	case err != nil:
+		report.fail(CategoryError, Mismatch{
+			Domain:  resp.Domain,
+			Address: formatted,
+			Message: err.Error(),
+		})
+		return
+	case !ok:
+		report.fail(CategoryReverse, Mismatch{
+			Domain:     resp.Domain,
+			Address:    formatted,
+			StarknetId: formatted,
+			Message:    "reverse domain is not found in the indexer",
+		})
+		return
+	}
 
-		processed += 1
+	indexerAddr, err := parseGraphQlAddress(indexed.Address)
+	if err != nil {
+		report.fail(CategoryError, Mismatch{
+			Domain:  indexed.Domain,
+			Indexer: indexed.Address,
+			Message: err.Error(),
+		})
+		return
+	}
+	if indexerAddr.Cmp(address) != 0 {
+		report.fail(CategoryReverse, Mismatch{
+			Domain:     resp.Domain,
+			Address:    formatted,
+			Indexer:    formatAddress(indexerAddr),
+			StarknetId: formatted,
+			Message:    "reverse domain is resolved to different address",
+		})
 	}
+}
 
-	log.Info().Int("failed", failed).Int("processed", processed).Msg("report")
+// claimAddress - returns true if the address reverse resolution wasn't checked yet
+func (t *Tester) claimAddress(address string) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.addresses == nil {
+		return true
+	}
+	if _, ok := t.addresses[address]; ok {
+		return false
+	}
+	t.addresses[address] = struct{}{}
+	return true
 }
 
-func (t *Tester) getActualDomains(ctx context.Context, limit, offset int) ([]ActualDomain, error) {
+// parseGraphQlAddress - decodes address in Hasura bytea format. Empty address is zero.
+func parseGraphQlAddress(value string) (*big.Int, error) {
+	hex := strings.TrimPrefix(value, "\\x")
+	if hex == "" {
+		return big.NewInt(0), nil
+	}
+	address, ok := big.NewInt(0).SetString(hex, 16)
+	if !ok {
+		return nil, errors.Errorf("can't decode indexer address: %s", value)
+	}
+	return address, nil
+}
+
+// parseApiAddress - decodes address returned by Starknet ID API: decimal or hex with 0x prefix
+func parseApiAddress(value string) (*big.Int, error) {
+	base := 10
+	if strings.HasPrefix(value, "0x") {
+		value = value[2:]
+		base = 16
+	}
+	address, ok := big.NewInt(0).SetString(value, base)
+	if !ok {
+		return nil, errors.Errorf("can't decode starknet id address: %s", value)
+	}
+	return address, nil
+}
+
+func formatAddress(address *big.Int) string {
+	return "0x" + address.Text(16)
+}
+
+func (t *Tester) getActualDomains(ctx context.Context, cursor uint64) ([]ActualDomain, error) {
+	gqCtx, cancelGQ := context.WithTimeout(ctx, time.Second*10)
+	defer cancelGQ()
+
+	return t.graphQlApi.ActualDomains(gqCtx, cursor, t.pageSize)
+}
+
+func (t *Tester) getLastDomainID(ctx context.Context) (uint64, error) {
 	gqCtx, cancelGQ := context.WithTimeout(ctx, time.Second*10)
 	defer cancelGQ()
 
-	return t.graphQlApi.ActualDomains(gqCtx, limit, offset)
+	return t.graphQlApi.LastDomainID(gqCtx)
+}
+
+// getHead - returns head of the indexer. Comparison isn't stopped if the head is unavailable, mismatches are not labelled by height then.
+func (t *Tester) getHead(ctx context.Context) uint64 {
+	gqCtx, cancelGQ := context.WithTimeout(ctx, time.Second*10)
+	defer cancelGQ()
+
+	height, err := t.graphQlApi.Head(gqCtx)
+	if err != nil && ctx.Err() == nil {
+		log.Warn().Err(err).Msg("receive head of the indexer")
+	}
+	return height
+}
+
+func (t *Tester) getActualDomain(ctx context.Context, name string) (ActualDomain, bool, error) {
+	gqCtx, cancelGQ := context.WithTimeout(ctx, time.Second*10)
+	defer cancelGQ()
+
+	return t.graphQlApi.ActualDomain(gqCtx, name)
 }
 
 func (t *Tester) getDomain(ctx context.Context, name string) (starknetid.DomainToAddrResponse, error) {
@@ -145,8 +537,46 @@ func (t *Tester) getDomain(ctx context.Context, name string) (starknetid.DomainT
 	return t.starknetIdApi.DomainToAddress(gqCtx, name)
 }
 
-// Close -
+func (t *Tester) getAddress(ctx context.Context, address string) (starknetid.AddrToDomainResponse, error) {
+	gqCtx, cancelGQ := context.WithTimeout(ctx, time.Second*10)
+	defer cancelGQ()
+
+	return t.starknetIdApi.AddressToDomain(gqCtx, address)
+}
+
+// Close - waits for the check, writes reports and saves recorded fixtures. Checkpoint of finished check is removed.
 func (t *Tester) Close() error {
 	t.wg.Wait()
+
+	if t.monitor != nil {
+		if err := t.monitor.Close(); err != nil {
+			return errors.Wrap(err, "close monitor")
+		}
+	}
+
+	report := t.Report()
+	if t.reportCfg.JSON != "" {
+		if err := report.WriteJSON(t.reportCfg.JSON); err != nil {
+			return errors.Wrap(err, "write json report")
+		}
+	}
+	if t.reportCfg.JUnit != "" {
+		if err := report.WriteJUnit(t.reportCfg.JUnit); err != nil {
+			return errors.Wrap(err, "write junit report")
+		}
+	}
+
+	if t.finished && t.checkpointPath != "" {
+		if err := os.Remove(t.checkpointPath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "remove checkpoint")
+		}
+	}
+
+	if t.recorder != nil {
+		if err := t.recorder.Store().Save(t.fixturesPath); err != nil {
+			return errors.Wrap(err, "save fixtures")
+		}
+		log.Info().Int("count", t.recorder.Store().Len()).Str("path", t.fixturesPath).Msg("fixtures are saved")
+	}
 	return nil
 }
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/stretchr/testify/require"
+)
+
+var testExpiry = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const testHeight = 100
+
+type liveDomain struct {
+	id      uint64
+	domain  string
+	address string
+	// addr and expiry - forward resolution of Starknet ID API. Empty addr is unknown domain.
+	addr   string
+	expiry time.Time
+}
+
+// liveDomains - data of the stand-in live APIs: indexed domains and forward resolution of Starknet ID API
+var liveDomains = []liveDomain{
+	{1, "alice.stark", `\x1a`, "26", testExpiry},
+	{2, "bob.stark", `\x02`, "3", testExpiry},
+	{3, "carol.stark", `\x0abc`, "2748", testExpiry.Add(time.Hour)},
+	{4, "dave.stark", `\x0d`, "", testExpiry},
+	{5, "eve.stark", "", "0", testExpiry},
+	{6, "gina.stark", `\x1a`, "26", testExpiry},
+	// subdomain doesn't have own expiry: Starknet ID API returns expiry of its parent
+	{7, "mike.alice.stark", `\x0e`, "14", testExpiry},
+}
+
+// liveReverse - reverse resolution of Starknet ID API
+var liveReverse = map[string]string{
+	"0x1a":  "alice.stark",
+	"0xabc": "frank.stark",
+	"0xe":   "alice.stark",
+}
+
+// liveServer - stand-in of Starknet ID and GraphQL APIs
+type liveServer struct {
+	*httptest.Server
+
+	// requests - count of received requests
+	requests atomic.Int64
+	// pages - count of requested pages by cursor
+	pages map[uint64]*atomic.Int64
+	// failAfter - page requests with greater cursor fail if it's set
+	failAfter atomic.Int64
+}
+
+func newLiveServer(t *testing.T) *liveServer {
+	server := &liveServer{
+		pages: make(map[uint64]*atomic.Int64),
+	}
+	for i := range liveDomains {
+		server.pages[liveDomains[i].id] = new(atomic.Int64)
+	}
+	server.pages[0] = new(atomic.Int64)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/graphql", server.graphql)
+	mux.HandleFunc("/api/indexer/domain_to_addr", server.domainToAddr)
+	mux.HandleFunc("/api/indexer/addr_to_domain", server.addrToDomain)
+	server.Server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *liveServer) graphql(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	var req GraphQlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var response ActualDomainsResponse
+	response.Data.ActualDomains = make([]ActualDomain, 0)
+	switch req.OperationName {
+	case "GetActualDomains":
+		cursor := uint64(req.Variables["cursor"].(float64))
+		limit := int(req.Variables["limit"].(float64))
+		s.pages[cursor].Add(1)
+		if failAfter := s.failAfter.Load(); failAfter > 0 && cursor > uint64(failAfter) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		for i := range liveDomains {
+			if liveDomains[i].id > cursor && len(response.Data.ActualDomains) < limit {
+				response.Data.ActualDomains = append(response.Data.ActualDomains, liveDomains[i].actual())
+			}
+		}
+	case "GetActualDomain":
+		for i := range liveDomains {
+			if liveDomains[i].domain == req.Variables["domain"] {
+				response.Data.ActualDomains = append(response.Data.ActualDomains, liveDomains[i].actual())
+			}
+		}
+	case "GetLastDomain":
+		response.Data.ActualDomains = append(response.Data.ActualDomains, liveDomains[len(liveDomains)-1].actual())
+	case "GetHead":
+		var head HeadStatusResponse
+		head.Data.HeadStatus = []HeadStatus{
+			{Name: "starknet_id", LastHeight: json.Number(strconv.Itoa(testHeight))},
+		}
+		_ = json.NewEncoder(w).Encode(head)
+		return
+	default:
+		response.Errors = append(response.Errors, GraphQlError{Message: "unknown operation"})
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (s *liveServer) domainToAddr(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	domain := r.URL.Query().Get("domain")
+	for i := range liveDomains {
+		if liveDomains[i].domain != domain || liveDomains[i].addr == "" {
+			continue
+		}
+		_ = json.NewEncoder(w).Encode(starknetid.DomainToAddrResponse{
+			Addr:         liveDomains[i].addr,
+			DomainExpiry: int(liveDomains[i].expiry.Unix()),
+		})
+		return
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(starknetid.ApiError{Error: "no address found"})
+}
+
+func (s *liveServer) addrToDomain(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	domain, ok := liveReverse[r.URL.Query().Get("addr")]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(starknetid.ApiError{Error: "no domain found"})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(starknetid.AddrToDomainResponse{
+		Domain:       domain,
+		DomainExpiry: int(testExpiry.Unix()),
+	})
+}
+
+func (d liveDomain) actual() ActualDomain {
+	domain := ActualDomain{
+		ID:              json.Number(strconv.FormatUint(d.id, 10)),
+		Domain:          d.domain,
+		Address:         d.address,
+		Expiry:          testExpiry,
+		EffectiveExpiry: testExpiry,
+	}
+	if strings.Count(d.domain, ".") > 1 {
+		domain.Expiry = time.Unix(0, 0).UTC()
+	}
+	return domain
+}
+
+func testConfig(url string, fixtures *FixturesConfig) Config {
+	return Config{
+		StarknetId: starknetid.ApiConfig{
+			Url:               url,
+			RequestsPerSecond: 1000,
+		},
+		GraphQlApi: GraphQlApiConfig{
+			Url:               url + "/v1/graphql",
+			RequestsPerSecond: 1000,
+		},
+		PageSize: 3,
+		Workers:  2,
+		Fixtures: fixtures,
+	}
+}
+
+func runTester(t *testing.T, cfg Config) (Report, error) {
+	tester, err := NewTester(cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tester.Start(ctx)
+	select {
+	case <-tester.Done():
+	case <-ctx.Done():
+		t.Fatal("tester is not finished")
+	}
+	require.NoError(t, tester.Close())
+	return tester.Report(), tester.Err()
+}
+
+// requireLiveReport - checks report of the whole liveDomains data
+func requireLiveReport(t *testing.T, report Report) {
+	require.Equal(t, map[Category]int{
+		CategoryForward: 7,
+		CategoryExpiry:  6,
+		CategoryReverse: 4,
+	}, report.Checks)
+	requireLiveMismatches(t, report)
+}
+
+func requireLiveMismatches(t *testing.T, report Report) {
+	require.Equal(t, 7, report.Processed)
+	require.EqualValues(t, testHeight, report.Height)
+	require.Equal(t, map[Category][]Mismatch{
+		CategoryForward: {
+			{Domain: "bob.stark", Indexer: "0x2", StarknetId: "0x3", Message: "domain is resolved to different address", Height: testHeight},
+			{Domain: "dave.stark", Indexer: "0xd", Message: "domain is unknown to Starknet ID API", Height: testHeight},
+		},
+		CategoryExpiry: {
+			{Domain: "carol.stark", Indexer: "2030-01-01T00:00:00Z", StarknetId: "2030-01-01T01:00:00Z", Message: "domain expires at different time", Height: testHeight},
+		},
+		CategoryReverse: {
+			{Domain: "alice.stark", Address: "0xe", Indexer: "0x1a", StarknetId: "0xe", Message: "reverse domain is resolved to different address", Height: testHeight},
+			{Domain: "frank.stark", Address: "0xabc", StarknetId: "0xabc", Message: "reverse domain is not found in the indexer", Height: testHeight},
+		},
+	}, report.Mismatches)
+}
+
+func TestTester_Live(t *testing.T) {
+	server := newLiveServer(t)
+
+	report, err := runTester(t, testConfig(server.URL, nil))
+	require.NoError(t, err)
+	requireLiveReport(t, report)
+
+	for cursor, count := range server.pages {
+		switch cursor {
+		case 0, 3, 6, 7:
+			require.EqualValues(t, 1, count.Load(), "cursor %d", cursor)
+		default:
+			require.Zero(t, count.Load(), "cursor %d", cursor)
+		}
+	}
+}
+
+func TestTester_Resume(t *testing.T) {
+	server := newLiveServer(t)
+	server.failAfter.Store(1)
+
+	cfg := testConfig(server.URL, nil)
+	cfg.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.json")
+
+	report, err := runTester(t, cfg)
+	require.Error(t, err)
+	require.Equal(t, 3, report.Processed)
+
+	checkpoint, err := loadCheckpoint(cfg.Checkpoint)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, checkpoint.Cursor)
+
+	server.failAfter.Store(0)
+	report, err = runTester(t, cfg)
+	require.NoError(t, err)
+	requireLiveMismatches(t, report)
+	// reverse resolution of 0x1a is checked before and after resume
+	require.Equal(t, 5, report.Checks[CategoryReverse])
+
+	require.EqualValues(t, 1, server.pages[0].Load())
+	require.EqualValues(t, 2, server.pages[3].Load())
+	require.NoFileExists(t, cfg.Checkpoint)
+}
+
+func TestTester_RecordReplay(t *testing.T) {
+	server := newLiveServer(t)
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+
+	recorded, err := runTester(t, testConfig(server.URL, &FixturesConfig{
+		Mode: "record",
+		Path: path,
+	}))
+	require.NoError(t, err)
+	requireLiveReport(t, recorded)
+	require.FileExists(t, path)
+
+	count := server.requests.Load()
+	server.Close()
+
+	// hosts are not used in replay mode, so the APIs are unreachable
+	replayed, err := runTester(t, testConfig("http://127.0.0.1:1", &FixturesConfig{
+		Mode: "replay",
+		Path: path,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+	require.Equal(t, count, server.requests.Load())
+}
+
+func TestTester_Fixtures(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig("https://app.starknet.id", &FixturesConfig{
+		Mode: "replay",
+		Path: filepath.Join("testdata", "fixtures.json"),
+	})
+	cfg.GraphQlApi.Url = "https://starknet-id.dipdup.net/v1/graphql"
+	cfg.Report = ReportConfig{
+		JSON:  filepath.Join(dir, "report.json"),
+		JUnit: filepath.Join(dir, "report.xml"),
+	}
+
+	report, err := runTester(t, cfg)
+	require.NoError(t, err)
+	requireLiveReport(t, report)
+
+	raw, err := os.ReadFile(cfg.Report.JSON)
+	require.NoError(t, err)
+	var written Report
+	require.NoError(t, json.Unmarshal(raw, &written))
+	require.Equal(t, report, written)
+	require.FileExists(t, cfg.Report.JUnit)
+}
+
+func TestTester_Daemon(t *testing.T) {
+	server := newLiveServer(t)
+	path := filepath.Join(t.TempDir(), "mismatches.ndjson")
+
+	cfg := testConfig(server.URL, nil)
+	cfg.Daemon = &DaemonConfig{
+		Window:     20,
+		Mismatches: path,
+	}
+	tester, err := NewTester(cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tester.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		return tester.Report().Processed >= 50
+	}, 10*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case <-tester.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("daemon is not stopped")
+	}
+	require.NoError(t, tester.Close())
+	require.NoError(t, tester.Err())
+
+	// mismatches are only counted, they are written to the file
+	report := tester.Report()
+	require.NotZero(t, report.Failed())
+	require.Empty(t, report.Mismatches)
+
+	recorder := httptest.NewRecorder()
+	tester.monitor.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	metrics := recorder.Body.String()
+	require.Contains(t, metrics, `starknet_id_tester_mismatch_rate{category="any"}`)
+	require.Contains(t, metrics, `starknet_id_tester_mismatches_total{category="forward"}`)
+	require.Contains(t, metrics, "starknet_id_tester_head_height 100")
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var count int
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var record mismatchRecord
+		require.NoError(t, decoder.Decode(&record))
+		require.EqualValues(t, testHeight, record.Height)
+		require.Contains(t, categories, record.Category)
+		require.NotEqual(t, CategoryError, record.Category, record.Message)
+		count += 1
+	}
+	require.NotZero(t, count)
+}
@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	"github.com/pkg/errors"
+)
+
+// Recorder - writes messages of data source to archive file. It's attached to the source output next to the indexer.
+// After resubscription the source repeats messages of blocks which aren't saved by the indexer yet, so blocks up to
+// the last recorded one and recorded addresses are written once.
+type Recorder struct {
+	modules.BaseModule
+
+	writer *stream.Writer
+
+	lastHeight    uint64
+	hasBlocks     bool
+	lastAddressId uint64
+}
+
+// NewRecorder - creates archive file or truncates existing one
+func NewRecorder(path string) (*Recorder, error) {
+	writer, err := stream.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "create archive")
+	}
+	recorder := &Recorder{
+		BaseModule: modules.New(RecorderName),
+		writer:     writer,
+	}
+	recorder.CreateInput(InputMessages)
+	return recorder, nil
+}
+
+// Start -
+func (r *Recorder) Start(ctx context.Context) {
+	r.G.GoCtx(ctx, r.listen)
+}
+
+func (r *Recorder) listen(ctx context.Context) {
+	input := r.MustInput(InputMessages)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-input.Listen():
+			if !ok {
+				return
+			}
+			sub, ok := msg.(*pb.Subscription)
+			if !ok {
+				r.Log.Warn().Msgf("unknown message: %T", msg)
+				continue
+			}
+			if err := r.write(sub); err != nil {
+				r.Log.Err(err).Msg("recording message")
+			}
+		}
+	}
+}
+
+func (r *Recorder) write(msg *pb.Subscription) error {
+	switch {
+	case msg.Block != nil:
+		if r.isRecorded(msg.Block.Height) {
+			return nil
+		}
+	case msg.Event != nil:
+		if r.isRecorded(msg.Event.Height) {
+			return nil
+		}
+	case msg.Address != nil:
+		if msg.Address.Id <= r.lastAddressId {
+			return nil
+		}
+		r.lastAddressId = msg.Address.Id
+	case msg.EndOfBlock != nil:
+		if r.isRecorded(msg.EndOfBlock.Height) {
+			return nil
+		}
+		r.lastHeight = msg.EndOfBlock.Height
+		r.hasBlocks = true
+	default:
+		// subscription responses don't carry data
+		return nil
+	}
+
+	if err := r.writer.Write(msg); err != nil {
+		return err
+	}
+	if msg.EndOfBlock != nil {
+		return r.writer.Flush()
+	}
+	return nil
+}
+
+func (r *Recorder) isRecorded(height uint64) bool {
+	return r.hasBlocks && height <= r.lastHeight
+}
+
+// Close - waits for the listening thread and closes archive file
+func (r *Recorder) Close() error {
+	r.G.Wait()
+	return r.writer.Close()
+}
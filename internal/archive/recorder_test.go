@@ -0,0 +1,60 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	generalPB "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+
+	output := modules.NewOutput("test")
+	output.Attach(recorder.MustInput(InputMessages))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recorder.Start(ctx)
+
+	messages := testMessages()
+	// subscription response
+	output.Push(&pb.Subscription{Response: &generalPB.SubscribeResponse{Id: 1}})
+	for i := range messages {
+		output.Push(messages[i])
+	}
+	// resubscription repeats the second block and the address
+	output.Push(messages[1])
+	output.Push(messages[4])
+	output.Push(messages[5])
+	output.Push(&pb.Subscription{EndOfBlock: &pb.EndOfBlock{Height: 3}})
+
+	require.Eventually(t, func() bool {
+		return len(recorder.MustInput(InputMessages).Listen()) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, recorder.Close())
+
+	r, err := stream.Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	expected := append(messages, &pb.Subscription{EndOfBlock: &pb.EndOfBlock{Height: 3}})
+	for i := range expected {
+		msg, err := r.Next()
+		require.NoError(t, err)
+		require.Zero(t, msg.GetResponse().GetId())
+		msg.Response = nil
+		require.Equal(t, expected[i].String(), msg.String())
+	}
+	_, err = r.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
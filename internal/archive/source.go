@@ -0,0 +1,201 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	generalPB "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc/pb"
+	"github.com/pkg/errors"
+)
+
+// module names
+const (
+	SourceName     = "archive_source"
+	RecorderName   = "archive_recorder"
+	InputMessages  = "messages"
+	OutputMessages = grpc.OutputMessages
+)
+
+// Config - archive file which is indexed instead of live stream
+type Config struct {
+	Path string `validate:"required" yaml:"path"`
+}
+
+// Source - reads archive file and sends its messages as starknet-indexer gRPC client sends messages of the subscription.
+// Archive contains messages of one subscription, so filters of the request aren't applied except resuming ones:
+// blocks and events up to the height of event filters and addresses up to the id of address filter are skipped.
+// Nothing is sent after the end of the archive.
+type Source struct {
+	modules.BaseModule
+
+	cfg Config
+
+	subscriptions map[uint64]context.CancelFunc
+	lastId        uint64
+	reconnect     chan uint64
+	mx            *sync.Mutex
+}
+
+// NewSource -
+func NewSource(cfg Config) *Source {
+	source := &Source{
+		BaseModule:    modules.New(SourceName),
+		cfg:           cfg,
+		subscriptions: make(map[uint64]context.CancelFunc),
+		reconnect:     make(chan uint64),
+		mx:            new(sync.Mutex),
+	}
+	source.CreateOutput(OutputMessages)
+	return source
+}
+
+// Connect - checks that archive file exists
+func (s *Source) Connect(ctx context.Context) error {
+	if _, err := os.Stat(s.cfg.Path); err != nil {
+		return errors.Wrap(err, "archive")
+	}
+	return nil
+}
+
+// Start -
+func (s *Source) Start(ctx context.Context) {}
+
+// Subscribe - starts reading of the archive from the beginning
+func (s *Source) Subscribe(ctx context.Context, req *pb.SubscribeRequest) (uint64, error) {
+	skip, err := newResumeFilter(req)
+	if err != nil {
+		return 0, err
+	}
+	reader, err := stream.Open(s.cfg.Path)
+	if err != nil {
+		return 0, errors.Wrap(err, "open archive")
+	}
+
+	s.mx.Lock()
+	s.lastId++
+	id := s.lastId
+	ctx, cancel := context.WithCancel(ctx)
+	s.subscriptions[id] = cancel
+	s.mx.Unlock()
+
+	s.G.GoCtx(ctx, func(ctx context.Context) {
+		defer reader.Close()
+
+		if err := s.read(ctx, id, reader, skip); err != nil {
+			s.Log.Err(err).Str("path", s.cfg.Path).Msg("reading archive")
+		}
+	})
+	return id, nil
+}
+
+// Unsubscribe - stops reading of the archive
+func (s *Source) Unsubscribe(ctx context.Context, id uint64) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	cancel, ok := s.subscriptions[id]
+	if !ok {
+		return errors.Errorf("unknown subscription: %d", id)
+	}
+	cancel()
+	delete(s.subscriptions, id)
+	return nil
+}
+
+// Reconnect - file is never disconnected. The channel is closed on Close.
+func (s *Source) Reconnect() <-chan uint64 {
+	return s.reconnect
+}
+
+// Close - waits for reading threads
+func (s *Source) Close() error {
+	s.G.Wait()
+	close(s.reconnect)
+	return nil
+}
+
+func (s *Source) read(ctx context.Context, id uint64, reader *stream.Reader, skip resumeFilter) error {
+	output := s.MustOutput(OutputMessages)
+
+	var count int
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		msg, err := reader.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				s.Log.Info().Str("path", s.cfg.Path).Int("messages", count).Msg("end of archive")
+				return nil
+			}
+			return err
+		}
+		if skip.skip(msg) {
+			continue
+		}
+		msg.Response = &generalPB.SubscribeResponse{Id: id}
+		output.Push(msg)
+		count++
+	}
+}
+
+// resumeFilter - the first height and the last address id which are sent to the subscriber
+type resumeFilter struct {
+	from      uint64
+	addressId uint64
+}
+
+func newResumeFilter(req *pb.SubscribeRequest) (resumeFilter, error) {
+	var f resumeFilter
+	for i, filter := range req.GetEvents() {
+		var from uint64
+		switch typ := filter.GetHeight().GetFilter().(type) {
+		case nil:
+		case *pb.IntegerFilter_Gt:
+			from = typ.Gt + 1
+		case *pb.IntegerFilter_Gte:
+			from = typ.Gte
+		default:
+			return f, errors.Errorf("event filter %d: only `gt` and `gte` height filters are supported", i)
+		}
+		if i == 0 || from < f.from {
+			f.from = from
+		}
+	}
+	for i, filter := range req.GetAddresses() {
+		switch typ := filter.GetId().GetFilter().(type) {
+		case nil:
+		case *pb.IntegerFilter_Gt:
+			if typ.Gt > f.addressId {
+				f.addressId = typ.Gt
+			}
+		default:
+			return f, errors.Errorf("address filter %d: only `gt` id filter is supported", i)
+		}
+	}
+	return f, nil
+}
+
+func (f resumeFilter) skip(msg *pb.Subscription) bool {
+	switch {
+	case msg.Block != nil:
+		return msg.Block.Height < f.from
+	case msg.Event != nil:
+		return msg.Event.Height < f.from
+	case msg.EndOfBlock != nil:
+		return msg.EndOfBlock.Height < f.from
+	case msg.Address != nil:
+		return msg.Address.Id <= f.addressId
+	default:
+		return false
+	}
+}
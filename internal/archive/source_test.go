@@ -0,0 +1,119 @@
+package archive
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/stream"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, input *modules.Input) *pb.Subscription {
+	t.Helper()
+	select {
+	case msg := <-input.Listen():
+		sub, ok := msg.(*pb.Subscription)
+		require.True(t, ok)
+		return sub
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "message is not received")
+		return nil
+	}
+}
+
+func requireNoMessages(t *testing.T, input *modules.Input) {
+	t.Helper()
+	select {
+	case msg := <-input.Listen():
+		require.FailNowf(t, "unexpected message", "%v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// testMessages - two blocks: the first one with an address and an event, the second one is empty
+func testMessages() []*pb.Subscription {
+	return []*pb.Subscription{
+		{Block: &pb.Block{Height: 1, Time: 1700000000}},
+		{Address: &pb.Address{Id: 16, Hash: []byte{0x1}, Height: 1}},
+		{Event: &pb.Event{Id: 1, Height: 1, Time: 1700000000, Name: "Transfer", ParsedData: []byte(`{"to":"0x1"}`)}},
+		{EndOfBlock: &pb.EndOfBlock{Height: 1}},
+		{Block: &pb.Block{Height: 2, Time: 1700000100}},
+		{EndOfBlock: &pb.EndOfBlock{Height: 2}},
+	}
+}
+
+func writeArchive(t *testing.T, path string, messages []*pb.Subscription) {
+	t.Helper()
+	w, err := stream.Create(path)
+	require.NoError(t, err)
+	for i := range messages {
+		require.NoError(t, w.Write(messages[i]))
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson.gz")
+	writeArchive(t, path, testMessages())
+
+	tests := []struct {
+		name      string
+		sub       grpc.Subscription
+		wantCount int
+		wantFirst uint64
+	}{
+		{
+			name: "from the beginning",
+			sub: grpc.Subscription{
+				EventFilter:   []*grpc.EventFilter{{Height: &grpc.IntegerFilter{Gt: 0}}},
+				AddressFilter: []*grpc.AddressFilter{{Id: &grpc.IntegerFilter{Gt: 0}}},
+			},
+			wantCount: 6,
+			wantFirst: 1,
+		}, {
+			name: "resume after the first block",
+			sub: grpc.Subscription{
+				EventFilter:   []*grpc.EventFilter{{Height: &grpc.IntegerFilter{Gt: 1}}},
+				AddressFilter: []*grpc.AddressFilter{{Id: &grpc.IntegerFilter{Gt: 16}}},
+			},
+			wantCount: 2,
+			wantFirst: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			source := NewSource(Config{Path: path})
+			input := modules.NewInput("test")
+			source.MustOutput(OutputMessages).Attach(input)
+
+			require.NoError(t, source.Connect(ctx))
+			id, err := source.Subscribe(ctx, tt.sub.ToGrpcFilter())
+			require.NoError(t, err)
+
+			first := receive(t, input)
+			require.Equal(t, id, first.GetResponse().GetId())
+			require.Equal(t, tt.wantFirst, first.GetBlock().GetHeight())
+			for i := 1; i < tt.wantCount; i++ {
+				receive(t, input)
+			}
+			requireNoMessages(t, input)
+
+			require.NoError(t, source.Unsubscribe(ctx, id))
+			require.Error(t, source.Unsubscribe(ctx, id))
+			require.NoError(t, source.Close())
+		})
+	}
+}
+
+func TestSource_Connect(t *testing.T) {
+	source := NewSource(Config{Path: filepath.Join(t.TempDir(), "missing.ndjson")})
+	require.Error(t, source.Connect(context.Background()))
+}
@@ -0,0 +1,221 @@
+package fakeindexer
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	generalPB "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc/pb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	// clients of starknet-indexer compress requests by gzip
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+const bufferSize = 1024
+
+// Subscription - active subscription of the client
+type Subscription struct {
+	Id      uint64
+	Request *pb.SubscribeRequest
+
+	messages chan *pb.Subscription
+	done     chan struct{}
+}
+
+// Server - in-process fake of starknet-indexer gRPC API. Subscriptions receive scripted messages sent by the test.
+type Server struct {
+	pb.UnimplementedIndexerServiceServer
+
+	address string
+	server  *grpc.Server
+	lastId  atomic.Uint64
+
+	subscriptions map[uint64]*Subscription
+	requests      []*pb.SubscribeRequest
+	unsubscribed  []uint64
+	subscribed    chan *Subscription
+	mx            sync.Mutex
+	wg            *sync.WaitGroup
+}
+
+// New - creates server listening on random local port and starts serving
+func New() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "listen")
+	}
+
+	s := &Server{
+		address:       listener.Addr().String(),
+		subscriptions: make(map[uint64]*Subscription),
+		subscribed:    make(chan *Subscription, bufferSize),
+		wg:            new(sync.WaitGroup),
+	}
+	s.serve(listener)
+	return s, nil
+}
+
+func (s *Server) serve(listener net.Listener) {
+	server := grpc.NewServer()
+	pb.RegisterIndexerServiceServer(server, s)
+
+	s.mx.Lock()
+	s.server = server
+	s.mx.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		_ = server.Serve(listener)
+	}()
+}
+
+// Address - returns address of the server in host:port form
+func (s *Server) Address() string {
+	return s.address
+}
+
+// Subscribe - sends subscription id and then messages sent to the subscription until unsubscribe or disconnect
+func (s *Server) Subscribe(req *pb.SubscribeRequest, stream pb.IndexerService_SubscribeServer) error {
+	sub := &Subscription{
+		Id:       s.lastId.Add(1),
+		Request:  req,
+		messages: make(chan *pb.Subscription, bufferSize),
+		done:     make(chan struct{}),
+	}
+	if err := stream.SendMsg(&generalPB.SubscribeResponse{Id: sub.Id}); err != nil {
+		return err
+	}
+
+	s.mx.Lock()
+	s.subscriptions[sub.Id] = sub
+	s.requests = append(s.requests, req)
+	s.mx.Unlock()
+
+	defer func() {
+		s.mx.Lock()
+		delete(s.subscriptions, sub.Id)
+		s.mx.Unlock()
+	}()
+
+	select {
+	case s.subscribed <- sub:
+	default:
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sub.done:
+			return nil
+		case msg := <-sub.messages:
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Unsubscribe - stops sending messages to the subscription
+func (s *Server) Unsubscribe(ctx context.Context, req *generalPB.UnsubscribeRequest) (*generalPB.UnsubscribeResponse, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	sub, ok := s.subscriptions[req.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown subscription: %d", req.GetId())
+	}
+	close(sub.done)
+	delete(s.subscriptions, sub.Id)
+	s.unsubscribed = append(s.unsubscribed, sub.Id)
+
+	return &generalPB.UnsubscribeResponse{
+		Id: sub.Id,
+		Response: &generalPB.Message{
+			Message: "success",
+		},
+	}, nil
+}
+
+// WaitSubscription - waits for the next subscription of any client
+func (s *Server) WaitSubscription(ctx context.Context) (*Subscription, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case sub := <-s.subscribed:
+		return sub, nil
+	}
+}
+
+// Send - sends messages to the subscription. Response of every message is set to the subscription id.
+func (s *Server) Send(id uint64, messages ...*pb.Subscription) error {
+	s.mx.Lock()
+	sub, ok := s.subscriptions[id]
+	s.mx.Unlock()
+	if !ok {
+		return errors.Errorf("unknown subscription: %d", id)
+	}
+
+	for i := range messages {
+		msg := proto.Clone(messages[i]).(*pb.Subscription)
+		msg.Response = &generalPB.SubscribeResponse{Id: id}
+		select {
+		case sub.messages <- msg:
+		case <-sub.done:
+			return errors.Errorf("subscription %d is closed", id)
+		}
+	}
+	return nil
+}
+
+// Requests - returns requests of all subscriptions in order of subscribing
+func (s *Server) Requests() []*pb.SubscribeRequest {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return append([]*pb.SubscribeRequest(nil), s.requests...)
+}
+
+// Unsubscribed - returns ids of subscriptions closed by clients
+func (s *Server) Unsubscribed() []uint64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return append([]uint64(nil), s.unsubscribed...)
+}
+
+// Disconnect - closes all connections and subscriptions and starts listening on the same address again,
+// so clients reconnect and have to resubscribe
+func (s *Server) Disconnect() error {
+	s.stop()
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return errors.Wrap(err, "listen after disconnect")
+	}
+	s.serve(listener)
+	return nil
+}
+
+func (s *Server) stop() {
+	s.mx.Lock()
+	server := s.server
+	s.mx.Unlock()
+
+	server.Stop()
+	s.wg.Wait()
+}
+
+// Close - stops the server
+func (s *Server) Close() error {
+	s.stop()
+	return nil
+}
@@ -0,0 +1,95 @@
+package fakeindexer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	grpcSDK "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, input *modules.Input) *pb.Subscription {
+	t.Helper()
+	select {
+	case msg := <-input.Listen():
+		sub, ok := msg.(*pb.Subscription)
+		require.True(t, ok)
+		return sub
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "message is not received")
+		return nil
+	}
+}
+
+func TestServer(t *testing.T) {
+	server, err := New()
+	require.NoError(t, err)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := grpc.NewClientWithServerAddress(server.Address())
+	input := modules.NewInput("test")
+	client.MustOutput(grpc.OutputMessages).Attach(input)
+
+	require.NoError(t, client.Connect(ctx, grpcSDK.WaitServer()))
+	client.Start(ctx)
+
+	request := grpc.Subscription{
+		EventFilter: []*grpc.EventFilter{
+			{Height: &grpc.IntegerFilter{Gt: 10}},
+		},
+	}
+	id, err := client.Subscribe(ctx, request.ToGrpcFilter())
+	require.NoError(t, err)
+
+	sub, err := server.WaitSubscription(ctx)
+	require.NoError(t, err)
+	require.Equal(t, id, sub.Id)
+	require.EqualValues(t, 10, sub.Request.Events[0].Height.GetGt())
+
+	require.NoError(t, server.Send(id,
+		&pb.Subscription{Event: &pb.Event{Id: 1, Height: 11, Name: "Transfer"}},
+		&pb.Subscription{EndOfBlock: &pb.EndOfBlock{Height: 11}},
+	))
+	event := receive(t, input)
+	require.Equal(t, id, event.GetResponse().GetId())
+	require.Equal(t, "Transfer", event.Event.Name)
+	end := receive(t, input)
+	require.EqualValues(t, 11, end.EndOfBlock.Height)
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		other, err := client.Subscribe(ctx, request.ToGrpcFilter())
+		require.NoError(t, err)
+		sub, err := server.WaitSubscription(ctx)
+		require.NoError(t, err)
+		require.Equal(t, other, sub.Id)
+
+		require.NoError(t, client.Unsubscribe(ctx, other))
+		require.Equal(t, []uint64{other}, server.Unsubscribed())
+		require.Error(t, client.Unsubscribe(ctx, other))
+		require.Len(t, server.Requests(), 2)
+	})
+
+	// the client replaces its streams in reconnect goroutine after sending ids to Reconnect channel without synchronization,
+	// so nothing is subscribed by the client after reconnect
+	t.Run("disconnect", func(t *testing.T) {
+		require.NoError(t, server.Disconnect())
+
+		// ids of unsubscribed streams are returned too, the order is random
+		timeout := time.After(10 * time.Second)
+		for reconnected := uint64(0); reconnected != id; {
+			select {
+			case reconnected = <-client.Reconnect():
+			case <-timeout:
+				require.FailNow(t, "client is not reconnected")
+			}
+		}
+		require.Error(t, server.Send(id, &pb.Subscription{EndOfBlock: &pb.EndOfBlock{Height: 12}}))
+	})
+}
@@ -0,0 +1,306 @@
+package fakenode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/goccy/go-json"
+)
+
+// JSON-RPC error codes of Starknet API
+const (
+	codeContractNotFound = 20
+	codeBlockNotFound    = 24
+	codeMethodNotFound   = -32601
+	codeInvalidParams    = -32602
+)
+
+// Event - event emitted in the block. The first key is selector of the name.
+type Event struct {
+	Contract    string
+	Name        string
+	Keys        []string
+	Data        []string
+	Transaction string
+}
+
+// Block - block with events. Transactions are ordered as in the block, events are ordered by transactions.
+type Block struct {
+	Number       uint64
+	Hash         string
+	Timestamp    uint64
+	Transactions []string
+	Events       []Event
+}
+
+// Server - in-process fake of Starknet JSON-RPC node. It serves blocks, events and class ABIs added by the test.
+// Blocks between added ones are empty, the last added block is the head.
+type Server struct {
+	server *httptest.Server
+
+	blocks   map[uint64]Block
+	head     uint64
+	abis     map[string]json.RawMessage
+	requests map[string]int
+	mx       sync.Mutex
+}
+
+// New - creates server listening on random local port and starts serving
+func New() *Server {
+	s := &Server{
+		blocks:   make(map[uint64]Block),
+		abis:     make(map[string]json.RawMessage),
+		requests: make(map[string]int),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL - returns URL of the node
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// AddBlocks - adds blocks. The head is moved to the highest block.
+func (s *Server) AddBlocks(blocks ...Block) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for i := range blocks {
+		s.blocks[blocks[i].Number] = blocks[i]
+		if blocks[i].Number > s.head {
+			s.head = blocks[i].Number
+		}
+	}
+}
+
+// SetAbi - sets ABI of the class deployed at the contract. Contracts without ABI aren't found by `starknet_getClassAt`.
+func (s *Server) SetAbi(contract string, abi json.RawMessage) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.abis[normalize(contract)] = abi
+}
+
+// Requests - returns count of received requests of the method
+func (s *Server) Requests(method string) int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return s.requests[method]
+}
+
+// Close - stops the server
+func (s *Server) Close() error {
+	s.server.Close()
+	return nil
+}
+
+type request struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	ID     uint64            `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	Version string    `json:"jsonrpc"`
+	Result  any       `json:"result,omitempty"`
+	Error   *rpcError `json:"error,omitempty"`
+	ID      uint64    `json:"id"`
+}
+
+type blockID struct {
+	BlockNumber *uint64 `json:"block_number"`
+}
+
+type eventsFilter struct {
+	FromBlock         blockID    `json:"from_block"`
+	ToBlock           blockID    `json:"to_block"`
+	Address           string     `json:"address"`
+	Keys              [][]string `json:"keys"`
+	ChunkSize         int        `json:"chunk_size"`
+	ContinuationToken string     `json:"continuation_token"`
+}
+
+type emittedEvent struct {
+	FromAddress     string   `json:"from_address"`
+	Keys            []string `json:"keys"`
+	Data            []string `json:"data"`
+	BlockHash       string   `json:"block_hash"`
+	BlockNumber     uint64   `json:"block_number"`
+	TransactionHash string   `json:"transaction_hash"`
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mx.Lock()
+	s.requests[req.Method]++
+	result, rpcErr := s.call(req)
+	s.mx.Unlock()
+
+	resp := response{
+		Version: "2.0",
+		Result:  result,
+		Error:   rpcErr,
+		ID:      req.ID,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) call(req request) (any, *rpcError) {
+	switch req.Method {
+	case "starknet_blockNumber":
+		return s.head, nil
+	case "starknet_getBlockWithTxHashes":
+		var id blockID
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &id) != nil || id.BlockNumber == nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+		}
+		return s.getBlock(*id.BlockNumber)
+	case "starknet_getEvents":
+		var filter eventsFilter
+		if len(req.Params) < 1 || json.Unmarshal(req.Params[0], &filter) != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+		}
+		return s.getEvents(filter)
+	case "starknet_getClassAt":
+		var contract string
+		if len(req.Params) < 2 || json.Unmarshal(req.Params[1], &contract) != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+		}
+		abi, ok := s.abis[normalize(contract)]
+		if !ok {
+			return nil, &rpcError{Code: codeContractNotFound, Message: "Contract not found"}
+		}
+		return map[string]any{"abi": abi}, nil
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "Method not found"}
+	}
+}
+
+func (s *Server) getBlock(number uint64) (any, *rpcError) {
+	if number > s.head {
+		return nil, &rpcError{Code: codeBlockNotFound, Message: "Block not found"}
+	}
+	block := s.block(number)
+	transactions := block.Transactions
+	if transactions == nil {
+		transactions = []string{}
+	}
+	return map[string]any{
+		"block_hash":   block.Hash,
+		"block_number": block.Number,
+		"timestamp":    block.Timestamp,
+		"transactions": transactions,
+	}, nil
+}
+
+func (s *Server) block(number uint64) Block {
+	block, ok := s.blocks[number]
+	if !ok {
+		block = Block{Number: number}
+	}
+	if block.Hash == "" {
+		block.Hash = "0x" + strconv.FormatUint(number, 16)
+	}
+	return block
+}
+
+// getEvents - returns events matching the filter. Continuation token is offset of the next chunk.
+func (s *Server) getEvents(filter eventsFilter) (any, *rpcError) {
+	if filter.FromBlock.BlockNumber == nil || filter.ToBlock.BlockNumber == nil || filter.ChunkSize <= 0 {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+	}
+
+	numbers := make([]uint64, 0, len(s.blocks))
+	for number := range s.blocks {
+		if number >= *filter.FromBlock.BlockNumber && number <= *filter.ToBlock.BlockNumber {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	events := make([]emittedEvent, 0)
+	for _, number := range numbers {
+		block := s.block(number)
+		for _, event := range block.Events {
+			keys := append([]string{encoding.GetSelectorWithPrefixFromName(event.Name)}, event.Keys...)
+			if filter.Address != "" && normalize(filter.Address) != normalize(event.Contract) {
+				continue
+			}
+			if !matchKeys(filter.Keys, keys) {
+				continue
+			}
+			events = append(events, emittedEvent{
+				FromAddress:     event.Contract,
+				Keys:            keys,
+				Data:            event.Data,
+				BlockHash:       block.Hash,
+				BlockNumber:     block.Number,
+				TransactionHash: event.Transaction,
+			})
+		}
+	}
+
+	var offset int
+	if filter.ContinuationToken != "" {
+		value, err := strconv.Atoi(filter.ContinuationToken)
+		if err != nil || value > len(events) {
+			return nil, &rpcError{Code: 33, Message: "The supplied continuation token is invalid or unknown"}
+		}
+		offset = value
+	}
+
+	result := map[string]any{}
+	end := offset + filter.ChunkSize
+	if end < len(events) {
+		result["continuation_token"] = strconv.Itoa(end)
+	} else {
+		end = len(events)
+	}
+	result["events"] = events[offset:end]
+	return result, nil
+}
+
+func matchKeys(filter [][]string, keys []string) bool {
+	for i := range filter {
+		if len(filter[i]) == 0 {
+			continue
+		}
+		if i >= len(keys) {
+			return false
+		}
+		var found bool
+		for _, key := range filter[i] {
+			if normalize(key) == normalize(keys[i]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize - felts are compared without prefix and leading zeroes
+func normalize(value string) string {
+	return encoding.TrimHex(strings.ToLower(value))
+}
@@ -0,0 +1,89 @@
+package fakenode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/jsonrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	const contract = "0x05dbdedc203e92749e2e746e2d40a768d966bd243df04a6b712e222bc040a9af"
+
+	server := New()
+	defer server.Close()
+
+	server.AddBlocks(
+		Block{
+			Number:       10,
+			Timestamp:    1700000000,
+			Transactions: []string{"0xa"},
+			Events: []Event{
+				{Contract: contract, Name: "Transfer", Data: []string{"0x0", "0x1", "0x1", "0x0"}, Transaction: "0xa"},
+				{Contract: contract, Name: "Approval", Data: []string{"0x1", "0x2", "0x1", "0x0"}, Transaction: "0xa"},
+				{Contract: "0x1", Name: "Transfer", Data: []string{"0x0", "0x1", "0x1", "0x0"}, Transaction: "0xa"},
+			},
+		},
+		Block{
+			Number: 12,
+			Events: []Event{
+				{Contract: contract, Name: "Transfer", Data: []string{"0x1", "0x2", "0x1", "0x0"}, Transaction: "0xb"},
+			},
+		},
+	)
+	server.SetAbi(contract, []byte(`[]`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := jsonrpc.NewApi(jsonrpc.Config{URL: server.URL()})
+
+	head, err := api.BlockNumber(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 12, head)
+
+	block, err := api.GetBlock(ctx, 11)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, block.BlockNumber)
+	require.Equal(t, "0xb", block.BlockHash)
+	require.Empty(t, block.Transactions)
+
+	_, err = api.GetBlock(ctx, 13)
+	require.Error(t, err)
+
+	filter := jsonrpc.EventsFilter{
+		FromBlock: jsonrpc.BlockID{Number: 0},
+		ToBlock:   jsonrpc.Latest,
+		Address:   "0x5dbdedc203e92749e2e746e2d40a768d966bd243df04a6b712e222bc040a9af",
+		Keys:      [][]string{{encoding.GetSelectorWithPrefixFromName("Transfer")}},
+		ChunkSize: 1,
+	}
+	_, err = api.GetEvents(ctx, filter)
+	require.Error(t, err, "block tags aren't supported")
+
+	filter.ToBlock = jsonrpc.BlockID{Number: 12}
+	chunk, err := api.GetEvents(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, chunk.Events, 1)
+	require.EqualValues(t, 10, chunk.Events[0].BlockNumber)
+	require.Equal(t, "0xa", chunk.Events[0].TransactionHash)
+	require.NotEmpty(t, chunk.ContinuationToken)
+
+	filter.ContinuationToken = chunk.ContinuationToken
+	chunk, err = api.GetEvents(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, chunk.Events, 1)
+	require.EqualValues(t, 12, chunk.Events[0].BlockNumber)
+	require.Empty(t, chunk.ContinuationToken)
+
+	_, err = api.GetAbi(ctx, contract)
+	require.NoError(t, err)
+	_, err = api.GetAbi(ctx, "0x1")
+	require.Error(t, err)
+
+	require.Equal(t, 1, server.Requests("starknet_blockNumber"))
+	require.Equal(t, 3, server.Requests("starknet_getEvents"))
+}
@@ -0,0 +1,229 @@
+package httpfixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Request - recorded request. Requests are matched by method, path, query and body, host is ignored,
+// so fixtures recorded from live APIs are served by a stand-in server. JSON body is stored in Body, other data in Text.
+type Request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Text   string          `json:"text,omitempty"`
+}
+
+// Response - recorded response. JSON body is stored in Body, other data in Text.
+type Response struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+	Text   string          `json:"text,omitempty"`
+}
+
+// Bytes - returns response body
+func (r Response) Bytes() []byte {
+	if len(r.Body) > 0 {
+		return r.Body
+	}
+	return []byte(r.Text)
+}
+
+// Fixture - request with its response
+type Fixture struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Store - fixtures by request. It's safe for concurrent use.
+type Store struct {
+	fixtures map[string]Fixture
+	mx       sync.RWMutex
+}
+
+// NewStore -
+func NewStore() *Store {
+	return &Store{
+		fixtures: make(map[string]Fixture),
+	}
+}
+
+// Load - reads fixtures from JSON file
+func Load(path string) (*Store, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read fixtures")
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(raw, &fixtures); err != nil {
+		return nil, errors.Wrapf(err, "decode fixtures %s", path)
+	}
+
+	store := NewStore()
+	for i := range fixtures {
+		store.Add(fixtures[i])
+	}
+	return store, nil
+}
+
+// Save - writes fixtures to JSON file ordered by request, so records of the same requests produce the same file
+func (s *Store) Save(path string) error {
+	fixtures := s.List()
+	raw, err := json.MarshalIndent(fixtures, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+// Add - adds fixture. Fixture of the same request is replaced.
+func (s *Store) Add(fixture Fixture) {
+	fixture.Request.Body = normalizeJSON(fixture.Request.Body)
+	fixture.Response.Body = compactJSON(fixture.Response.Body)
+
+	s.mx.Lock()
+	s.fixtures[fixture.Request.key()] = fixture
+	s.mx.Unlock()
+}
+
+// Get - returns response recorded for the request
+func (s *Store) Get(request Request) (Response, bool) {
+	request.Body = normalizeJSON(request.Body)
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	fixture, ok := s.fixtures[request.key()]
+	return fixture.Response, ok
+}
+
+// Len -
+func (s *Store) Len() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	return len(s.fixtures)
+}
+
+// List - returns fixtures ordered by request
+func (s *Store) List() []Fixture {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	keys := make([]string, 0, len(s.fixtures))
+	for key := range s.fixtures {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fixtures := make([]Fixture, len(keys))
+	for i := range keys {
+		fixtures[i] = s.fixtures[keys[i]]
+	}
+	return fixtures
+}
+
+func (r Request) key() string {
+	var builder bytes.Buffer
+	builder.WriteString(r.Method)
+	builder.WriteByte(' ')
+	builder.WriteString(r.Path)
+	if r.Query != "" {
+		builder.WriteByte('?')
+		builder.WriteString(r.Query)
+	}
+	if len(r.Body) > 0 {
+		builder.WriteByte(' ')
+		builder.Write(r.Body)
+	} else if r.Text != "" {
+		builder.WriteByte(' ')
+		builder.WriteString(r.Text)
+	}
+	return builder.String()
+}
+
+// newRequest - returns request for matching. Query parameters are sorted.
+func newRequest(r *http.Request, body []byte) Request {
+	request := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  sortedQuery(r.URL.RawQuery),
+	}
+	if isJSON(body) {
+		request.Body = json.RawMessage(body)
+	} else {
+		request.Text = string(body)
+	}
+	return request
+}
+
+// newResponse - returns response for recording
+func newResponse(status int, body []byte) Response {
+	response := Response{
+		Status: status,
+	}
+	if isJSON(body) {
+		response.Body = json.RawMessage(bytes.TrimSpace(body))
+	} else {
+		response.Text = string(body)
+	}
+	return response
+}
+
+func isJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && json.Valid(data)
+}
+
+func sortedQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// compactJSON - returns JSON without insignificant whitespace keeping order of object keys
+func compactJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+// normalizeJSON - returns compact JSON with sorted object keys
+func normalizeJSON(data json.RawMessage) json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return data
+	}
+	return raw
+}
@@ -0,0 +1,177 @@
+package httpfixture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Get(t *testing.T) {
+	store := NewStore()
+	store.Add(Fixture{
+		Request: Request{
+			Method: http.MethodPost,
+			Path:   "/v1/graphql",
+			Query:  "a=1&b=2",
+			Body:   []byte(`{"query": "q", "variables": {"offset": 0, "limit": 100}}`),
+		},
+		Response: Response{Status: http.StatusOK, Body: []byte(`{"data":{}}`)},
+	})
+
+	tests := []struct {
+		name    string
+		request Request
+		want    bool
+	}{
+		{
+			name: "same request",
+			request: Request{
+				Method: http.MethodPost,
+				Path:   "/v1/graphql",
+				Query:  "a=1&b=2",
+				Body:   []byte(`{"query": "q", "variables": {"offset": 0, "limit": 100}}`),
+			},
+			want: true,
+		}, {
+			name: "reordered body keys",
+			request: Request{
+				Method: http.MethodPost,
+				Path:   "/v1/graphql",
+				Query:  "a=1&b=2",
+				Body:   []byte(`{"variables":{"limit":100,"offset":0},"query":"q"}`),
+			},
+			want: true,
+		}, {
+			name: "other body",
+			request: Request{
+				Method: http.MethodPost,
+				Path:   "/v1/graphql",
+				Query:  "a=1&b=2",
+				Body:   []byte(`{"variables":{"limit":100,"offset":100},"query":"q"}`),
+			},
+		}, {
+			name: "other method",
+			request: Request{
+				Method: http.MethodGet,
+				Path:   "/v1/graphql",
+				Query:  "a=1&b=2",
+				Body:   []byte(`{"variables":{"limit":100,"offset":0},"query":"q"}`),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, ok := store.Get(tt.request)
+			require.Equal(t, tt.want, ok)
+			if tt.want {
+				require.Equal(t, http.StatusOK, response.Status)
+			}
+		})
+	}
+}
+
+func TestStore_SaveLoad(t *testing.T) {
+	store := NewStore()
+	store.Add(Fixture{
+		Request:  Request{Method: http.MethodPost, Path: "/b"},
+		Response: Response{Status: http.StatusOK, Text: "plain"},
+	})
+	store.Add(Fixture{
+		Request:  Request{Method: http.MethodPost, Path: "/a", Query: "domain=x.stark"},
+		Response: Response{Status: http.StatusOK, Body: []byte(`{"addr":"1"}`)},
+	})
+
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	require.NoError(t, store.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, store.List(), loaded.List())
+	require.Equal(t, "/a", loaded.List()[0].Request.Path)
+}
+
+func TestRecorder(t *testing.T) {
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Query().Get("domain") == "unknown.stark" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"no address found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer live.Close()
+
+	store := NewStore()
+	client := &http.Client{Transport: NewRecorder(nil, store)}
+
+	requests := []struct {
+		query  string
+		body   string
+		status int
+		want   string
+	}{
+		{query: "domain=a.stark", body: `{"x":1}`, status: http.StatusOK, want: `{"echo":{"x":1}}`},
+		{query: "domain=unknown.stark", body: `{"x":2}`, status: http.StatusBadRequest, want: `{"error":"no address found"}`},
+	}
+	for _, r := range requests {
+		status, body := post(t, client, live.URL+"/api?"+r.query, r.body)
+		require.Equal(t, r.status, status)
+		require.JSONEq(t, r.want, body)
+	}
+	require.Equal(t, 2, store.Len())
+	live.Close()
+
+	server := NewServer(store)
+	defer server.Close()
+
+	replays := []struct {
+		name    string
+		client  *http.Client
+		baseURL string
+	}{
+		{name: "replayer", client: &http.Client{Transport: NewReplayer(store)}, baseURL: "http://127.0.0.1:1"},
+		{name: "server", client: http.DefaultClient, baseURL: server.URL},
+	}
+	for _, replay := range replays {
+		t.Run(replay.name, func(t *testing.T) {
+			for _, r := range requests {
+				status, body := post(t, replay.client, replay.baseURL+"/api?"+r.query, r.body)
+				require.Equal(t, r.status, status)
+				require.JSONEq(t, r.want, body)
+			}
+		})
+	}
+}
+
+func post(t *testing.T, client *http.Client, url, body string) (int, string) {
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestReplayer_NotFound(t *testing.T) {
+	client := &http.Client{Transport: NewReplayer(NewStore())}
+	_, err := client.Post("http://127.0.0.1:1/api", "application/json", bytes.NewReader([]byte(`{}`)))
+	require.ErrorContains(t, err, "fixture is not found")
+}
+
+func TestServer_NotFound(t *testing.T) {
+	server := NewServer(NewStore())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api", "application/json", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
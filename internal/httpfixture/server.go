@@ -0,0 +1,40 @@
+package httpfixture
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+)
+
+// Handler - serves recorded responses. Request which was not recorded gets 404.
+func Handler(store *Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readRequestBody(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request := newRequest(r, body)
+
+		response, ok := store.Get(request)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error": "fixture is not found: " + request.key(),
+			})
+			return
+		}
+
+		for key, values := range responseHeader(response) {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(response.Status)
+		_, _ = w.Write(response.Bytes())
+	})
+}
+
+// NewServer - starts stand-in server of recorded API. Server must be closed by caller.
+func NewServer(store *Store) *httptest.Server {
+	return httptest.NewServer(Handler(store))
+}
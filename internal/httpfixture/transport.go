@@ -0,0 +1,120 @@
+package httpfixture
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Recorder - round tripper which sends requests with the base transport and records responses to the store
+type Recorder struct {
+	base  http.RoundTripper
+	store *Store
+}
+
+// NewRecorder - creates recorder. If base is nil, http.DefaultTransport is used.
+func NewRecorder(base http.RoundTripper, store *Store) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{
+		base:  base,
+		store: store,
+	}
+}
+
+// RoundTrip -
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "read response body")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.store.Add(Fixture{
+		Request:  newRequest(req, body),
+		Response: newResponse(resp.StatusCode, respBody),
+	})
+	return resp, nil
+}
+
+// Store - returns store of recorded fixtures
+func (r *Recorder) Store() *Store {
+	return r.store
+}
+
+// Replayer - round tripper which serves responses from the store without network access
+type Replayer struct {
+	store *Store
+}
+
+// NewReplayer -
+func NewReplayer(store *Store) *Replayer {
+	return &Replayer{
+		store: store,
+	}
+}
+
+// RoundTrip - returns recorded response. Request which was not recorded is an error.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	request := newRequest(req, body)
+
+	response, ok := r.store.Get(request)
+	if !ok {
+		return nil, errors.Errorf("fixture is not found: %s", request.key())
+	}
+
+	data := response.Bytes()
+	return &http.Response{
+		Status:        http.StatusText(response.Status),
+		StatusCode:    response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        responseHeader(response),
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody - reads request body and restores it, so the request can be sent after reading
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "read request body")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func responseHeader(response Response) http.Header {
+	header := make(http.Header)
+	if len(response.Body) > 0 {
+		header.Set("Content-Type", "application/json")
+	} else {
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	return header
+}
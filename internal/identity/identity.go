@@ -0,0 +1,277 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// errors
+var (
+	ErrNotFound     = errors.New("identity is not found")
+	ErrUnknownField = errors.New("unknown field")
+	ErrInvalidValue = errors.New("invalid field value")
+)
+
+// Profile - aggregated information about starknet id
+type Profile struct {
+	StarknetId string   `json:"starknet_id"`
+	Owner      string   `json:"owner"`
+	MainDomain *Domain  `json:"main_domain,omitempty"`
+	Domains    []Domain `json:"domains"`
+	Subdomains []Domain `json:"subdomains"`
+	Verifier   []Field  `json:"verifier_data"`
+	User       []Field  `json:"user_data"`
+	Inft       *Inft    `json:"inft,omitempty"`
+}
+
+// Domain -
+type Domain struct {
+	Domain  string    `json:"domain"`
+	Address string    `json:"address,omitempty"`
+	Expiry  time.Time `json:"expiry"`
+}
+
+// Field -
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Raw   string `json:"raw"`
+}
+
+// Inft - equipped iNFT
+type Inft struct {
+	Contract string `json:"contract"`
+	TokenId  string `json:"token_id"`
+}
+
+// Service - builds profiles of starknet ids
+type Service struct {
+	starknetIds storage.IStarknetId
+	domains     storage.IDomain
+	fields      storage.IField
+}
+
+// NewService -
+func NewService(starknetIds storage.IStarknetId, domains storage.IDomain, fields storage.IField) Service {
+	return Service{
+		starknetIds: starknetIds,
+		domains:     domains,
+		fields:      fields,
+	}
+}
+
+// ByStarknetId - returns profile of starknet id
+func (s Service) ByStarknetId(ctx context.Context, starknetId decimal.Decimal) (Profile, error) {
+	id, err := s.starknetIds.GetByStarknetId(ctx, starknetId)
+	if err != nil {
+		if s.starknetIds.IsNoRows(err) {
+			return Profile{}, ErrNotFound
+		}
+		return Profile{}, errors.Wrap(err, "get starknet id")
+	}
+	return s.build(ctx, id)
+}
+
+// ByAddress - returns profile of starknet id which is linked with address. Starknet id of domain resolving to the address is preferred, otherwise the first owned starknet id is used.
+func (s Service) ByAddress(ctx context.Context, address []byte) (Profile, error) {
+	domains, err := s.domains.ListByAddress(ctx, address)
+	if err != nil {
+		return Profile{}, errors.Wrap(err, "list domains by address")
+	}
+	for i := range domains {
+		if domains[i].Owner.IsZero() {
+			continue
+		}
+		profile, err := s.ByStarknetId(ctx, domains[i].Owner)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return profile, err
+	}
+
+	ids, err := s.starknetIds.ListByOwner(ctx, address)
+	if err != nil {
+		return Profile{}, errors.Wrap(err, "list starknet ids by owner")
+	}
+	if len(ids) == 0 {
+		return Profile{}, ErrNotFound
+	}
+	return s.build(ctx, ids[0])
+}
+
+// StarknetIdsByField - returns starknet ids which have verified well-known field with the value. Value is normalized by field decoder before lookup.
+func (s Service) StarknetIdsByField(ctx context.Context, name, value string) ([]string, error) {
+	decoder, ok := starknetid.GetFieldDecoder(name)
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownField, "%s", name)
+	}
+	normalized, err := decoder.Normalize(value)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidValue, err.Error())
+	}
+
+	ids, err := s.fields.ListStarknetIdsByValue(ctx, name, normalized)
+	if err != nil {
+		return nil, errors.Wrap(err, "list starknet ids by field value")
+	}
+	result := make([]string, len(ids))
+	for i := range ids {
+		result[i] = ids[i].String()
+	}
+	return result, nil
+}
+
+func (s Service) build(ctx context.Context, id storage.StarknetId) (Profile, error) {
+	profile := Profile{
+		StarknetId: id.StarknetId.String(),
+		Domains:    make([]Domain, 0),
+		Subdomains: make([]Domain, 0),
+		Verifier:   make([]Field, 0),
+		User:       make([]Field, 0),
+	}
+	if len(id.OwnerAddress) > 0 {
+		profile.Owner = encoding.EncodeHex(id.OwnerAddress)
+	}
+	if len(id.InftContract) > 0 {
+		profile.Inft = &Inft{
+			Contract: encoding.EncodeHex(id.InftContract),
+			TokenId:  id.InftId.Decimal.String(),
+		}
+	}
+
+	domains, err := s.domains.ListByOwner(ctx, id.StarknetId)
+	if err != nil {
+		return profile, errors.Wrap(err, "list domains")
+	}
+	domains = linkedDomains(domains)
+	sort.Slice(domains, func(i, j int) bool {
+		return domains[i].Domain < domains[j].Domain
+	})
+
+	for i := range domains {
+		domain := newDomain(domains[i])
+		if isSubdomain(domains[i].Domain) {
+			profile.Subdomains = append(profile.Subdomains, domain)
+			continue
+		}
+		profile.Domains = append(profile.Domains, domain)
+
+		subdomains, err := s.domains.ListSubdomains(ctx, domains[i].Domain)
+		if err != nil {
+			return profile, errors.Wrap(err, "list subdomains")
+		}
+		subdomains = linkedDomains(subdomains)
+		for j := range subdomains {
+			profile.Subdomains = append(profile.Subdomains, newDomain(subdomains[j]))
+		}
+	}
+	profile.Subdomains = uniqueDomains(profile.Subdomains)
+	profile.MainDomain = mainDomain(domains, id.OwnerAddress)
+
+	fields, err := s.fields.ListByOwner(ctx, id.StarknetId)
+	if err != nil {
+		return profile, errors.Wrap(err, "list fields")
+	}
+	for i := range fields {
+		field := Field{
+			Name:  fields[i].Name,
+			Value: fields[i].TextValue,
+			Raw:   encoding.EncodeHex(fields[i].Value),
+		}
+		if field.Value == "" {
+			field.Value = DecodeValue(fields[i].Value)
+		}
+		switch fields[i].Namespace {
+		case storage.FieldNamespaceVerifier:
+			profile.Verifier = append(profile.Verifier, field)
+		case storage.FieldNamespaceUser:
+			profile.User = append(profile.User, field)
+		}
+	}
+
+	return profile, nil
+}
+
+// mainDomain - root domain which resolves to the owner of starknet id. If there is no such domain the first root domain is returned.
+func mainDomain(domains []storage.Domain, owner []byte) *Domain {
+	var fallback *Domain
+	for i := range domains {
+		if isSubdomain(domains[i].Domain) {
+			continue
+		}
+		if len(owner) > 0 && bytes.Equal(domains[i].AddressHash, owner) {
+			domain := newDomain(domains[i])
+			return &domain
+		}
+		if fallback == nil {
+			domain := newDomain(domains[i])
+			fallback = &domain
+		}
+	}
+	return fallback
+}
+
+// DecodeValue - returns field value as a string if it's a printable short string, otherwise as a hex
+func DecodeValue(value []byte) string {
+	trimmed := bytes.TrimLeft(value, "\x00")
+	if len(trimmed) == 0 {
+		return "0x0"
+	}
+	for _, b := range trimmed {
+		if b < 0x20 || b > 0x7e {
+			return encoding.EncodeHex(value)
+		}
+	}
+	return string(trimmed)
+}
+
+func newDomain(domain storage.Domain) Domain {
+	d := Domain{
+		Domain: domain.Domain,
+		Expiry: domain.EffectiveExpiry.UTC(),
+	}
+	if len(domain.AddressHash) > 0 {
+		d.Address = encoding.EncodeHex(domain.AddressHash)
+	}
+	return d
+}
+
+// linkedDomains - filters out domains without address: they were cleared by zero address and don't resolve
+func linkedDomains(domains []storage.Domain) []storage.Domain {
+	result := domains[:0]
+	for i := range domains {
+		if len(domains[i].AddressHash) > 0 {
+			result = append(result, domains[i])
+		}
+	}
+	return result
+}
+
+func isSubdomain(domain string) bool {
+	return strings.Count(domain, ".") > 1
+}
+
+func uniqueDomains(domains []Domain) []Domain {
+	seen := make(map[string]struct{}, len(domains))
+	result := domains[:0]
+	for i := range domains {
+		if _, ok := seen[domains[i].Domain]; ok {
+			continue
+		}
+		seen[domains[i].Domain] = struct{}{}
+		result = append(result, domains[i])
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Domain < result[j].Domain
+	})
+	return result
+}
@@ -0,0 +1,292 @@
+package identity
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	ownerAddress = encoding.MustDecodeHex("0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8")
+	otherAddress = encoding.MustDecodeHex("0x05dbdedc203e92749e2e746e2d40a768d966bd243df04a6b712e222bc040a9af")
+	expiry       = time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type testStarknetIds struct {
+	storage.IStarknetId
+
+	ids []storage.StarknetId
+}
+
+func (ts testStarknetIds) GetByStarknetId(ctx context.Context, starknetId decimal.Decimal) (storage.StarknetId, error) {
+	for i := range ts.ids {
+		if ts.ids[i].StarknetId.Equal(starknetId) {
+			return ts.ids[i], nil
+		}
+	}
+	return storage.StarknetId{}, sql.ErrNoRows
+}
+
+func (ts testStarknetIds) ListByOwner(ctx context.Context, address []byte) ([]storage.StarknetId, error) {
+	var result []storage.StarknetId
+	for i := range ts.ids {
+		if string(ts.ids[i].OwnerAddress) == string(address) {
+			result = append(result, ts.ids[i])
+		}
+	}
+	return result, nil
+}
+
+func (ts testStarknetIds) IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+type testDomains struct {
+	storage.IDomain
+
+	domains []storage.Domain
+}
+
+func (td testDomains) ListByOwner(ctx context.Context, owner decimal.Decimal) ([]storage.Domain, error) {
+	var result []storage.Domain
+	for i := range td.domains {
+		if td.domains[i].Owner.Equal(owner) {
+			result = append(result, td.domains[i])
+		}
+	}
+	return result, nil
+}
+
+func (td testDomains) ListByAddress(ctx context.Context, address []byte) ([]storage.Domain, error) {
+	var result []storage.Domain
+	for i := range td.domains {
+		if string(td.domains[i].AddressHash) == string(address) {
+			result = append(result, td.domains[i])
+		}
+	}
+	return result, nil
+}
+
+func (td testDomains) ListSubdomains(ctx context.Context, parent string) ([]storage.Domain, error) {
+	var result []storage.Domain
+	for i := range td.domains {
+		if strings.HasSuffix(td.domains[i].Domain, "."+parent) {
+			result = append(result, td.domains[i])
+		}
+	}
+	return result, nil
+}
+
+type testFields struct {
+	storage.IField
+
+	fields []storage.Field
+}
+
+func (tf testFields) ListByOwner(ctx context.Context, owner decimal.Decimal) ([]storage.Field, error) {
+	var result []storage.Field
+	for i := range tf.fields {
+		if tf.fields[i].OwnerId.Equal(owner) {
+			result = append(result, tf.fields[i])
+		}
+	}
+	return result, nil
+}
+
+func (tf testFields) ListStarknetIdsByValue(ctx context.Context, name, value string) ([]decimal.Decimal, error) {
+	var result []decimal.Decimal
+	for i := range tf.fields {
+		if tf.fields[i].Name == name && tf.fields[i].TextValue == value && tf.fields[i].Namespace == storage.FieldNamespaceVerifier {
+			result = append(result, tf.fields[i].OwnerId)
+		}
+	}
+	return result, nil
+}
+
+func newTestService() Service {
+	return NewService(
+		testStarknetIds{
+			ids: []storage.StarknetId{
+				{
+					StarknetId:   decimal.NewFromInt(1),
+					OwnerAddress: ownerAddress,
+					InftContract: []byte{0x01, 0x02},
+					InftId:       decimal.NewNullDecimal(decimal.NewFromInt(7)),
+				}, {
+					StarknetId:   decimal.NewFromInt(2),
+					OwnerAddress: otherAddress,
+				},
+			},
+		},
+		testDomains{
+			domains: []storage.Domain{
+				{Domain: "alpha.stark", Owner: decimal.NewFromInt(1), AddressHash: otherAddress, Expiry: expiry, EffectiveExpiry: expiry},
+				{Domain: "fricoben.stark", Owner: decimal.NewFromInt(1), AddressHash: ownerAddress, Expiry: expiry, EffectiveExpiry: expiry},
+				{Domain: "deployer.fricoben.stark", AddressHash: otherAddress, Expiry: expiry, EffectiveExpiry: expiry},
+				{Domain: "own.other.stark", Owner: decimal.NewFromInt(1), AddressHash: ownerAddress, Expiry: expiry, EffectiveExpiry: expiry},
+				{Domain: "cleared.stark", Owner: decimal.NewFromInt(1), Expiry: expiry, EffectiveExpiry: expiry},
+				{Domain: "cleared.fricoben.stark", Expiry: expiry, EffectiveExpiry: expiry},
+			},
+		},
+		testFields{
+			fields: []storage.Field{
+				{OwnerId: decimal.NewFromInt(1), Namespace: storage.FieldNamespaceVerifier, Name: "nickname", Value: encoding.MustDecodeHex("0x000000000000000000000000000000000000000000000000000000006669636f")},
+				{OwnerId: decimal.NewFromInt(1), Namespace: storage.FieldNamespaceVerifier, Name: "github", Value: []byte{0x04, 0xd2}, TextValue: "1234"},
+				{OwnerId: decimal.NewFromInt(1), Namespace: storage.FieldNamespaceUser, Name: "avatar", Value: []byte{0x00, 0x9f, 0x01}},
+			},
+		},
+	)
+}
+
+func TestService_ByStarknetId(t *testing.T) {
+	service := newTestService()
+
+	profile, err := service.ByStarknetId(context.Background(), decimal.NewFromInt(1))
+	require.NoError(t, err)
+
+	require.Equal(t, "1", profile.StarknetId)
+	require.Equal(t, encoding.EncodeHex(ownerAddress), profile.Owner)
+	require.NotNil(t, profile.MainDomain)
+	require.Equal(t, "fricoben.stark", profile.MainDomain.Domain)
+
+	require.Len(t, profile.Domains, 2)
+	require.Equal(t, "alpha.stark", profile.Domains[0].Domain)
+	require.Equal(t, "fricoben.stark", profile.Domains[1].Domain)
+
+	require.Len(t, profile.Subdomains, 2)
+	require.Equal(t, "deployer.fricoben.stark", profile.Subdomains[0].Domain)
+	require.Equal(t, "own.other.stark", profile.Subdomains[1].Domain)
+
+	require.Len(t, profile.Verifier, 2)
+	require.Equal(t, "nickname", profile.Verifier[0].Name)
+	require.Equal(t, "fico", profile.Verifier[0].Value)
+	require.Equal(t, "github", profile.Verifier[1].Name)
+	require.Equal(t, "1234", profile.Verifier[1].Value)
+	require.Equal(t, "0x04d2", profile.Verifier[1].Raw)
+	require.Len(t, profile.User, 1)
+	require.Equal(t, "0x009f01", profile.User[0].Value)
+
+	require.NotNil(t, profile.Inft)
+	require.Equal(t, "0x0102", profile.Inft.Contract)
+	require.Equal(t, "7", profile.Inft.TokenId)
+}
+
+func TestService_ByStarknetId_NotFound(t *testing.T) {
+	_, err := newTestService().ByStarknetId(context.Background(), decimal.NewFromInt(100))
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestService_ByAddress(t *testing.T) {
+	service := newTestService()
+
+	tests := []struct {
+		name    string
+		address []byte
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "resolved by domain",
+			address: ownerAddress,
+			want:    "1",
+		}, {
+			name:    "domain resolves to address",
+			address: otherAddress,
+			want:    "1",
+		}, {
+			name:    "unknown address",
+			address: []byte{0x01},
+			wantErr: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := service.ByAddress(context.Background(), tt.address)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, profile.StarknetId)
+		})
+	}
+}
+
+func TestService_ByAddress_FallbackToOwner(t *testing.T) {
+	service := NewService(
+		testStarknetIds{
+			ids: []storage.StarknetId{
+				{StarknetId: decimal.NewFromInt(2), OwnerAddress: otherAddress},
+			},
+		},
+		testDomains{},
+		testFields{},
+	)
+
+	profile, err := service.ByAddress(context.Background(), otherAddress)
+	require.NoError(t, err)
+	require.Equal(t, "2", profile.StarknetId)
+	require.Nil(t, profile.MainDomain)
+	require.Nil(t, profile.Inft)
+	require.Empty(t, profile.Domains)
+}
+
+func TestService_StarknetIdsByField(t *testing.T) {
+	service := newTestService()
+
+	ids, err := service.StarknetIdsByField(context.Background(), "github", "0x4d2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1"}, ids)
+
+	ids, err = service.StarknetIdsByField(context.Background(), "github", "1")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	_, err = service.StarknetIdsByField(context.Background(), "nickname", "fico")
+	require.ErrorIs(t, err, ErrUnknownField)
+
+	_, err = service.StarknetIdsByField(context.Background(), "github", "octocat")
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  string
+	}{
+		{
+			name:  "short string",
+			value: []byte("github"),
+			want:  "github",
+		}, {
+			name:  "padded short string",
+			value: []byte{0x00, 0x00, 'a', 'b'},
+			want:  "ab",
+		}, {
+			name:  "number",
+			value: []byte{0x01, 0xc8},
+			want:  "0x01c8",
+		}, {
+			name:  "zero",
+			value: []byte{0x00},
+			want:  "0x0",
+		}, {
+			name: "empty",
+			want: "0x0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, DecodeValue(tt.value))
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/abi"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// Api - client of Starknet JSON-RPC node. Only methods which are required to index events are implemented.
+// starknet-go-api client isn't used because its `starknet_getEvents` request has empty method name
+// and its event doesn't contain block number.
+type Api struct {
+	client    *http.Client
+	url       string
+	id        *atomic.Uint64
+	rateLimit *rate.Limiter
+}
+
+// NewApi -
+func NewApi(cfg Config) Api {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 10
+	t.MaxConnsPerHost = 10
+	t.MaxIdleConnsPerHost = 10
+
+	api := Api{
+		client: &http.Client{
+			Transport: t,
+		},
+		url: cfg.URL,
+		id:  new(atomic.Uint64),
+	}
+	if cfg.RequestsPerSecond > 0 {
+		api.rateLimit = rate.NewLimiter(rate.Every(time.Second/time.Duration(cfg.RequestsPerSecond)), cfg.RequestsPerSecond)
+	}
+	return api
+}
+
+type request struct {
+	Version string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+	ID      uint64 `json:"id"`
+}
+
+type response struct {
+	Result json.RawMessage `json:"result"`
+	ID     uint64          `json:"id"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error - error object of JSON-RPC response
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error -
+func (e Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// BlockID - block identifier of request parameters: number or tag (`latest`)
+type BlockID struct {
+	Number uint64
+	Tag    string
+}
+
+// Latest - the last accepted block
+var Latest = BlockID{Tag: "latest"}
+
+// MarshalJSON -
+func (id BlockID) MarshalJSON() ([]byte, error) {
+	if id.Tag != "" {
+		return json.Marshal(id.Tag)
+	}
+	return json.Marshal(map[string]uint64{"block_number": id.Number})
+}
+
+// EventsFilter - parameters of `starknet_getEvents`
+type EventsFilter struct {
+	FromBlock         BlockID    `json:"from_block"`
+	ToBlock           BlockID    `json:"to_block"`
+	Address           string     `json:"address,omitempty"`
+	Keys              [][]string `json:"keys,omitempty"`
+	ChunkSize         uint64     `json:"chunk_size"`
+	ContinuationToken string     `json:"continuation_token,omitempty"`
+}
+
+// EmittedEvent -
+type EmittedEvent struct {
+	FromAddress     string   `json:"from_address"`
+	Keys            []string `json:"keys"`
+	Data            []string `json:"data"`
+	BlockHash       string   `json:"block_hash"`
+	BlockNumber     uint64   `json:"block_number"`
+	TransactionHash string   `json:"transaction_hash"`
+}
+
+// EventsChunk - page of `starknet_getEvents` response
+type EventsChunk struct {
+	Events            []EmittedEvent `json:"events"`
+	ContinuationToken string         `json:"continuation_token"`
+}
+
+// Block - result of `starknet_getBlockWithTxHashes`
+type Block struct {
+	BlockHash    string   `json:"block_hash"`
+	BlockNumber  uint64   `json:"block_number"`
+	Timestamp    uint64   `json:"timestamp"`
+	Transactions []string `json:"transactions"`
+}
+
+// BlockNumber - returns number of the last accepted block
+func (api Api) BlockNumber(ctx context.Context) (uint64, error) {
+	var number uint64
+	err := api.post(ctx, "starknet_blockNumber", []any{}, &number)
+	return number, err
+}
+
+// GetBlock - returns block header with hashes of its transactions
+func (api Api) GetBlock(ctx context.Context, number uint64) (Block, error) {
+	var block Block
+	err := api.post(ctx, "starknet_getBlockWithTxHashes", []any{BlockID{Number: number}}, &block)
+	return block, err
+}
+
+// GetEvents - returns chunk of events matching the filter
+func (api Api) GetEvents(ctx context.Context, filter EventsFilter) (EventsChunk, error) {
+	var chunk EventsChunk
+	err := api.post(ctx, "starknet_getEvents", []any{filter}, &chunk)
+	return chunk, err
+}
+
+// GetAbi - returns ABI of the class deployed at the address. Cairo 1 classes return ABI as JSON string.
+func (api Api) GetAbi(ctx context.Context, address string) (abi.Abi, error) {
+	var class struct {
+		Abi json.RawMessage `json:"abi"`
+	}
+	if err := api.post(ctx, "starknet_getClassAt", []any{Latest, address}, &class); err != nil {
+		return abi.Abi{}, err
+	}
+
+	raw := []byte(class.Abi)
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return abi.Abi{}, errors.Wrap(err, "abi string")
+		}
+		raw = []byte(s)
+	}
+
+	var result abi.Abi
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return abi.Abi{}, errors.Wrap(err, "abi")
+	}
+	return result, nil
+}
+
+func (api Api) post(ctx context.Context, method string, params []any, output any) error {
+	req := request{
+		Version: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      api.id.Add(1),
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, api.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Add("Content-Type", "application/json")
+
+	if api.rateLimit != nil {
+		if err := api.rateLimit.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	httpResponse, err := api.client.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return errors.Errorf("%s: invalid status code: %d", method, httpResponse.StatusCode)
+	}
+
+	var resp response
+	if err := json.NewDecoder(httpResponse.Body).Decode(&resp); err != nil {
+		return errors.Wrap(err, method)
+	}
+	if resp.Error != nil {
+		return errors.Wrap(resp.Error, method)
+	}
+	if err := json.Unmarshal(resp.Result, output); err != nil {
+		return errors.Wrap(err, method)
+	}
+	return nil
+}
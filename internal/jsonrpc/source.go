@@ -0,0 +1,518 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/abi"
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	generalPB "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc/pb"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+// module names
+const (
+	ModuleName     = "starknet_jsonrpc_source"
+	OutputMessages = grpc.OutputMessages
+)
+
+// defaults
+const (
+	defaultPollInterval     = 5
+	defaultBlocksPerRequest = 1000
+	defaultChunkSize        = 1000
+)
+
+// Config - Starknet JSON-RPC node which is polled for events instead of starknet-indexer
+type Config struct {
+	URL               string `validate:"required,url"    yaml:"url"`
+	PollInterval      uint64 `validate:"omitempty,min=1" yaml:"poll_interval"`
+	BlocksPerRequest  uint64 `validate:"omitempty,min=1" yaml:"blocks_per_request"`
+	ChunkSize         uint64 `validate:"omitempty,min=1" yaml:"chunk_size"`
+	RequestsPerSecond int    `validate:"omitempty,min=1" yaml:"requests_per_second"`
+}
+
+// Source - polls Starknet JSON-RPC node with `starknet_getEvents` and sends the same subscription messages as
+// starknet-indexer gRPC client: Block, Address, Event and EndOfBlock. Events are decoded by ABI of the emitting
+// contract or by ABI of Starknet ID events if the contract doesn't declare them (e.g. proxy).
+//
+// The node doesn't number addresses, so the source assigns ids to addresses found in events which aren't stored yet
+// starting from id of the subscription address filter. Only blocks with events and the last block of each polled
+// range are sent.
+type Source struct {
+	modules.BaseModule
+
+	api       Api
+	cfg       Config
+	addresses storage.IAddress
+	fallback  abi.Abi
+
+	subscriptions map[uint64]*subscription
+	lastId        uint64
+	lastEventId   uint64
+	abis          map[string]abi.Abi
+	known         map[string]uint64
+	lastAddressId uint64
+	reconnect     chan uint64
+	mx            *sync.Mutex
+}
+
+// NewSource -
+func NewSource(cfg Config, addresses storage.IAddress) (*Source, error) {
+	fallback, err := starknetid.Abi()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.BlocksPerRequest == 0 {
+		cfg.BlocksPerRequest = defaultBlocksPerRequest
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = defaultChunkSize
+	}
+
+	source := &Source{
+		BaseModule:    modules.New(ModuleName),
+		api:           NewApi(cfg),
+		cfg:           cfg,
+		addresses:     addresses,
+		fallback:      fallback,
+		subscriptions: make(map[uint64]*subscription),
+		abis:          make(map[string]abi.Abi),
+		known:         make(map[string]uint64),
+		reconnect:     make(chan uint64),
+		mx:            new(sync.Mutex),
+	}
+	source.CreateOutput(OutputMessages)
+	return source, nil
+}
+
+// Connect - waits until the node responds
+func (s *Source) Connect(ctx context.Context) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		_, err := s.api.BlockNumber(ctx)
+		if err == nil {
+			return nil
+		}
+		s.Log.Warn().Err(err).Str("url", s.cfg.URL).Msg("waiting for node")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Start -
+func (s *Source) Start(ctx context.Context) {}
+
+// Subscribe - starts polling of events matching the request. Only head, event and address filters are supported.
+func (s *Source) Subscribe(ctx context.Context, req *pb.SubscribeRequest) (uint64, error) {
+	sub, err := newSubscription(req)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mx.Lock()
+	s.lastId++
+	sub.id = s.lastId
+	if sub.addresses && sub.lastAddressId > s.lastAddressId {
+		s.lastAddressId = sub.lastAddressId
+	}
+	ctx, sub.cancel = context.WithCancel(ctx)
+	s.subscriptions[sub.id] = sub
+	s.mx.Unlock()
+
+	s.G.GoCtx(ctx, func(ctx context.Context) {
+		s.poll(ctx, sub)
+	})
+	return sub.id, nil
+}
+
+// Unsubscribe - stops polling of the subscription
+func (s *Source) Unsubscribe(ctx context.Context, id uint64) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		return errors.Errorf("unknown subscription: %d", id)
+	}
+	sub.cancel()
+	delete(s.subscriptions, id)
+	return nil
+}
+
+// Reconnect - polling doesn't hold connection, so subscriptions are never restored. The channel is closed on Close.
+func (s *Source) Reconnect() <-chan uint64 {
+	return s.reconnect
+}
+
+// Close - waits for polling threads
+func (s *Source) Close() error {
+	s.G.Wait()
+	close(s.reconnect)
+	return nil
+}
+
+func (s *Source) poll(ctx context.Context, sub *subscription) {
+	ticker := time.NewTicker(time.Duration(s.cfg.PollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sync(ctx, sub); err != nil && ctx.Err() == nil {
+			s.Log.Err(err).Uint64("subscription", sub.id).Msg("polling events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync - sends blocks from the next one of the subscription up to the head
+func (s *Source) sync(ctx context.Context, sub *subscription) error {
+	head, err := s.api.BlockNumber(ctx)
+	if err != nil {
+		return errors.Wrap(err, "head")
+	}
+
+	for sub.next <= head {
+		to := sub.next + s.cfg.BlocksPerRequest - 1
+		if to > head {
+			to = head
+		}
+		if err := s.syncRange(ctx, sub, sub.next, to); err != nil {
+			return errors.Wrapf(err, "blocks %d-%d", sub.next, to)
+		}
+	}
+	return nil
+}
+
+func (s *Source) syncRange(ctx context.Context, sub *subscription, from, to uint64) error {
+	blocks := map[uint64][]EmittedEvent{
+		to: nil,
+	}
+	for _, filter := range sub.filters {
+		events, err := s.getEvents(ctx, filter, from, to)
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			blocks[events[i].BlockNumber] = append(blocks[events[i].BlockNumber], events[i])
+		}
+	}
+
+	heights := make([]uint64, 0, len(blocks))
+	for height := range blocks {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	output := s.MustOutput(OutputMessages)
+	for _, height := range heights {
+		messages, err := s.blockMessages(ctx, sub, height, blocks[height])
+		if err != nil {
+			return errors.Wrapf(err, "block %d", height)
+		}
+		for i := range messages {
+			output.Push(messages[i])
+		}
+		sub.next = height + 1
+	}
+	return nil
+}
+
+func (s *Source) getEvents(ctx context.Context, filter eventFilter, from, to uint64) ([]EmittedEvent, error) {
+	request := EventsFilter{
+		FromBlock: BlockID{Number: from},
+		ToBlock:   BlockID{Number: to},
+		Address:   filter.contract,
+		ChunkSize: s.cfg.ChunkSize,
+	}
+	if len(filter.selectors) > 0 {
+		request.Keys = [][]string{filter.selectors}
+	}
+
+	var events []EmittedEvent
+	for {
+		chunk, err := s.api.GetEvents(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, chunk.Events...)
+		if chunk.ContinuationToken == "" {
+			return events, nil
+		}
+		request.ContinuationToken = chunk.ContinuationToken
+	}
+}
+
+// blockMessages - returns messages of the block. Nothing is changed if an error is returned, so the block can be retried.
+func (s *Source) blockMessages(ctx context.Context, sub *subscription, height uint64, events []EmittedEvent) ([]*pb.Subscription, error) {
+	response := &generalPB.SubscribeResponse{Id: sub.id}
+	endOfBlock := &pb.Subscription{
+		Response:   response,
+		EndOfBlock: &pb.EndOfBlock{Height: height},
+	}
+	if len(events) == 0 && !sub.head {
+		return []*pb.Subscription{endOfBlock}, nil
+	}
+
+	block, err := s.api.GetBlock(ctx, height)
+	if err != nil {
+		return nil, errors.Wrap(err, "get block")
+	}
+
+	txIndex := make(map[string]int, len(block.Transactions))
+	for i := range block.Transactions {
+		txIndex[encoding.TrimHex(strings.ToLower(block.Transactions[i]))] = i
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return txIndex[encoding.TrimHex(strings.ToLower(events[i].TransactionHash))] <
+			txIndex[encoding.TrimHex(strings.ToLower(events[j].TransactionHash))]
+	})
+
+	decoded := make([]*pb.Event, 0, len(events))
+	for i := range events {
+		event, err := s.decode(ctx, events[i])
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			continue
+		}
+		event.Height = height
+		event.Time = block.Timestamp
+		event.Order = uint64(len(decoded))
+		decoded = append(decoded, event)
+	}
+
+	addresses, err := s.resolveAddresses(ctx, height, decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*pb.Subscription, 0, len(decoded)+len(addresses)+2)
+	if sub.head {
+		hash, _ := feltBytes(block.BlockHash)
+		messages = append(messages, &pb.Subscription{
+			Response: response,
+			Block: &pb.Block{
+				Height:  height,
+				Time:    block.Timestamp,
+				Hash:    hash,
+				TxCount: uint64(len(block.Transactions)),
+			},
+		})
+	}
+	if sub.addresses {
+		for i := range addresses {
+			messages = append(messages, &pb.Subscription{
+				Response: response,
+				Address:  addresses[i],
+			})
+		}
+	}
+
+	s.mx.Lock()
+	for i := range decoded {
+		s.lastEventId++
+		decoded[i].Id = s.lastEventId
+		messages = append(messages, &pb.Subscription{
+			Response: response,
+			Event:    decoded[i],
+		})
+	}
+	s.mx.Unlock()
+
+	return append(messages, endOfBlock), nil
+}
+
+// decode - decodes event by ABI of the contract. Events which can't be decoded are skipped as events
+// of unknown handlers are skipped by channels.
+func (s *Source) decode(ctx context.Context, emitted EmittedEvent) (*pb.Event, error) {
+	if len(emitted.Keys) == 0 {
+		return nil, nil
+	}
+	contract, err := feltBytes(emitted.FromAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	contractAbi, err := s.abi(ctx, emitted.FromAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "abi of %s", emitted.FromAddress)
+	}
+
+	selector := encoding.TrimHex(strings.ToLower(emitted.Keys[0]))
+	item, ok := contractAbi.EventsBySelector[selector]
+	structs := contractAbi.Structs
+	if !ok {
+		item, ok = s.fallback.EventsBySelector[selector]
+		structs = s.fallback.Structs
+	}
+	if !ok {
+		s.Log.Warn().Str("contract", emitted.FromAddress).Str("selector", emitted.Keys[0]).Msg("unknown event")
+		return nil, nil
+	}
+
+	parsed, err := abi.DecodeEventData(emitted.Data, *item, structs)
+	if err != nil {
+		s.Log.Warn().Err(err).Str("contract", emitted.FromAddress).Str("event", item.Name).Msg("decoding event")
+		return nil, nil
+	}
+	parsedData, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsed data")
+	}
+
+	return &pb.Event{
+		Contract:   &pb.Address{Hash: contract},
+		Keys:       emitted.Keys,
+		Data:       emitted.Data,
+		Name:       item.Name,
+		ParsedData: parsedData,
+	}, nil
+}
+
+func (s *Source) abi(ctx context.Context, address string) (abi.Abi, error) {
+	key := encoding.TrimHex(strings.ToLower(address))
+
+	s.mx.Lock()
+	contractAbi, ok := s.abis[key]
+	s.mx.Unlock()
+	if ok {
+		return contractAbi, nil
+	}
+
+	contractAbi, err := s.api.GetAbi(ctx, address)
+	if err != nil {
+		return abi.Abi{}, err
+	}
+
+	s.mx.Lock()
+	s.abis[key] = contractAbi
+	s.mx.Unlock()
+	return contractAbi, nil
+}
+
+// resolveAddresses - sets ids of event contracts and returns addresses which are met first time.
+// New ids are assigned only if all addresses are resolved.
+func (s *Source) resolveAddresses(ctx context.Context, height uint64, events []*pb.Event) ([]*pb.Address, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var (
+		created = make(map[string]*pb.Address)
+		result  = make([]*pb.Address, 0)
+		lastId  = s.lastAddressId
+	)
+
+	resolve := func(hash []byte) (uint64, error) {
+		key := hex.EncodeToString(hash)
+		if id, ok := s.known[key]; ok {
+			return id, nil
+		}
+		if address, ok := created[key]; ok {
+			return address.Id, nil
+		}
+
+		stored, err := s.addresses.GetByHash(ctx, hash)
+		switch {
+		case err == nil:
+			s.known[key] = stored.Id
+			return stored.Id, nil
+		case s.addresses.IsNoRows(err):
+			lastId++
+			address := &pb.Address{
+				Id:     lastId,
+				Hash:   hash,
+				Height: height,
+			}
+			created[key] = address
+			result = append(result, address)
+			return address.Id, nil
+		default:
+			return 0, err
+		}
+	}
+
+	for _, event := range events {
+		id, err := resolve(event.Contract.Hash)
+		if err != nil {
+			return nil, errors.Wrap(err, "contract address")
+		}
+		event.Contract.Id = id
+
+		fields := starknetid.AddressFields(event.Name)
+		if len(fields) == 0 {
+			continue
+		}
+		var parsed map[string]any
+		if err := json.Unmarshal(event.ParsedData, &parsed); err != nil {
+			return nil, errors.Wrap(err, "parsed data")
+		}
+		for _, field := range fields {
+			value, ok := parsed[field].(string)
+			if !ok {
+				continue
+			}
+			hash, err := feltBytes(value)
+			if err != nil {
+				return nil, errors.Wrap(err, field)
+			}
+			if isZero(hash) {
+				continue
+			}
+			if _, err := resolve(hash); err != nil {
+				return nil, errors.Wrap(err, field)
+			}
+		}
+	}
+
+	for key, address := range created {
+		s.known[key] = address.Id
+	}
+	s.lastAddressId = lastId
+	return result, nil
+}
+
+func feltBytes(value string) ([]byte, error) {
+	s := strings.TrimPrefix(value, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return nil, errors.Wrapf(err, "invalid felt: %s", value)
+	}
+	return data.Felt(value).Bytes(), nil
+}
+
+func isZero(hash []byte) bool {
+	for i := range hash {
+		if hash[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+package jsonrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/dipdup-io/starknet-id/internal/fakenode"
+	starknetid "github.com/dipdup-io/starknet-id/internal/starknet-id"
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/memory"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/dipdup-net/indexer-sdk/pkg/modules"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	alice = "0x0327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8"
+
+	// ABI of a proxy which doesn't declare events of its implementation
+	proxyAbi = `[{"type":"function","name":"get_implementation","inputs":[],"outputs":[{"name":"implementation","type":"felt"}]}]`
+
+	transferAbi = `[
+		{"type":"struct","name":"Uint256","size":2,"members":[{"name":"low","offset":0,"type":"felt"},{"name":"high","offset":1,"type":"felt"}]},
+		{"type":"event","name":"Transfer","keys":[],"data":[{"name":"from_","type":"felt"},{"name":"to","type":"felt"},{"name":"tokenId","type":"Uint256"}]}
+	]`
+)
+
+func receive(t *testing.T, input *modules.Input) *pb.Subscription {
+	t.Helper()
+	select {
+	case msg := <-input.Listen():
+		sub, ok := msg.(*pb.Subscription)
+		require.True(t, ok)
+		return sub
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "message is not received")
+		return nil
+	}
+}
+
+func networkRequest(height, addressId uint64) *pb.SubscribeRequest {
+	sub := grpc.Subscription{
+		Head: true,
+		EventFilter: []*grpc.EventFilter{
+			{
+				Contract: &grpc.BytesFilter{Eq: starknetid.AddressStarknetId.Bytes()},
+				Name:     &grpc.StringFilter{In: starknetid.RoleStarknetId.Events()},
+				Height:   &grpc.IntegerFilter{Gt: height},
+			}, {
+				Contract: &grpc.BytesFilter{Eq: starknetid.AddressNaming.Bytes()},
+				Name:     &grpc.StringFilter{In: starknetid.RoleNaming.Events()},
+				Height:   &grpc.IntegerFilter{Gt: height},
+			},
+		},
+		AddressFilter: []*grpc.AddressFilter{
+			{OnlyStarknet: true, Id: &grpc.IntegerFilter{Gt: addressId}},
+		},
+	}
+	return sub.ToGrpcFilter()
+}
+
+func TestSource(t *testing.T) {
+	node := fakenode.New()
+	defer node.Close()
+
+	node.SetAbi(starknetid.AddressStarknetId.String(), []byte(transferAbi))
+	node.SetAbi(starknetid.AddressNaming.String(), []byte(proxyAbi))
+	node.AddBlocks(
+		fakenode.Block{
+			Number:       100,
+			Timestamp:    1700000000,
+			Transactions: []string{"0xa", "0xb"},
+			Events: []fakenode.Event{
+				{
+					Contract:    starknetid.AddressNaming.String(),
+					Name:        starknetid.EventStarknetIdUpdate,
+					Data:        []string{"0x1", "0x15d246f6c1b", "0x1", "0x64b3b2d0"},
+					Transaction: "0xb",
+				}, {
+					Contract:    starknetid.AddressStarknetId.String(),
+					Name:        starknetid.EventTransfer,
+					Data:        []string{"0x0", alice, "0x1", "0x0"},
+					Transaction: "0xa",
+				}, {
+					Contract:    starknetid.AddressStarknetId.String(),
+					Name:        "Approval",
+					Data:        []string{"0x0", alice, "0x1", "0x0"},
+					Transaction: "0xa",
+				}, {
+					Contract:    starknetid.AddressNaming.String(),
+					Name:        starknetid.EventDomainToAddrUpdate,
+					Data:        []string{"0x1", "0x15d246f6c1b", alice},
+					Transaction: "0xb",
+				},
+			},
+		},
+		fakenode.Block{Number: 102, Timestamp: 1700000200},
+	)
+
+	storage := memory.New()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := storage.BeginTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.SaveAddress(ctx, &models.Address{Id: 1, Hash: starknetid.AddressStarknetId.Bytes()}))
+	require.NoError(t, tx.Flush(ctx))
+
+	source, err := NewSource(Config{URL: node.URL(), ChunkSize: 1, PollInterval: 1}, storage.Addresses)
+	require.NoError(t, err)
+	input := modules.NewInput("test")
+	source.MustOutput(OutputMessages).Attach(input)
+
+	require.NoError(t, source.Connect(ctx))
+	source.Start(ctx)
+
+	id, err := source.Subscribe(ctx, networkRequest(99, 15))
+	require.NoError(t, err)
+
+	block := receive(t, input)
+	require.Equal(t, id, block.GetResponse().GetId())
+	require.EqualValues(t, 100, block.GetBlock().GetHeight())
+	require.EqualValues(t, 1700000000, block.GetBlock().GetTime())
+
+	address := receive(t, input).GetAddress()
+	require.EqualValues(t, 16, address.GetId())
+	require.Equal(t, data.Felt(alice).Bytes(), address.GetHash())
+	require.EqualValues(t, 100, address.GetHeight())
+
+	address = receive(t, input).GetAddress()
+	require.EqualValues(t, 17, address.GetId())
+	require.Equal(t, starknetid.AddressNaming.Bytes(), address.GetHash())
+
+	transfer := receive(t, input).GetEvent()
+	require.Equal(t, starknetid.EventTransfer, transfer.GetName())
+	require.EqualValues(t, 100, transfer.GetHeight())
+	require.EqualValues(t, 1700000000, transfer.GetTime())
+	require.EqualValues(t, 0, transfer.GetOrder())
+	require.EqualValues(t, 1, transfer.GetContract().GetId())
+	require.JSONEq(t, `{"from_":"0x0","to":"`+alice+`","tokenId":{"low":"0x1","high":"0x0"}}`, string(transfer.GetParsedData()))
+
+	update := receive(t, input).GetEvent()
+	require.Equal(t, starknetid.EventStarknetIdUpdate, update.GetName())
+	require.EqualValues(t, 1, update.GetOrder())
+	require.EqualValues(t, 17, update.GetContract().GetId())
+	require.Equal(t, starknetid.AddressNaming.Bytes(), update.GetContract().GetHash())
+	require.JSONEq(t, `{"domain_len":"0x1","domain":["0x15d246f6c1b"],"owner":"0x1","expiry":"0x64b3b2d0"}`, string(update.GetParsedData()))
+
+	domainToAddr := receive(t, input).GetEvent()
+	require.Equal(t, starknetid.EventDomainToAddrUpdate, domainToAddr.GetName())
+	require.JSONEq(t, `{"domain_len":"0x1","domain":["0x15d246f6c1b"],"address":"`+alice+`"}`, string(domainToAddr.GetParsedData()))
+
+	require.EqualValues(t, 100, receive(t, input).GetEndOfBlock().GetHeight())
+
+	// the last block of the range is sent without events, so the state is moved to the head
+	require.EqualValues(t, 102, receive(t, input).GetBlock().GetHeight())
+	require.EqualValues(t, 102, receive(t, input).GetEndOfBlock().GetHeight())
+
+	// new blocks are polled and addresses which are known already aren't sent again
+	node.AddBlocks(fakenode.Block{
+		Number:       103,
+		Timestamp:    1700000300,
+		Transactions: []string{"0xc"},
+		Events: []fakenode.Event{
+			{
+				Contract:    starknetid.AddressNaming.String(),
+				Name:        starknetid.EventAddrToDomainUpdate,
+				Data:        []string{alice, "0x1", "0x15d246f6c1b"},
+				Transaction: "0xc",
+			},
+		},
+	})
+	require.EqualValues(t, 103, receive(t, input).GetBlock().GetHeight())
+	addrToDomain := receive(t, input).GetEvent()
+	require.Equal(t, starknetid.EventAddrToDomainUpdate, addrToDomain.GetName())
+	require.EqualValues(t, 17, addrToDomain.GetContract().GetId())
+	require.EqualValues(t, 103, receive(t, input).GetEndOfBlock().GetHeight())
+
+	require.Equal(t, 2, node.Requests("starknet_getClassAt"))
+	require.Greater(t, node.Requests("starknet_getEvents"), 4, "events are requested by chunks")
+
+	require.NoError(t, source.Unsubscribe(ctx, id))
+	require.Error(t, source.Unsubscribe(ctx, id))
+
+	cancel()
+	require.NoError(t, source.Close())
+}
+
+func TestNewSubscription(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.SubscribeRequest
+		wantNext uint64
+		wantErr  bool
+	}{
+		{
+			name:     "network subscription",
+			req:      networkRequest(99, 15),
+			wantNext: 100,
+		}, {
+			name:     "empty database",
+			req:      networkRequest(0, 0),
+			wantNext: 0,
+		}, {
+			name: "contracts in one filter",
+			req: grpc.Subscription{
+				EventFilter: []*grpc.EventFilter{
+					{
+						Contract: &grpc.BytesFilter{In: []grpc.Bytes{
+							starknetid.AddressBraavos.Bytes(), starknetid.AddressXplorer.Bytes(),
+						}},
+						Height: &grpc.IntegerFilter{Gte: 5},
+					},
+				},
+			}.ToGrpcFilter(),
+			wantNext: 5,
+		}, {
+			name: "without contract",
+			req: grpc.Subscription{
+				EventFilter: []*grpc.EventFilter{
+					{Name: &grpc.StringFilter{Eq: starknetid.EventTransfer}},
+				},
+			}.ToGrpcFilter(),
+			wantErr: true,
+		}, {
+			name: "parsed data filter",
+			req: grpc.Subscription{
+				EventFilter: []*grpc.EventFilter{
+					{
+						Contract:   &grpc.BytesFilter{Eq: starknetid.AddressNaming.Bytes()},
+						ParsedData: map[string]string{"address": alice},
+					},
+				},
+			}.ToGrpcFilter(),
+			wantErr: true,
+		}, {
+			name: "invokes",
+			req: grpc.Subscription{
+				InvokeFilters: []*grpc.InvokeFilters{
+					{Height: &grpc.IntegerFilter{Gt: 1}},
+				},
+			}.ToGrpcFilter(),
+			wantErr: true,
+		}, {
+			name:    "head only",
+			req:     grpc.Subscription{Head: true}.ToGrpcFilter(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := newSubscription(tt.req)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantNext, sub.next)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package jsonrpc
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/encoding"
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/pkg/errors"
+)
+
+type eventFilter struct {
+	contract  string
+	selectors []string
+}
+
+type subscription struct {
+	id            uint64
+	head          bool
+	addresses     bool
+	lastAddressId uint64
+	filters       []eventFilter
+	next          uint64
+	cancel        context.CancelFunc
+}
+
+// newSubscription - converts request to `starknet_getEvents` filters. Events are requested by contract and name
+// from the height of the request. Filters which can't be expressed by the node API are rejected.
+func newSubscription(req *pb.SubscribeRequest) (*subscription, error) {
+	switch {
+	case len(req.GetInvokes()) > 0, len(req.GetDeclares()) > 0, len(req.GetDeploys()) > 0,
+		len(req.GetDeployAccounts()) > 0, len(req.GetL1Handlers()) > 0, len(req.GetInternals()) > 0,
+		len(req.GetFees()) > 0, len(req.GetMsgs()) > 0, len(req.GetTransfers()) > 0,
+		len(req.GetStorageDiffs()) > 0, len(req.GetTokenBalances()) > 0, len(req.GetTokens()) > 0:
+		return nil, errors.New("only head, event and address filters are supported by JSON-RPC source")
+	}
+
+	sub := &subscription{
+		head:      req.GetHead(),
+		addresses: len(req.GetAddresses()) > 0,
+		filters:   make([]eventFilter, 0, len(req.GetEvents())),
+	}
+
+	for i, address := range req.GetAddresses() {
+		if address.GetHeight() != nil {
+			return nil, errors.Errorf("address filter %d: height isn't supported", i)
+		}
+		switch typ := address.GetId().GetFilter().(type) {
+		case nil:
+		case *pb.IntegerFilter_Gt:
+			if typ.Gt > sub.lastAddressId {
+				sub.lastAddressId = typ.Gt
+			}
+		default:
+			return nil, errors.Errorf("address filter %d: only `gt` id filter is supported", i)
+		}
+	}
+
+	for i, filter := range req.GetEvents() {
+		if filter.GetFrom() != nil || filter.GetTime() != nil || filter.GetId() != nil || len(filter.GetParsedData()) > 0 {
+			return nil, errors.Errorf("event filter %d: only contract, name and height filters are supported", i)
+		}
+
+		var contracts [][]byte
+		switch typ := filter.GetContract().GetFilter().(type) {
+		case *pb.BytesFilter_Eq:
+			contracts = [][]byte{typ.Eq}
+		case *pb.BytesFilter_In:
+			contracts = typ.In.GetArr()
+		default:
+			return nil, errors.Errorf("event filter %d: contract is required", i)
+		}
+
+		var names []string
+		switch typ := filter.GetName().GetFilter().(type) {
+		case nil:
+		case *pb.StringFilter_Eq:
+			names = []string{typ.Eq}
+		case *pb.StringFilter_In:
+			names = typ.In.GetArr()
+		}
+		selectors := make([]string, len(names))
+		for j := range names {
+			selectors[j] = encoding.GetSelectorWithPrefixFromName(names[j])
+		}
+
+		var next uint64
+		switch typ := filter.GetHeight().GetFilter().(type) {
+		case nil:
+		case *pb.IntegerFilter_Gt:
+			next = typ.Gt + 1
+		case *pb.IntegerFilter_Gte:
+			next = typ.Gte
+		default:
+			return nil, errors.Errorf("event filter %d: only `gt` and `gte` height filters are supported", i)
+		}
+		if i == 0 || next < sub.next {
+			sub.next = next
+		}
+
+		for _, contract := range contracts {
+			sub.filters = append(sub.filters, eventFilter{
+				contract:  encoding.EncodeHex(contract),
+				selectors: selectors,
+			})
+		}
+	}
+
+	if len(sub.filters) == 0 {
+		return nil, errors.New("JSON-RPC source requires event filters")
+	}
+	return sub, nil
+}
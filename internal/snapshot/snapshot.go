@@ -0,0 +1,389 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// FormatVersion - version of archive layout. Archives of other versions are rejected on import.
+const FormatVersion = 1
+
+const (
+	manifestFile = "manifest.json"
+	tablesDir    = "tables"
+	batchSize    = 1000
+)
+
+// errors
+var (
+	ErrInvalidArchive = errors.New("invalid snapshot archive")
+	ErrChecksum       = errors.New("snapshot checksum mismatch")
+	ErrSchemaVersion  = errors.New("snapshot schema version differs from database")
+)
+
+// Manifest - description of archive content. It's the first file of archive.
+type Manifest struct {
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int       `json:"schema_version"`
+	States        []State   `json:"states"`
+	Tables        []Table   `json:"tables"`
+}
+
+// State - indexer state of channel at the moment of export
+type State struct {
+	Name       string `json:"name"`
+	LastHeight uint64 `json:"last_height"`
+}
+
+// Table - table dump inside archive. Checksum is SHA-256 of uncompressed file.
+type Table struct {
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Rows     int64  `json:"rows"`
+	Checksum string `json:"checksum"`
+}
+
+// LastHeight - returns the lowest height of channels, the indexer continues from it
+func (m Manifest) LastHeight() uint64 {
+	var height uint64
+	for i := range m.States {
+		if i == 0 || m.States[i].LastHeight < height {
+			height = m.States[i].LastHeight
+		}
+	}
+	return height
+}
+
+// ReadTx - consistent read-only view of the database
+type ReadTx interface {
+	SchemaVersion(ctx context.Context) (int, error)
+	Dump(ctx context.Context, model sdk.Model, fn func(row sdk.Model) error) error
+}
+
+// WriteTx - transaction which restores tables
+type WriteTx interface {
+	SchemaVersion(ctx context.Context) (int, error)
+	Truncate(ctx context.Context, models ...sdk.Model) error
+	Insert(ctx context.Context, rows []sdk.Model) error
+}
+
+// Database - storage which can be dumped and restored. Changes made in RestoreSnapshot are committed only if fn returns nil.
+type Database interface {
+	ReadSnapshot(ctx context.Context, fn func(tx ReadTx) error) error
+	RestoreSnapshot(ctx context.Context, fn func(tx WriteTx) error) error
+}
+
+type tableDump struct {
+	table Table
+	file  *os.File
+}
+
+var baseModelType = reflect.TypeOf(bun.BaseModel{})
+
+// columns - row struct which contains only columns of the model. Relations marked with `bun:"-"` and bun.BaseModel aren't dumped.
+type columns struct {
+	typ   reflect.Type
+	index []int
+}
+
+func newColumns(model sdk.Model) columns {
+	typ := reflect.TypeOf(model).Elem()
+	fields := make([]reflect.StructField, 0, typ.NumField())
+	index := make([]int, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() || field.Type == baseModelType || field.Tag.Get("bun") == "-" {
+			continue
+		}
+		fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+		index = append(index, i)
+	}
+	return columns{
+		typ:   reflect.StructOf(fields),
+		index: index,
+	}
+}
+
+// row - copies columns of the model to the row struct
+func (c columns) row(model sdk.Model) any {
+	src := reflect.ValueOf(model).Elem()
+	dst := reflect.New(c.typ).Elem()
+	for i, j := range c.index {
+		dst.Field(i).Set(src.Field(j))
+	}
+	return dst.Interface()
+}
+
+// Export - writes all tables of models to gzipped tar archive
+func Export(ctx context.Context, db Database, models []sdk.Model, w io.Writer) (Manifest, error) {
+	manifest := Manifest{
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		States:    make([]State, 0),
+		Tables:    make([]Table, 0, len(models)),
+	}
+
+	// tables are dumped to temporary files first because manifest with checksums is written before them
+	dumps := make([]tableDump, 0, len(models))
+	defer func() {
+		for i := range dumps {
+			dumps[i].file.Close()
+			os.Remove(dumps[i].file.Name())
+		}
+	}()
+
+	err := db.ReadSnapshot(ctx, func(tx ReadTx) error {
+		version, err := tx.SchemaVersion(ctx)
+		if err != nil {
+			return errors.Wrap(err, "schema version")
+		}
+		manifest.SchemaVersion = version
+
+		for _, model := range models {
+			file, err := os.CreateTemp("", "snapshot-*.ndjson")
+			if err != nil {
+				return err
+			}
+			dump := tableDump{
+				table: Table{
+					Name: model.TableName(),
+					File: path.Join(tablesDir, model.TableName()+".ndjson"),
+				},
+				file: file,
+			}
+			dumps = append(dumps, dump)
+
+			checksum := sha256.New()
+			buf := bufio.NewWriter(io.MultiWriter(file, checksum))
+			encoder := json.NewEncoder(buf)
+			cols := newColumns(model)
+			var rows int64
+			if err := tx.Dump(ctx, model, func(row sdk.Model) error {
+				if state, ok := row.(*storage.State); ok {
+					manifest.States = append(manifest.States, State{
+						Name:       state.Name,
+						LastHeight: state.LastHeight,
+					})
+				}
+				rows++
+				return encoder.Encode(cols.row(row))
+			}); err != nil {
+				return errors.Wrapf(err, "dump %s", model.TableName())
+			}
+			if err := buf.Flush(); err != nil {
+				return err
+			}
+			dumps[len(dumps)-1].table.Rows = rows
+			dumps[len(dumps)-1].table.Checksum = hex.EncodeToString(checksum.Sum(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return manifest, err
+	}
+
+	for i := range dumps {
+		manifest.Tables = append(manifest.Tables, dumps[i].table)
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	rawManifest, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := archive.WriteHeader(&tar.Header{
+		Name:    manifestFile,
+		Mode:    0o644,
+		Size:    int64(len(rawManifest)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return manifest, err
+	}
+	if _, err := archive.Write(rawManifest); err != nil {
+		return manifest, err
+	}
+
+	for i := range dumps {
+		if err := writeDump(archive, dumps[i], manifest.CreatedAt); err != nil {
+			return manifest, errors.Wrapf(err, "archive %s", dumps[i].table.Name)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return manifest, err
+	}
+	return manifest, gz.Close()
+}
+
+func writeDump(archive *tar.Writer, dump tableDump, modTime time.Time) error {
+	info, err := dump.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := dump.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := archive.WriteHeader(&tar.Header{
+		Name:    dump.table.File,
+		Mode:    0o644,
+		Size:    info.Size(),
+		ModTime: modTime,
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(archive, dump.file)
+	return err
+}
+
+// Import - restores tables of models from archive in one transaction. Tables are truncated before restoring,
+// and nothing is changed if archive is broken or its schema version differs from the database.
+func Import(ctx context.Context, db Database, models []sdk.Model, r io.Reader) (Manifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Manifest{}, errors.Wrap(ErrInvalidArchive, err.Error())
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return manifest, err
+	}
+
+	byName := make(map[string]sdk.Model, len(models))
+	for _, model := range models {
+		byName[model.TableName()] = model
+	}
+	tables := make(map[string]Table, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		if _, ok := byName[table.Name]; !ok {
+			return manifest, errors.Wrapf(ErrInvalidArchive, "unknown table: %s", table.Name)
+		}
+		tables[table.File] = table
+	}
+
+	err = db.RestoreSnapshot(ctx, func(tx WriteTx) error {
+		version, err := tx.SchemaVersion(ctx)
+		if err != nil {
+			return errors.Wrap(err, "schema version")
+		}
+		if version != manifest.SchemaVersion {
+			return errors.Wrapf(ErrSchemaVersion, "database %d, snapshot %d", version, manifest.SchemaVersion)
+		}
+
+		if err := tx.Truncate(ctx, models...); err != nil {
+			return errors.Wrap(err, "truncate")
+		}
+
+		restored := make(map[string]struct{}, len(tables))
+		for {
+			header, err := archive.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return errors.Wrap(ErrInvalidArchive, err.Error())
+			}
+			table, ok := tables[header.Name]
+			if !ok {
+				return errors.Wrapf(ErrInvalidArchive, "unexpected file: %s", header.Name)
+			}
+			if _, ok := restored[table.File]; ok {
+				return errors.Wrapf(ErrInvalidArchive, "duplicate file: %s", header.Name)
+			}
+			if err := restoreTable(ctx, tx, byName[table.Name], table, archive); err != nil {
+				return errors.Wrapf(err, "restore %s", table.Name)
+			}
+			restored[table.File] = struct{}{}
+		}
+
+		for file := range tables {
+			if _, ok := restored[file]; !ok {
+				return errors.Wrapf(ErrInvalidArchive, "missing file: %s", file)
+			}
+		}
+		return nil
+	})
+	return manifest, err
+}
+
+func readManifest(archive *tar.Reader) (Manifest, error) {
+	var manifest Manifest
+	header, err := archive.Next()
+	if err != nil {
+		return manifest, errors.Wrap(ErrInvalidArchive, err.Error())
+	}
+	if header.Name != manifestFile {
+		return manifest, errors.Wrapf(ErrInvalidArchive, "first file is %s, expected %s", header.Name, manifestFile)
+	}
+	if err := json.NewDecoder(archive).Decode(&manifest); err != nil {
+		return manifest, errors.Wrap(ErrInvalidArchive, err.Error())
+	}
+	if manifest.Version != FormatVersion {
+		return manifest, errors.Wrapf(ErrInvalidArchive, "unsupported format version %d", manifest.Version)
+	}
+	return manifest, nil
+}
+
+func restoreTable(ctx context.Context, tx WriteTx, model sdk.Model, table Table, r io.Reader) error {
+	checksum := sha256.New()
+	tee := io.TeeReader(r, checksum)
+	decoder := json.NewDecoder(tee)
+	typ := reflect.TypeOf(model).Elem()
+
+	var rows int64
+	batch := make([]sdk.Model, 0, batchSize)
+	for {
+		row := reflect.New(typ).Interface().(sdk.Model)
+		if err := decoder.Decode(row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return errors.Wrap(ErrInvalidArchive, err.Error())
+		}
+		rows++
+
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := tx.Insert(ctx, batch); err != nil {
+				return err
+			}
+			batch = make([]sdk.Model, 0, batchSize)
+		}
+	}
+	if len(batch) > 0 {
+		if err := tx.Insert(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	// the rest of file has to be hashed too
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+	if rows != table.Rows {
+		return errors.Wrapf(ErrChecksum, "%d rows, expected %d", rows, table.Rows)
+	}
+	if sum := hex.EncodeToString(checksum.Sum(nil)); sum != table.Checksum {
+		return errors.Wrapf(ErrChecksum, "%s, expected %s", sum, table.Checksum)
+	}
+	return nil
+}
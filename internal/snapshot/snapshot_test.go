@@ -0,0 +1,273 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// testDatabase - in-memory database. RestoreSnapshot works on a copy of tables which replaces them on success.
+type testDatabase struct {
+	version int
+	tables  map[string][]sdk.Model
+}
+
+func newTestDatabase(version int) *testDatabase {
+	return &testDatabase{
+		version: version,
+		tables:  make(map[string][]sdk.Model),
+	}
+}
+
+func (db *testDatabase) SchemaVersion(ctx context.Context) (int, error) {
+	return db.version, nil
+}
+
+func (db *testDatabase) Dump(ctx context.Context, model sdk.Model, fn func(row sdk.Model) error) error {
+	for _, row := range db.tables[model.TableName()] {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *testDatabase) Truncate(ctx context.Context, models ...sdk.Model) error {
+	for _, model := range models {
+		delete(db.tables, model.TableName())
+	}
+	return nil
+}
+
+func (db *testDatabase) Insert(ctx context.Context, rows []sdk.Model) error {
+	for _, row := range rows {
+		db.tables[row.TableName()] = append(db.tables[row.TableName()], row)
+	}
+	return nil
+}
+
+func (db *testDatabase) ReadSnapshot(ctx context.Context, fn func(tx ReadTx) error) error {
+	return fn(db)
+}
+
+func (db *testDatabase) RestoreSnapshot(ctx context.Context, fn func(tx WriteTx) error) error {
+	tx := newTestDatabase(db.version)
+	for name, rows := range db.tables {
+		tx.tables[name] = append([]sdk.Model(nil), rows...)
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	db.tables = tx.tables
+	return nil
+}
+
+func testSource() *testDatabase {
+	db := newTestDatabase(5)
+	blockTime := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	db.tables["state"] = []sdk.Model{
+		&storage.State{ID: 1, Name: "starknet_id", LastHeight: 120, LastTime: blockTime, LastBlockTime: blockTime},
+		&storage.State{ID: 2, Name: "naming", LastHeight: 100, LastTime: blockTime},
+	}
+	db.tables["address"] = []sdk.Model{
+		&storage.Address{Id: 1, Hash: []byte{0x01, 0x02}},
+	}
+	db.tables["domain"] = []sdk.Model{
+		&storage.Domain{Id: 1, AddressId: 1, AddressHash: []byte{0x01, 0x02}, Domain: "fricoben.stark", Owner: decimal.NewFromInt(1), Expiry: blockTime},
+	}
+	db.tables["field"] = []sdk.Model{
+		&storage.Field{Id: 1, OwnerId: decimal.NewFromInt(1), Namespace: storage.FieldNamespaceVerifier, Name: "twitter", Value: []byte{0x2a}, TextValue: "42", NumericValue: decimal.NewNullDecimal(decimal.NewFromInt(42))},
+	}
+	return db
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := testSource()
+
+	var archive bytes.Buffer
+	exported, err := Export(ctx, source, storage.Models, &archive)
+	require.NoError(t, err)
+	require.Equal(t, FormatVersion, exported.Version)
+	require.Equal(t, 5, exported.SchemaVersion)
+	require.Len(t, exported.Tables, len(storage.Models))
+	require.Equal(t, []State{{Name: "starknet_id", LastHeight: 120}, {Name: "naming", LastHeight: 100}}, exported.States)
+	require.EqualValues(t, 100, exported.LastHeight())
+
+	target := newTestDatabase(5)
+	target.tables["domain"] = []sdk.Model{
+		&storage.Domain{Id: 10, Domain: "stale.stark"},
+	}
+
+	imported, err := Import(ctx, target, storage.Models, &archive)
+	require.NoError(t, err)
+	require.Equal(t, exported.Tables, imported.Tables)
+
+	for _, model := range storage.Models {
+		name := model.TableName()
+		require.Len(t, target.tables[name], len(source.tables[name]), name)
+		for i := range source.tables[name] {
+			expected, err := json.Marshal(source.tables[name][i])
+			require.NoError(t, err)
+			actual, err := json.Marshal(target.tables[name][i])
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), string(actual), name)
+		}
+	}
+}
+
+func TestExport_Columns(t *testing.T) {
+	ctx := context.Background()
+	source := testSource()
+	domain := source.tables["domain"][0].(*storage.Domain)
+	domain.Address = storage.Address{Id: 1, Hash: []byte{0x01, 0x02}}
+	domain.StarknetId = storage.StarknetId{Id: 1, Fields: []storage.Field{{Name: "twitter"}}}
+
+	var archive bytes.Buffer
+	_, err := Export(ctx, source, storage.Models, &archive)
+	require.NoError(t, err)
+
+	var content []byte
+	rewriteArchive(t, archive.Bytes(), func(name string, data []byte) ([]byte, bool) {
+		if name == "tables/domain.ndjson" {
+			content = data
+		}
+		return data, true
+	})
+
+	var row map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(content, &row))
+	keys := make([]string, 0, len(row))
+	for key := range row {
+		keys = append(keys, key)
+	}
+	require.ElementsMatch(t, []string{"Id", "AddressId", "AddressHash", "Domain", "Owner", "Expiry", "EffectiveExpiry"}, keys)
+
+	target := newTestDatabase(5)
+	_, err = Import(ctx, target, storage.Models, &archive)
+	require.NoError(t, err)
+	restored := target.tables["domain"][0].(*storage.Domain)
+	require.Equal(t, "fricoben.stark", restored.Domain)
+	require.Zero(t, restored.Address.Id)
+}
+
+func TestImport_SchemaVersion(t *testing.T) {
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	_, err := Export(ctx, testSource(), storage.Models, &archive)
+	require.NoError(t, err)
+
+	target := newTestDatabase(4)
+	_, err = Import(ctx, target, storage.Models, &archive)
+	require.ErrorIs(t, err, ErrSchemaVersion)
+	require.Empty(t, target.tables)
+}
+
+func TestImport_Corrupted(t *testing.T) {
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	_, err := Export(ctx, testSource(), storage.Models, &archive)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		modify  func(name string, content []byte) ([]byte, bool)
+		wantErr error
+	}{
+		{
+			name: "changed row",
+			modify: func(name string, content []byte) ([]byte, bool) {
+				if name == "tables/domain.ndjson" {
+					return bytes.Replace(content, []byte("fricoben"), []byte("fricobem"), 1), true
+				}
+				return content, true
+			},
+			wantErr: ErrChecksum,
+		}, {
+			name: "missing table",
+			modify: func(name string, content []byte) ([]byte, bool) {
+				return content, name != "tables/field.ndjson"
+			},
+			wantErr: ErrInvalidArchive,
+		}, {
+			name: "without manifest",
+			modify: func(name string, content []byte) ([]byte, bool) {
+				return content, name != manifestFile
+			},
+			wantErr: ErrInvalidArchive,
+		}, {
+			name: "replaced table",
+			modify: func(name string, content []byte) ([]byte, bool) {
+				if name == "tables/field.ndjson" {
+					return []byte("{}"), true
+				}
+				return content, true
+			},
+			wantErr: ErrChecksum,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTestDatabase(5)
+			target.tables["domain"] = []sdk.Model{
+				&storage.Domain{Id: 10, Domain: "stale.stark"},
+			}
+
+			corrupted := rewriteArchive(t, archive.Bytes(), tt.modify)
+			_, err := Import(ctx, target, storage.Models, bytes.NewReader(corrupted))
+			require.Error(t, err)
+			require.True(t, errors.Is(err, tt.wantErr), err.Error())
+			require.Len(t, target.tables["domain"], 1)
+			require.Equal(t, "stale.stark", target.tables["domain"][0].(*storage.Domain).Domain)
+		})
+	}
+}
+
+func TestImport_NotArchive(t *testing.T) {
+	_, err := Import(context.Background(), newTestDatabase(5), storage.Models, bytes.NewBufferString("not an archive"))
+	require.ErrorIs(t, err, ErrInvalidArchive)
+}
+
+func rewriteArchive(t *testing.T, archive []byte, modify func(name string, content []byte) ([]byte, bool)) []byte {
+	gzReader, err := gzip.NewReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	reader := tar.NewReader(gzReader)
+
+	var result bytes.Buffer
+	gzWriter := gzip.NewWriter(&result)
+	writer := tar.NewWriter(gzWriter)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		content, keep := modify(header.Name, content)
+		if !keep {
+			continue
+		}
+		header.Size = int64(len(content))
+		require.NoError(t, writer.WriteHeader(header))
+		_, err = writer.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, gzWriter.Close())
+	return result.Bytes()
+}
@@ -0,0 +1,43 @@
+package starknetid
+
+import (
+	_ "embed"
+	"sync"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/abi"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+)
+
+//go:embed abi.json
+var rawAbi []byte
+
+var (
+	eventsAbi    abi.Abi
+	eventsAbiErr error
+	eventsOnce   sync.Once
+)
+
+// Abi - returns ABI of indexed events as they are declared in Cairo 0 contracts of Starknet ID. It's used to decode events
+// of contracts which ABI doesn't declare them, e.g. proxies.
+func Abi() (abi.Abi, error) {
+	eventsOnce.Do(func() {
+		if err := json.Unmarshal(rawAbi, &eventsAbi); err != nil {
+			eventsAbiErr = errors.Wrap(err, "starknet id abi")
+		}
+	})
+	return eventsAbi, eventsAbiErr
+}
+
+// addressFields - fields of events which contain Starknet addresses
+var addressFields = map[string][]string{
+	EventTransfer:               {"from_", "to"},
+	EventDomainToAddrUpdate:     {"address"},
+	EventAddrToDomainUpdate:     {"address"},
+	EventDomainToResolverUpdate: {"resolver"},
+}
+
+// AddressFields - returns names of event fields which contain Starknet addresses
+func AddressFields(event string) []string {
+	return addressFields[event]
+}
@@ -23,6 +23,26 @@ type ApiError struct {
 	Error string `json:"error"`
 }
 
+// StatusError - error response of the API
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error -
+func (e StatusError) Error() string {
+	return fmt.Sprintf("invalid status code (%s): %d", e.Message, e.StatusCode)
+}
+
+// IsNotFound - returns true if the API doesn't know requested domain or address. The API responds to them with client error.
+func IsNotFound(err error) bool {
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode >= http.StatusBadRequest && statusErr.StatusCode < http.StatusInternalServerError
+}
+
 // DomainToAddrResponse -
 type DomainToAddrResponse struct {
 	Addr         string `json:"addr"`
@@ -61,6 +81,14 @@ func NewApi(cfg ApiConfig) Api {
 	return api
 }
 
+// WithTransport - returns API sending requests with the transport. It's used to record and replay fixtures.
+func (api Api) WithTransport(transport http.RoundTripper) Api {
+	api.client = &http.Client{
+		Transport: transport,
+	}
+	return api
+}
+
 func (api Api) get(ctx context.Context, requestUrl string, output any) error {
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, requestUrl, nil)
 	if err != nil {
@@ -87,7 +115,10 @@ func (api Api) get(ctx context.Context, requestUrl string, output any) error {
 		if err := decoder.Decode(&apiErr); err != nil {
 			return errors.Wrapf(err, "invalid status code: %d", response.StatusCode)
 		}
-		return errors.Errorf("invalid status code (%s): %d", apiErr.Error, response.StatusCode)
+		return StatusError{
+			StatusCode: response.StatusCode,
+			Message:    apiErr.Error,
+		}
 	}
 
 	if err := decoder.Decode(output); err != nil {
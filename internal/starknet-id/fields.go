@@ -0,0 +1,139 @@
+package starknetid
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// well-known verifier fields
+const (
+	FieldTwitter           = "twitter"
+	FieldDiscord           = "discord"
+	FieldGithub            = "github"
+	FieldProofOfPersonhood = "proof_of_personhood"
+)
+
+// FieldValue - normalized value of the field
+type FieldValue struct {
+	Text    string
+	Numeric decimal.NullDecimal
+}
+
+// FieldDecoder - converts raw field value to normalized one and normalizes user input for lookup.
+// Normalized input has to be equal to `FieldValue.Text` of the same value.
+type FieldDecoder interface {
+	Decode(raw []byte) (FieldValue, error)
+	Normalize(input string) (string, error)
+}
+
+var (
+	fieldDecoders = map[string]FieldDecoder{
+		FieldTwitter:           NumericDecoder{},
+		FieldDiscord:           NumericDecoder{},
+		FieldGithub:            NumericDecoder{},
+		FieldProofOfPersonhood: BoolDecoder{},
+	}
+	fieldDecodersMx sync.RWMutex
+)
+
+// RegisterFieldDecoder - registers decoder for the field name. Replaces existing decoder.
+func RegisterFieldDecoder(name string, decoder FieldDecoder) {
+	fieldDecodersMx.Lock()
+	fieldDecoders[name] = decoder
+	fieldDecodersMx.Unlock()
+}
+
+// GetFieldDecoder - returns decoder of the field. If field is unknown, returns false.
+func GetFieldDecoder(name string) (FieldDecoder, bool) {
+	fieldDecodersMx.RLock()
+	decoder, ok := fieldDecoders[name]
+	fieldDecodersMx.RUnlock()
+	return decoder, ok
+}
+
+// FieldDecoderNames - returns sorted names of fields which have registered decoders
+func FieldDecoderNames() []string {
+	fieldDecodersMx.RLock()
+	names := make([]string, 0, len(fieldDecoders))
+	for name := range fieldDecoders {
+		names = append(names, name)
+	}
+	fieldDecodersMx.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// DecodeField - decodes raw value of the field by registered decoder. If field is unknown, returns false.
+func DecodeField(name string, raw []byte) (FieldValue, bool, error) {
+	decoder, ok := GetFieldDecoder(name)
+	if !ok {
+		return FieldValue{}, false, nil
+	}
+	value, err := decoder.Decode(raw)
+	return value, true, err
+}
+
+// NumericDecoder - value is a number, e.g. user id in external service
+type NumericDecoder struct{}
+
+// Decode -
+func (NumericDecoder) Decode(raw []byte) (FieldValue, error) {
+	number := decimal.NewFromBigInt(new(big.Int).SetBytes(raw), 0)
+	return FieldValue{
+		Text:    number.String(),
+		Numeric: decimal.NewNullDecimal(number),
+	}, nil
+}
+
+// Normalize -
+func (NumericDecoder) Normalize(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "0x") {
+		number, ok := new(big.Int).SetString(strings.TrimPrefix(input, "0x"), 16)
+		if !ok {
+			return "", errors.Errorf("invalid hex number: %s", input)
+		}
+		return number.String(), nil
+	}
+	number, err := decimal.NewFromString(input)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid number")
+	}
+	if !number.IsInteger() || number.IsNegative() {
+		return "", errors.Errorf("value has to be non-negative integer: %s", input)
+	}
+	return number.String(), nil
+}
+
+// BoolDecoder - value is a flag: 0 is false, any other value is true
+type BoolDecoder struct{}
+
+// Decode -
+func (BoolDecoder) Decode(raw []byte) (FieldValue, error) {
+	value := FieldValue{
+		Text:    "false",
+		Numeric: decimal.NewNullDecimal(decimal.Zero),
+	}
+	if new(big.Int).SetBytes(raw).Sign() != 0 {
+		value.Text = "true"
+		value.Numeric = decimal.NewNullDecimal(decimal.NewFromInt(1))
+	}
+	return value, nil
+}
+
+// Normalize -
+func (BoolDecoder) Normalize(input string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "true", "1":
+		return "true", nil
+	case "false", "0":
+		return "false", nil
+	default:
+		return "", errors.Errorf("invalid boolean value: %s", input)
+	}
+}
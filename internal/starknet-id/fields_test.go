@@ -0,0 +1,131 @@
+package starknetid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeField(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		raw     []byte
+		want    string
+		known   bool
+		numeric string
+	}{
+		{
+			name:    "twitter",
+			field:   FieldTwitter,
+			raw:     []byte{0x00, 0x00, 0x01, 0x00},
+			want:    "256",
+			known:   true,
+			numeric: "256",
+		}, {
+			name:    "github",
+			field:   FieldGithub,
+			raw:     []byte{0x04, 0xd2},
+			want:    "1234",
+			known:   true,
+			numeric: "1234",
+		}, {
+			name:    "discord empty",
+			field:   FieldDiscord,
+			want:    "0",
+			known:   true,
+			numeric: "0",
+		}, {
+			name:    "proof of personhood",
+			field:   FieldProofOfPersonhood,
+			raw:     []byte{0x01},
+			want:    "true",
+			known:   true,
+			numeric: "1",
+		}, {
+			name:    "proof of personhood is reset",
+			field:   FieldProofOfPersonhood,
+			raw:     []byte{0x00},
+			want:    "false",
+			known:   true,
+			numeric: "0",
+		}, {
+			name:  "unknown",
+			field: "nft_pp_contract",
+			raw:   []byte{0x01},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, known, err := DecodeField(tt.field, tt.raw)
+			require.NoError(t, err)
+			require.Equal(t, tt.known, known)
+			require.Equal(t, tt.want, value.Text)
+			if tt.numeric != "" {
+				require.True(t, value.Numeric.Valid)
+				require.Equal(t, tt.numeric, value.Numeric.Decimal.String())
+			}
+		})
+	}
+}
+
+func TestFieldDecoder_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "decimal",
+			field: FieldTwitter,
+			input: " 00256",
+			want:  "256",
+		}, {
+			name:  "hex",
+			field: FieldTwitter,
+			input: "0x100",
+			want:  "256",
+		}, {
+			name:    "negative",
+			field:   FieldGithub,
+			input:   "-1",
+			wantErr: true,
+		}, {
+			name:    "text",
+			field:   FieldDiscord,
+			input:   "username",
+			wantErr: true,
+		}, {
+			name:  "bool",
+			field: FieldProofOfPersonhood,
+			input: "1",
+			want:  "true",
+		}, {
+			name:  "bool text",
+			field: FieldProofOfPersonhood,
+			input: "False",
+			want:  "false",
+		}, {
+			name:    "invalid bool",
+			field:   FieldProofOfPersonhood,
+			input:   "yes",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder, ok := GetFieldDecoder(tt.field)
+			require.True(t, ok)
+
+			got, err := decoder.Normalize(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,17 +2,19 @@ package starknetid
 
 import "github.com/dipdup-io/starknet-go-api/pkg/data"
 
-// addresses
+// mainnet addresses, see Mainnet preset
 const (
 	AddressStarknetId = data.Felt("0x05dbdedc203e92749e2e746e2d40a768d966bd243df04a6b712e222bc040a9af")
 	AddressNaming     = data.Felt("0x06ac597f8116f886fa1c97a23fa4e08299975ecaf6b598873ca6792b9bbfb678")
 	AddressBraavos    = data.Felt("0x03448896d4a0df143f98c9eeccc7e279bf3c2008bda2ad2759f5b20ed263585f")
+	AddressXplorer    = data.Felt("0x04942ebdc9fc996a42adb4a825e9070737fe68cef32a64a616ba5528d457812e")
 )
 
 // event names
 const (
 	EventTransfer               = "Transfer"
 	EventVerifierDataUpdate     = "VerifierDataUpdate"
+	EventUserDataUpdate         = "UserDataUpdate"
 	EventOnInftEquipped         = "on_inft_equipped"
 	EventDomainToAddrUpdate     = "domain_to_addr_update"
 	EventAddrToDomainUpdate     = "addr_to_domain_update"
@@ -37,6 +39,13 @@ type VerifierDataUpdate struct {
 	Verifier   data.Felt `json:"verifier"`
 }
 
+// UserDataUpdate -
+type UserDataUpdate struct {
+	StarknetId data.Felt `json:"starknet_id"`
+	Field      data.Felt `json:"field"`
+	Data       data.Felt `json:"data"`
+}
+
 // OnInftEquipped -
 type OnInftEquipped struct {
 	InftContract data.Felt `json:"inft_contract"`
@@ -0,0 +1,209 @@
+package starknetid
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/pkg/errors"
+)
+
+// Role - purpose of a contract in Starknet ID deployment. Role defines events which are indexed from the contract.
+type Role string
+
+// roles
+const (
+	RoleStarknetId Role = "starknet_id"
+	RoleNaming     Role = "naming"
+	RoleResolver   Role = "resolver"
+)
+
+var roleEvents = map[Role][]string{
+	RoleStarknetId: {
+		EventTransfer,
+		EventVerifierDataUpdate,
+		EventUserDataUpdate,
+		EventOnInftEquipped,
+	},
+	RoleNaming: {
+		EventDomainToAddrUpdate,
+		EventAddrToDomainUpdate,
+		EventStarknetIdUpdate,
+		EventDomainTransfer,
+		EventDomainToResolverUpdate,
+	},
+	RoleResolver: {
+		EventDomainToAddrUpdate,
+	},
+}
+
+// Events - returns names of events indexed from contracts of the role
+func (r Role) Events() []string {
+	return roleEvents[r]
+}
+
+// Contracts - addresses of Starknet ID contracts. Resolvers are subdomain resolvers keyed by subdomain name (e.g. `braavos`).
+type Contracts struct {
+	StarknetId data.Felt            `validate:"omitempty" yaml:"starknet_id"`
+	Naming     data.Felt            `validate:"omitempty" yaml:"naming"`
+	Resolvers  map[string]data.Felt `validate:"omitempty" yaml:"resolvers"`
+}
+
+// Contract - contract address with its role
+type Contract struct {
+	Role      Role
+	Address   data.Felt
+	Subdomain string
+}
+
+// Network - Starknet ID deployment: contracts, the block to start indexing from and the root domain
+type Network struct {
+	Name       string
+	StartBlock uint64
+	RootDomain string
+	Contracts  Contracts
+}
+
+// network presets
+var (
+	Mainnet = Network{
+		Name:       "mainnet",
+		RootDomain: "stark",
+		Contracts: Contracts{
+			StarknetId: AddressStarknetId,
+			Naming:     AddressNaming,
+			Resolvers: map[string]data.Felt{
+				"braavos": AddressBraavos,
+				"xplorer": AddressXplorer,
+			},
+		},
+	}
+
+	Sepolia = Network{
+		Name:       "sepolia",
+		RootDomain: "stark",
+		Contracts: Contracts{
+			StarknetId: data.Felt("0x03697660a0981d734780731949ecb2b4a38d6a58fc41629ed611e8defda"),
+			Naming:     data.Felt("0x0154bc2e1af9260b9e66af0e9c46fc757ff893b3ff6a85718a810baf1474"),
+		},
+	}
+
+	presets = map[string]Network{
+		Mainnet.Name: Mainnet,
+		Sepolia.Name: Sepolia,
+	}
+)
+
+// Preset - returns built-in network by name
+func Preset(name string) (Network, bool) {
+	network, ok := presets[name]
+	if ok {
+		network.Contracts.Resolvers = copyResolvers(network.Contracts.Resolvers)
+	}
+	return network, ok
+}
+
+// Override - returns network with non-empty fields of other. Resolvers are merged.
+func (n Network) Override(other Network) Network {
+	if other.Name != "" {
+		n.Name = other.Name
+	}
+	if other.StartBlock > 0 {
+		n.StartBlock = other.StartBlock
+	}
+	if other.RootDomain != "" {
+		n.RootDomain = other.RootDomain
+	}
+	if other.Contracts.StarknetId != "" {
+		n.Contracts.StarknetId = other.Contracts.StarknetId
+	}
+	if other.Contracts.Naming != "" {
+		n.Contracts.Naming = other.Contracts.Naming
+	}
+	resolvers := copyResolvers(n.Contracts.Resolvers)
+	for name, address := range other.Contracts.Resolvers {
+		if resolvers == nil {
+			resolvers = make(map[string]data.Felt)
+		}
+		resolvers[name] = address
+	}
+	n.Contracts.Resolvers = resolvers
+	return n
+}
+
+// Validate - checks that the root domain and contracts of all roles are set and addresses are valid hex
+func (n Network) Validate() error {
+	if n.RootDomain == "" {
+		return errors.New("empty root domain")
+	}
+	if n.Contracts.StarknetId == "" {
+		return errors.Errorf("empty %s contract", RoleStarknetId)
+	}
+	if n.Contracts.Naming == "" {
+		return errors.Errorf("empty %s contract", RoleNaming)
+	}
+	for _, contract := range n.List() {
+		if err := validateAddress(contract.Address); err != nil {
+			return errors.Wrapf(err, "%s contract %s", contract.Role, contract.Address)
+		}
+	}
+	return nil
+}
+
+// List - returns contracts ordered by role. Resolvers are ordered by subdomain.
+func (n Network) List() []Contract {
+	contracts := make([]Contract, 0, len(n.Contracts.Resolvers)+2)
+	if n.Contracts.StarknetId != "" {
+		contracts = append(contracts, Contract{Role: RoleStarknetId, Address: n.Contracts.StarknetId})
+	}
+	if n.Contracts.Naming != "" {
+		contracts = append(contracts, Contract{Role: RoleNaming, Address: n.Contracts.Naming})
+	}
+
+	subdomains := make([]string, 0, len(n.Contracts.Resolvers))
+	for subdomain := range n.Contracts.Resolvers {
+		subdomains = append(subdomains, subdomain)
+	}
+	sort.Strings(subdomains)
+	for _, subdomain := range subdomains {
+		contracts = append(contracts, Contract{
+			Role:      RoleResolver,
+			Address:   n.Contracts.Resolvers[subdomain],
+			Subdomain: subdomain,
+		})
+	}
+	return contracts
+}
+
+// Subdomains - returns subdomain names keyed by hex of resolver address without prefix
+func (n Network) Subdomains() map[string]string {
+	subdomains := make(map[string]string, len(n.Contracts.Resolvers))
+	for subdomain, address := range n.Contracts.Resolvers {
+		subdomains[hex.EncodeToString(address.Bytes())] = subdomain
+	}
+	return subdomains
+}
+
+func validateAddress(address data.Felt) error {
+	value := strings.TrimPrefix(address.String(), "0x")
+	if value == "" || len(value) > data.AddressBytesLength*2 {
+		return errors.New("invalid address length")
+	}
+	if len(value)%2 == 1 {
+		value = "0" + value
+	}
+	_, err := hex.DecodeString(value)
+	return err
+}
+
+func copyResolvers(resolvers map[string]data.Felt) map[string]data.Felt {
+	if resolvers == nil {
+		return nil
+	}
+	result := make(map[string]data.Felt, len(resolvers))
+	for name, address := range resolvers {
+		result[name] = address
+	}
+	return result
+}
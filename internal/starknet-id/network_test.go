@@ -0,0 +1,109 @@
+package starknetid
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dipdup-io/starknet-go-api/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreset(t *testing.T) {
+	mainnet, ok := Preset("mainnet")
+	require.True(t, ok)
+	require.Equal(t, "stark", mainnet.RootDomain)
+	require.Equal(t, AddressStarknetId, mainnet.Contracts.StarknetId)
+	require.NoError(t, mainnet.Validate())
+
+	mainnet.Contracts.Resolvers["braavos"] = "0x1"
+	require.Equal(t, AddressBraavos, Mainnet.Contracts.Resolvers["braavos"], "preset must not be changed through returned copy")
+
+	sepolia, ok := Preset("sepolia")
+	require.True(t, ok)
+	require.NoError(t, sepolia.Validate())
+	require.NotEqual(t, mainnet.Contracts.Naming, sepolia.Contracts.Naming)
+
+	_, ok = Preset("goerli")
+	require.False(t, ok)
+}
+
+func TestNetwork_Override(t *testing.T) {
+	network := Mainnet.Override(Network{
+		StartBlock: 100,
+		Contracts: Contracts{
+			Naming:    "0x01",
+			Resolvers: map[string]data.Felt{"argent": "0x02"},
+		},
+	})
+	require.Equal(t, "mainnet", network.Name)
+	require.EqualValues(t, 100, network.StartBlock)
+	require.Equal(t, "stark", network.RootDomain)
+	require.Equal(t, AddressStarknetId, network.Contracts.StarknetId)
+	require.Equal(t, data.Felt("0x01"), network.Contracts.Naming)
+	require.Len(t, network.Contracts.Resolvers, 3)
+	require.Len(t, Mainnet.Contracts.Resolvers, 2)
+}
+
+func TestNetwork_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		network Network
+		wantErr bool
+	}{
+		{
+			name:    "mainnet",
+			network: Mainnet,
+		}, {
+			name:    "without root domain",
+			network: Network{Contracts: Contracts{StarknetId: "0x1", Naming: "0x2"}},
+			wantErr: true,
+		}, {
+			name:    "without naming",
+			network: Network{RootDomain: "stark", Contracts: Contracts{StarknetId: "0x1"}},
+			wantErr: true,
+		}, {
+			name:    "invalid resolver",
+			network: Network{RootDomain: "stark", Contracts: Contracts{StarknetId: "0x1", Naming: "0x2", Resolvers: map[string]data.Felt{"test": "0xzz"}}},
+			wantErr: true,
+		}, {
+			name:    "too long address",
+			network: Network{RootDomain: "stark", Contracts: Contracts{StarknetId: data.Felt("0x" + strings.Repeat("1", 65)), Naming: "0x2"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.network.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNetwork_List(t *testing.T) {
+	contracts := Mainnet.List()
+	require.Len(t, contracts, 4)
+	require.Equal(t, Contract{Role: RoleStarknetId, Address: AddressStarknetId}, contracts[0])
+	require.Equal(t, Contract{Role: RoleNaming, Address: AddressNaming}, contracts[1])
+	require.Equal(t, Contract{Role: RoleResolver, Address: AddressBraavos, Subdomain: "braavos"}, contracts[2])
+	require.Equal(t, Contract{Role: RoleResolver, Address: AddressXplorer, Subdomain: "xplorer"}, contracts[3])
+	require.Equal(t, []string{EventDomainToAddrUpdate}, contracts[2].Role.Events())
+}
+
+func TestNetwork_Subdomains(t *testing.T) {
+	network := Network{
+		Contracts: Contracts{
+			Resolvers: map[string]data.Felt{
+				"braavos": AddressBraavos,
+				"short":   "0x1",
+			},
+		},
+	}
+	require.Equal(t, map[string]string{
+		"03448896d4a0df143f98c9eeccc7e279bf3c2008bda2ad2759f5b20ed263585f": "braavos",
+		"0000000000000000000000000000000000000000000000000000000000000001": "short",
+	}, network.Subdomains())
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+	"strings"
 	"time"
 
 	"github.com/dipdup-net/indexer-sdk/pkg/storage"
@@ -11,18 +13,26 @@ import (
 // IDomain -
 type IDomain interface {
 	storage.Table[*Domain]
+
+	Count(ctx context.Context) (int, error)
+	GetByName(ctx context.Context, name string) (Domain, error)
+	ListByOwner(ctx context.Context, owner decimal.Decimal) ([]Domain, error)
+	ListByAddress(ctx context.Context, address []byte) ([]Domain, error)
+	ListSubdomains(ctx context.Context, parent string) ([]Domain, error)
+	Search(ctx context.Context, mode SearchMode, query string, includeExpired bool, limit, offset int) ([]DomainSearchResult, error)
 }
 
 // Domain -
 type Domain struct {
 	bun.BaseModel `bun:"domain" comment:"Domains table"`
 
-	Id          uint64          `bun:"id,pk,autoincrement"              comment:"Unique internal identity"`
-	AddressId   uint64          `comment:"Address id from main indexer"`
-	AddressHash []byte          `comment:"Address hash"`
-	Domain      string          `bun:",unique"                          comment:"Domain string"`
-	Owner       decimal.Decimal `bun:",type:numeric"                    comment:"Owner's starknet id"`
-	Expiry      time.Time       `comment:"Expiration time"`
+	Id              uint64          `bun:"id,pk,autoincrement"              comment:"Unique internal identity"`
+	AddressId       uint64          `comment:"Address id from main indexer"`
+	AddressHash     []byte          `comment:"Address hash"`
+	Domain          string          `bun:",unique"                          comment:"Domain string"`
+	Owner           decimal.Decimal `bun:",type:numeric"                    comment:"Owner's starknet id"`
+	Expiry          time.Time       `comment:"Expiration time"`
+	EffectiveExpiry time.Time       `comment:"Expiration time of the domain or of its nearest ancestor with expiration time"`
 
 	Address    Address    `bun:"-" hasura:"table:address,field:address_id,remote_field:id,type:oto,name:address"`
 	StarknetId StarknetId `bun:"-" hasura:"table:starknet_id,field:owner,remote_field:id,type:oto,name:starknet_id"`
@@ -32,3 +42,54 @@ type Domain struct {
 func (Domain) TableName() string {
 	return "domain"
 }
+
+// Ancestors - returns parent domains from the nearest one, e.g. `b.stark` and `stark` for `a.b.stark`
+func Ancestors(domain string) []string {
+	ancestors := make([]string, 0)
+	for {
+		index := strings.IndexByte(domain, '.')
+		if index < 0 {
+			return ancestors
+		}
+		domain = domain[index+1:]
+		ancestors = append(ancestors, domain)
+	}
+}
+
+// Reverse - reverses domain by characters as `reverse` function of Postgres does. Reversed domains are indexed,
+// so descendants are found by prefix: `kcats.nebocirf.` for `fricoben.stark`
+func Reverse(domain string) string {
+	runes := []rune(domain)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// HasExpiry - returns false for zero time and for Unix epoch which is written by contracts for domains without expiry
+func HasExpiry(expiry time.Time) bool {
+	return expiry.Unix() > 0
+}
+
+// SearchMode -
+type SearchMode string
+
+// search modes
+const (
+	SearchModePrefix     SearchMode = "prefix"
+	SearchModeSubstring  SearchMode = "substring"
+	SearchModeSimilarity SearchMode = "similarity"
+)
+
+// DomainSearchResult - row returned by domain search functions
+type DomainSearchResult struct {
+	bun.BaseModel `bun:"domain_search_result"`
+
+	Id              uint64          `bun:"id"`
+	Address         []byte          `bun:"address"`
+	Domain          string          `bun:"domain"`
+	Expiry          time.Time       `bun:"expiry"`
+	EffectiveExpiry time.Time       `bun:"effective_expiry"`
+	StarknetId      decimal.Decimal `bun:"starknet_id,type:numeric"`
+	Rank            float64         `bun:"rank"`
+}
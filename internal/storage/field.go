@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/dipdup-net/indexer-sdk/pkg/storage"
 	"github.com/shopspring/decimal"
 	"github.com/uptrace/bun"
@@ -18,6 +20,12 @@ const (
 // IField -
 type IField interface {
 	storage.Table[*Field]
+
+	Count(ctx context.Context) (int, error)
+	ListByOwner(ctx context.Context, owner decimal.Decimal) ([]Field, error)
+	ListStarknetIdsByValue(ctx context.Context, name, value string) ([]decimal.Decimal, error)
+	ListDecoded(ctx context.Context) ([]string, error)
+	MarkDecoded(ctx context.Context, names ...string) error
 }
 
 // Field
@@ -30,6 +38,9 @@ type Field struct {
 	Name      string          `comment:"Field name"`
 	Value     []byte          `comment:"Field value"`
 
+	TextValue    string              `bun:",nullzero"     comment:"Normalized value of well-known field"`
+	NumericValue decimal.NullDecimal `bun:",type:numeric" comment:"Numeric value of well-known field"`
+
 	Owner StarknetId `bun:"-" hasura:"table:starknet_id,field:owner_id,remote_field:id,type:oto,name:starknet_id"`
 }
 
@@ -37,3 +48,16 @@ type Field struct {
 func (Field) TableName() string {
 	return "field"
 }
+
+// DecodedField - name of well-known field whose stored values were decoded. Values indexed before decoder of the field
+// was added are decoded once.
+type DecodedField struct {
+	bun.BaseModel `bun:"decoded_field" comment:"Well-known fields whose stored values were decoded"`
+
+	Name string `bun:"name,pk" comment:"Field name"`
+}
+
+// TableName -
+func (DecodedField) TableName() string {
+	return "decoded_field"
+}
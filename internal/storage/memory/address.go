@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// Address -
+type Address struct {
+	*Table[storage.Address, *storage.Address]
+}
+
+// newAddress -
+func newAddress(db *database) *Address {
+	return &Address{
+		Table: newTable[storage.Address, *storage.Address](db, func(data *tables) *rows[storage.Address] {
+			return &data.addresses
+		}),
+	}
+}
+
+// GetByHash -
+func (a *Address) GetByHash(ctx context.Context, hash []byte) (address storage.Address, err error) {
+	a.db.read(func(data *tables) {
+		var ok bool
+		address, ok = data.addresses.find(func(item storage.Address) bool {
+			return bytes.Equal(item.Hash, hash)
+		})
+		if !ok {
+			err = sql.ErrNoRows
+		}
+	})
+	return
+}
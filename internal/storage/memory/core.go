@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// Storage - in-memory storage backend. Data isn't persisted, it's used to run indexing logic without database.
+type Storage struct {
+	db *database
+
+	Addresses   models.IAddress
+	Domains     models.IDomain
+	Subdomains  models.ISubdomain
+	StarknetIds models.IStarknetId
+	Fields      models.IField
+	State       models.IState
+}
+
+// New - creates empty storage
+func New() *Storage {
+	db := &database{
+		data: tables{
+			addresses:   newRows(func(a *models.Address) *uint64 { return &a.Id }),
+			starknetIds: newRows(func(s *models.StarknetId) *uint64 { return &s.Id }),
+			domains:     newRows(func(d *models.Domain) *uint64 { return &d.Id }),
+			subdomains:  newRows(func(s *models.Subdomain) *uint64 { return &s.Id }),
+			fields:      newRows(func(f *models.Field) *uint64 { return &f.Id }),
+			states:      newRows(func(s *models.State) *uint64 { return &s.ID }),
+
+			decodedFields: make(map[string]struct{}),
+		},
+	}
+	return &Storage{
+		db:          db,
+		Addresses:   newAddress(db),
+		Domains:     newDomain(db),
+		Subdomains:  newSubdomain(db),
+		StarknetIds: newStarknetId(db),
+		Fields:      newField(db),
+		State:       newState(db),
+	}
+}
+
+// BeginTransaction - opens transaction which saves indexed block. Transactions are serialized: the next one waits
+// until the previous one is flushed or closed.
+func (s *Storage) BeginTransaction(ctx context.Context) (models.Transaction, error) {
+	s.db.txMx.Lock()
+
+	s.db.mx.RLock()
+	data := s.db.data.clone()
+	s.db.mx.RUnlock()
+
+	return &Transaction{db: s.db, data: &data}, nil
+}
+
+// Backend - returns tables and transactions of the storage
+func (s *Storage) Backend() models.Backend {
+	return models.Backend{
+		Transactable: s,
+		Addresses:    s.Addresses,
+		Domains:      s.Domains,
+		Subdomains:   s.Subdomains,
+		StarknetIds:  s.StarknetIds,
+		Fields:       s.Fields,
+		State:        s.State,
+	}
+}
+
+// Close -
+func (s *Storage) Close() error {
+	return nil
+}
+
+// IsNoRows - checks the error is returned because requested row isn't found
+func IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// database - data of all tables. Readers see the last flushed data, writers are serialized by txMx
+// and replace data under mx.
+type database struct {
+	data tables
+	mx   sync.RWMutex
+	txMx sync.Mutex
+}
+
+func (db *database) read(f func(data *tables)) {
+	db.mx.RLock()
+	defer db.mx.RUnlock()
+	f(&db.data)
+}
+
+func (db *database) write(f func(data *tables) error) error {
+	db.txMx.Lock()
+	defer db.txMx.Unlock()
+
+	db.mx.Lock()
+	defer db.mx.Unlock()
+	return f(&db.data)
+}
+
+type tables struct {
+	addresses   rows[models.Address]
+	starknetIds rows[models.StarknetId]
+	domains     rows[models.Domain]
+	subdomains  rows[models.Subdomain]
+	fields      rows[models.Field]
+	states      rows[models.State]
+
+	decodedFields map[string]struct{}
+}
+
+func (t tables) clone() tables {
+	return tables{
+		addresses:   t.addresses.clone(),
+		starknetIds: t.starknetIds.clone(),
+		domains:     t.domains.clone(),
+		subdomains:  t.subdomains.clone(),
+		fields:      t.fields.clone(),
+		states:      t.states.clone(),
+
+		decodedFields: maps.Clone(t.decodedFields),
+	}
+}
+
+var errDuplicateKey = errors.New("duplicate key value violates unique constraint")
+
+// rows - rows of the table by id. Rows are stored by value and replaced on update, so readers get copies.
+type rows[T any] struct {
+	items  map[uint64]T
+	lastId uint64
+	id     func(*T) *uint64
+}
+
+func newRows[T any](id func(*T) *uint64) rows[T] {
+	return rows[T]{
+		items: make(map[uint64]T),
+		id:    id,
+	}
+}
+
+func (r rows[T]) clone() rows[T] {
+	return rows[T]{
+		items:  maps.Clone(r.items),
+		lastId: r.lastId,
+		id:     r.id,
+	}
+}
+
+// insert - stores the row. Zero id is set to the next value of the table sequence as autoincrement does.
+func (r *rows[T]) insert(item *T) error {
+	id := r.id(item)
+	switch {
+	case *id == 0:
+		r.lastId++
+		*id = r.lastId
+	case *id > r.lastId:
+		r.lastId = *id
+	}
+	if _, ok := r.items[*id]; ok {
+		return errDuplicateKey
+	}
+	r.items[*id] = *item
+	return nil
+}
+
+// update - replaces the row with the same id if it exists
+func (r *rows[T]) update(item T) {
+	id := *r.id(&item)
+	if _, ok := r.items[id]; ok {
+		r.items[id] = item
+	}
+}
+
+// ids - returns sorted ids of the rows
+func (r rows[T]) ids() []uint64 {
+	ids := make([]uint64, 0, len(r.items))
+	for id := range r.items {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// find - returns the row with the lowest id matching the condition
+func (r rows[T]) find(match func(T) bool) (T, bool) {
+	for _, id := range r.ids() {
+		if item := r.items[id]; match(item) {
+			return item, true
+		}
+	}
+	var empty T
+	return empty, false
+}
+
+// filter - returns rows matching the condition ordered by id
+func (r rows[T]) filter(match func(T) bool) []T {
+	result := make([]T, 0)
+	for _, id := range r.ids() {
+		if item := r.items[id]; match(item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// remove - deletes rows matching the condition
+func (r *rows[T]) remove(match func(T) bool) {
+	for id, item := range r.items {
+		if match(item) {
+			delete(r.items, id)
+		}
+	}
+}
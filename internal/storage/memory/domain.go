@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Domain -
+type Domain struct {
+	*Table[storage.Domain, *storage.Domain]
+}
+
+// newDomain -
+func newDomain(db *database) *Domain {
+	return &Domain{
+		Table: newTable[storage.Domain, *storage.Domain](db, func(data *tables) *rows[storage.Domain] {
+			return &data.domains
+		}),
+	}
+}
+
+// Count -
+func (d *Domain) Count(ctx context.Context) (count int, err error) {
+	d.db.read(func(data *tables) {
+		count = len(data.domains.items)
+	})
+	return
+}
+
+// GetByName -
+func (d *Domain) GetByName(ctx context.Context, name string) (domain storage.Domain, err error) {
+	d.db.read(func(data *tables) {
+		var ok bool
+		domain, ok = data.domains.find(func(item storage.Domain) bool {
+			return item.Domain == name
+		})
+		if !ok {
+			err = sql.ErrNoRows
+		}
+	})
+	return
+}
+
+// ListByOwner -
+func (d *Domain) ListByOwner(ctx context.Context, owner decimal.Decimal) (domains []storage.Domain, err error) {
+	d.db.read(func(data *tables) {
+		domains = data.domains.filter(func(item storage.Domain) bool {
+			return item.Owner.Equal(owner)
+		})
+	})
+	return
+}
+
+// ListByAddress -
+func (d *Domain) ListByAddress(ctx context.Context, address []byte) (domains []storage.Domain, err error) {
+	d.db.read(func(data *tables) {
+		domains = data.domains.filter(func(item storage.Domain) bool {
+			return bytes.Equal(item.AddressHash, address)
+		})
+	})
+	return
+}
+
+// ListSubdomains - returns all domains which are under the parent domain on any level
+func (d *Domain) ListSubdomains(ctx context.Context, parent string) (domains []storage.Domain, err error) {
+	d.db.read(func(data *tables) {
+		domains = data.domains.filter(func(item storage.Domain) bool {
+			return strings.HasSuffix(item.Domain, "."+parent)
+		})
+	})
+	slices.SortFunc(domains, func(a, b storage.Domain) int {
+		return cmp.Compare(a.Domain, b.Domain)
+	})
+	return
+}
+
+// Search - searches domains as search functions of Postgres do. Results are ranked by relevance.
+func (d *Domain) Search(ctx context.Context, mode storage.SearchMode, query string, includeExpired bool, limit, offset int) ([]storage.DomainSearchResult, error) {
+	rank, ok := searchRanks[mode]
+	if !ok {
+		return nil, errors.Errorf("unknown search mode: %s", mode)
+	}
+	query = strings.ToLower(query)
+	now := time.Now()
+
+	results := make([]storage.DomainSearchResult, 0)
+	d.db.read(func(data *tables) {
+		for _, domain := range data.domains.filter(func(item storage.Domain) bool {
+			// domains cleared by zero address don't resolve
+			return len(item.AddressHash) > 0 && (includeExpired || item.EffectiveExpiry.After(now))
+		}) {
+			value, ok := rank(domain.Domain, query)
+			if !ok {
+				continue
+			}
+			results = append(results, storage.DomainSearchResult{
+				Id:              domain.Id,
+				Address:         domain.AddressHash,
+				Domain:          domain.Domain,
+				Expiry:          domain.Expiry,
+				EffectiveExpiry: domain.EffectiveExpiry,
+				StarknetId:      domain.Owner,
+				Rank:            value,
+			})
+		}
+	})
+
+	slices.SortFunc(results, func(a, b storage.DomainSearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Domain, b.Domain)
+	})
+
+	if offset >= len(results) || limit <= 0 {
+		return results[:0], nil
+	}
+	results = results[max(offset, 0):]
+	if limit < len(results) {
+		results = results[:limit]
+	}
+	return results, nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/shopspring/decimal"
+)
+
+// Field -
+type Field struct {
+	*Table[storage.Field, *storage.Field]
+}
+
+// newField -
+func newField(db *database) *Field {
+	return &Field{
+		Table: newTable[storage.Field, *storage.Field](db, func(data *tables) *rows[storage.Field] {
+			return &data.fields
+		}),
+	}
+}
+
+// Count -
+func (f *Field) Count(ctx context.Context) (count int, err error) {
+	f.db.read(func(data *tables) {
+		count = len(data.fields.items)
+	})
+	return
+}
+
+// ListByOwner -
+func (f *Field) ListByOwner(ctx context.Context, owner decimal.Decimal) (fields []storage.Field, err error) {
+	f.db.read(func(data *tables) {
+		fields = data.fields.filter(func(item storage.Field) bool {
+			return item.OwnerId.Equal(owner)
+		})
+	})
+	slices.SortStableFunc(fields, func(a, b storage.Field) int {
+		if c := cmp.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return
+}
+
+// ListStarknetIdsByValue - returns starknet ids which have verified field with the normalized value
+func (f *Field) ListStarknetIdsByValue(ctx context.Context, name, value string) (ids []decimal.Decimal, err error) {
+	ids = make([]decimal.Decimal, 0)
+	f.db.read(func(data *tables) {
+		for _, field := range data.fields.filter(func(item storage.Field) bool {
+			return item.Name == name && item.TextValue == value && item.Namespace == storage.FieldNamespaceVerifier
+		}) {
+			ids = append(ids, field.OwnerId)
+		}
+	})
+	slices.SortStableFunc(ids, func(a, b decimal.Decimal) int {
+		return a.Cmp(b)
+	})
+	return
+}
+
+// ListDecoded - returns names of well-known fields whose stored values were decoded
+func (f *Field) ListDecoded(ctx context.Context) (names []string, err error) {
+	names = make([]string, 0)
+	f.db.read(func(data *tables) {
+		for name := range data.decodedFields {
+			names = append(names, name)
+		}
+	})
+	slices.Sort(names)
+	return
+}
+
+// MarkDecoded -
+func (f *Field) MarkDecoded(ctx context.Context, names ...string) error {
+	return f.db.write(func(data *tables) error {
+		for i := range names {
+			data.decodedFields[names[i]] = struct{}{}
+		}
+		return nil
+	})
+}
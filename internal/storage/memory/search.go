@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// searchRank - returns rank of the domain for the lower-cased query and false if the domain doesn't match
+type searchRank func(domain, query string) (float64, bool)
+
+var searchRanks = map[storage.SearchMode]searchRank{
+	storage.SearchModePrefix:     prefixRank,
+	storage.SearchModeSubstring:  substringRank,
+	storage.SearchModeSimilarity: similarityRank,
+}
+
+// prefixRank - shorter domains are ranked higher: rank is the share of the domain matched by the query
+func prefixRank(domain, query string) (float64, bool) {
+	if !strings.HasPrefix(domain, query) {
+		return 0, false
+	}
+	return matchedShare(domain, query), true
+}
+
+// substringRank - prefix matches are ranked above other matches, then shorter domains are ranked higher
+func substringRank(domain, query string) (float64, bool) {
+	if !strings.Contains(domain, query) {
+		return 0, false
+	}
+	rank := matchedShare(domain, query)
+	if strings.HasPrefix(domain, query) {
+		rank += 1
+	}
+	return rank, true
+}
+
+func matchedShare(domain, query string) float64 {
+	return float64(utf8.RuneCountInString(query)) / float64(utf8.RuneCountInString(domain))
+}
+
+// similarityRank - trigram similarity as pg_trgm computes it
+func similarityRank(domain, query string) (float64, bool) {
+	rank := storage.Similarity(domain, query)
+	return rank, rank >= storage.SimilarityThreshold
+}
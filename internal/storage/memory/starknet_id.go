@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/shopspring/decimal"
+)
+
+// StarknetId -
+type StarknetId struct {
+	*Table[storage.StarknetId, *storage.StarknetId]
+}
+
+// newStarknetId -
+func newStarknetId(db *database) *StarknetId {
+	return &StarknetId{
+		Table: newTable[storage.StarknetId, *storage.StarknetId](db, func(data *tables) *rows[storage.StarknetId] {
+			return &data.starknetIds
+		}),
+	}
+}
+
+// Count -
+func (s *StarknetId) Count(ctx context.Context) (count int, err error) {
+	s.db.read(func(data *tables) {
+		count = len(data.starknetIds.items)
+	})
+	return
+}
+
+// GetByStarknetId -
+func (s *StarknetId) GetByStarknetId(ctx context.Context, starknetId decimal.Decimal) (result storage.StarknetId, err error) {
+	s.db.read(func(data *tables) {
+		var ok bool
+		result, ok = data.starknetIds.find(func(item storage.StarknetId) bool {
+			return item.StarknetId.Equal(starknetId)
+		})
+		if !ok {
+			err = sql.ErrNoRows
+		}
+	})
+	return
+}
+
+// ListByOwner -
+func (s *StarknetId) ListByOwner(ctx context.Context, address []byte) (result []storage.StarknetId, err error) {
+	s.db.read(func(data *tables) {
+		result = data.starknetIds.filter(func(item storage.StarknetId) bool {
+			return bytes.Equal(item.OwnerAddress, address)
+		})
+	})
+	return
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// State -
+type State struct {
+	*Table[storage.State, *storage.State]
+}
+
+// newState -
+func newState(db *database) *State {
+	return &State{
+		Table: newTable[storage.State, *storage.State](db, func(data *tables) *rows[storage.State] {
+			return &data.states
+		}),
+	}
+}
+
+// ByName -
+func (s *State) ByName(ctx context.Context, name string) (state storage.State, err error) {
+	s.db.read(func(data *tables) {
+		var ok bool
+		state, ok = data.states.find(func(item storage.State) bool {
+			return item.Name == name
+		})
+		if !ok {
+			err = sql.ErrNoRows
+		}
+	})
+	return
+}
@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// Subdomain -
+type Subdomain struct {
+	*Table[storage.Subdomain, *storage.Subdomain]
+}
+
+// newSubdomain -
+func newSubdomain(db *database) *Subdomain {
+	return &Subdomain{
+		Table: newTable[storage.Subdomain, *storage.Subdomain](db, func(data *tables) *rows[storage.Subdomain] {
+			return &data.subdomains
+		}),
+	}
+}
+
+// GetByResolverId -
+func (s *Subdomain) GetByResolverId(ctx context.Context, resolverId uint64) (result storage.Subdomain, err error) {
+	s.db.read(func(data *tables) {
+		var ok bool
+		result, ok = data.subdomains.find(func(item storage.Subdomain) bool {
+			return item.ResolverId == resolverId
+		})
+		if !ok {
+			err = sql.ErrNoRows
+		}
+	})
+	return
+}
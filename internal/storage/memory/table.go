@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+
+	"github.com/dipdup-net/indexer-sdk/pkg/storage"
+)
+
+// Table - in-memory realization of storage.Table. M is pointer to the model T.
+type Table[T any, M interface {
+	*T
+	storage.Model
+}] struct {
+	db   *database
+	rows func(data *tables) *rows[T]
+}
+
+func newTable[T any, M interface {
+	*T
+	storage.Model
+}](db *database, rows func(data *tables) *rows[T]) *Table[T, M] {
+	return &Table[T, M]{db, rows}
+}
+
+// GetByID -
+func (t *Table[T, M]) GetByID(ctx context.Context, id uint64) (m M, err error) {
+	t.db.read(func(data *tables) {
+		item, ok := t.rows(data).items[id]
+		if !ok {
+			err = sql.ErrNoRows
+			return
+		}
+		m = &item
+	})
+	return
+}
+
+// Save - inserts row to table and sets its id
+func (t *Table[T, M]) Save(ctx context.Context, m M) error {
+	return t.db.write(func(data *tables) error {
+		return t.rows(data).insert(m)
+	})
+}
+
+// Update - updates table row by primary key
+func (t *Table[T, M]) Update(ctx context.Context, m M) error {
+	return t.db.write(func(data *tables) error {
+		t.rows(data).update(*m)
+		return nil
+	})
+}
+
+// List - returns array of rows
+func (t *Table[T, M]) List(ctx context.Context, limit, offset uint64, order storage.SortOrder) ([]M, error) {
+	return t.list(0, limit, offset, order, storage.ComparatorEq), nil
+}
+
+// CursorList - returns array of rows by cursor pagination
+func (t *Table[T, M]) CursorList(ctx context.Context, id, limit uint64, order storage.SortOrder, cmp storage.Comparator) ([]M, error) {
+	return t.list(id, limit, 0, order, cmp), nil
+}
+
+// LastID - returns last used id
+func (t *Table[T, M]) LastID(ctx context.Context) (id uint64, err error) {
+	t.db.read(func(data *tables) {
+		ids := t.rows(data).ids()
+		if len(ids) == 0 {
+			err = sql.ErrNoRows
+			return
+		}
+		id = ids[len(ids)-1]
+	})
+	return
+}
+
+// IsNoRows -
+func (t *Table[T, M]) IsNoRows(err error) bool {
+	return IsNoRows(err)
+}
+
+func (t *Table[T, M]) list(cursor, limit, offset uint64, order storage.SortOrder, cmp storage.Comparator) []M {
+	if limit == 0 {
+		limit = 10
+	}
+
+	result := make([]M, 0)
+	t.db.read(func(data *tables) {
+		rows := t.rows(data)
+		ids := rows.ids()
+		if order == storage.SortOrderDesc {
+			slices.Reverse(ids)
+		}
+
+		var skipped uint64
+		for _, id := range ids {
+			if uint64(len(result)) == limit {
+				break
+			}
+			if cursor > 0 && !compare(id, cursor, cmp) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			item := rows.items[id]
+			result = append(result, &item)
+		}
+	})
+	return result
+}
+
+func compare(id, cursor uint64, cmp storage.Comparator) bool {
+	switch cmp {
+	case storage.ComparatorEq:
+		return id == cursor
+	case storage.ComparatorNeq:
+		return id != cursor
+	case storage.ComparatorLt:
+		return id < cursor
+	case storage.ComparatorLte:
+		return id <= cursor
+	case storage.ComparatorGt:
+		return id > cursor
+	case storage.ComparatorGte:
+		return id >= cursor
+	default:
+		return false
+	}
+}
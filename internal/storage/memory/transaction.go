@@ -0,0 +1,298 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+var errTxClosed = errors.New("transaction is closed")
+
+// Transaction - changes the copy of the data which replaces the storage data on flush
+type Transaction struct {
+	db   *database
+	data *tables
+}
+
+// Flush - commits changes
+func (t *Transaction) Flush(ctx context.Context) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	t.db.mx.Lock()
+	t.db.data = *t.data
+	t.db.mx.Unlock()
+
+	t.finish()
+	return nil
+}
+
+// HandleError - discards changes and wraps the error
+func (t *Transaction) HandleError(ctx context.Context, err error) error {
+	t.finish()
+	return errors.Wrap(err, "transaction error")
+}
+
+// Close - discards changes if transaction wasn't flushed
+func (t *Transaction) Close(ctx context.Context) error {
+	t.finish()
+	return nil
+}
+
+func (t *Transaction) finish() {
+	if t.data == nil {
+		return
+	}
+	t.data = nil
+	t.db.txMx.Unlock()
+}
+
+// SaveState -
+func (t *Transaction) SaveState(ctx context.Context, state *models.State) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	existing, ok := t.data.states.find(func(item models.State) bool {
+		return item.Name == state.Name
+	})
+	if !ok {
+		return t.data.states.insert(state)
+	}
+	existing.LastHeight = state.LastHeight
+	existing.LastTime = state.LastTime
+	existing.LastBlockTime = state.LastBlockTime
+	t.data.states.update(existing)
+	state.ID = existing.ID
+	return nil
+}
+
+// SaveAddress -
+func (t *Transaction) SaveAddress(ctx context.Context, addresses ...*models.Address) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	for _, address := range addresses {
+		existing, ok := t.data.addresses.items[address.Id]
+		if !ok {
+			if err := t.data.addresses.insert(address); err != nil {
+				return err
+			}
+			continue
+		}
+		existing.ClassId = address.ClassId
+		t.data.addresses.update(existing)
+	}
+	return nil
+}
+
+// SaveStarknetIds - mints replayed after reindex replace existing starknet ids with their minted state
+func (t *Transaction) SaveStarknetIds(ctx context.Context, starknetIds ...*models.StarknetId) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	for _, starknetId := range starknetIds {
+		existing, ok := t.findStarknetId(starknetId.StarknetId)
+		if !ok {
+			if err := t.data.starknetIds.insert(starknetId); err != nil {
+				return err
+			}
+			continue
+		}
+		existing.OwnerAddress = starknetId.OwnerAddress
+		existing.OwnerId = starknetId.OwnerId
+		existing.InftContract = starknetId.InftContract
+		existing.InftId = starknetId.InftId
+		t.data.starknetIds.update(existing)
+		starknetId.Id = existing.Id
+	}
+	return nil
+}
+
+// TransferStarknetId -
+func (t *Transaction) TransferStarknetId(ctx context.Context, starknetId *models.StarknetId) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	if existing, ok := t.findStarknetId(starknetId.StarknetId); ok {
+		existing.OwnerAddress = starknetId.OwnerAddress
+		existing.OwnerId = starknetId.OwnerId
+		t.data.starknetIds.update(existing)
+	}
+	return nil
+}
+
+// BurnStarknetIds -
+func (t *Transaction) BurnStarknetIds(ctx context.Context, starknetIds ...decimal.Decimal) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	t.data.starknetIds.remove(func(item models.StarknetId) bool {
+		for i := range starknetIds {
+			if item.StarknetId.Equal(starknetIds[i]) {
+				return true
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// EquipInft -
+func (t *Transaction) EquipInft(ctx context.Context, starknetId *models.StarknetId) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	if existing, ok := t.findStarknetId(starknetId.StarknetId); ok {
+		existing.InftContract = starknetId.InftContract
+		existing.InftId = starknetId.InftId
+		t.data.starknetIds.update(existing)
+	}
+	return nil
+}
+
+func (t *Transaction) findStarknetId(starknetId decimal.Decimal) (models.StarknetId, bool) {
+	return t.data.starknetIds.find(func(item models.StarknetId) bool {
+		return item.StarknetId.Equal(starknetId)
+	})
+}
+
+// SaveSubdomain -
+func (t *Transaction) SaveSubdomain(ctx context.Context, subdomain *models.Subdomain) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	existing, ok := t.data.subdomains.find(func(item models.Subdomain) bool {
+		return item.Subdomain == subdomain.Subdomain
+	})
+	if !ok {
+		inserted := *subdomain
+		inserted.Id = 0
+		return t.data.subdomains.insert(&inserted)
+	}
+	existing.RegistrationHeight = subdomain.RegistrationHeight
+	existing.RegistrationDate = subdomain.RegistrationDate
+	existing.ResolverId = subdomain.ResolverId
+	t.data.subdomains.update(existing)
+	return nil
+}
+
+// SaveDomain -
+func (t *Transaction) SaveDomain(ctx context.Context, domain *models.Domain) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	var (
+		timeIsZero    = domain.Expiry.IsZero()
+		addressIsNull = len(domain.AddressHash) == 0
+	)
+	if timeIsZero && addressIsNull {
+		return nil
+	}
+
+	existing, ok := t.findDomain(domain.Domain)
+	switch {
+	case !ok:
+		existing = *domain
+		existing.Id = 0
+		existing.EffectiveExpiry = domain.Expiry
+		if !models.HasExpiry(domain.Expiry) {
+			existing.EffectiveExpiry = t.ancestorExpiry(domain.Domain, domain.Expiry)
+		}
+		if err := t.data.domains.insert(&existing); err != nil {
+			return err
+		}
+	default:
+		if !addressIsNull {
+			existing.AddressId = domain.AddressId
+			existing.AddressHash = domain.AddressHash
+		}
+		if !timeIsZero {
+			existing.Owner = domain.Owner
+			existing.Expiry = domain.Expiry
+			existing.EffectiveExpiry = t.ancestorExpiry(domain.Domain, domain.Expiry)
+			if models.HasExpiry(domain.Expiry) {
+				existing.EffectiveExpiry = domain.Expiry
+			}
+		}
+		t.data.domains.update(existing)
+	}
+	if !models.HasExpiry(domain.Expiry) {
+		return nil
+	}
+
+	// descendants without own expiry and without nearer ancestor with expiry inherit the new one
+	for _, item := range t.data.domains.filter(func(item models.Domain) bool {
+		return strings.HasSuffix(item.Domain, "."+domain.Domain) && !models.HasExpiry(item.Expiry)
+	}) {
+		if t.ancestorExpiry(item.Domain, time.Time{}).Equal(domain.Expiry) {
+			item.EffectiveExpiry = domain.Expiry
+			t.data.domains.update(item)
+		}
+	}
+	return nil
+}
+
+// ancestorExpiry - returns expiry of the nearest ancestor with expiry or the fallback value if there is no such ancestor
+func (t *Transaction) ancestorExpiry(name string, fallback time.Time) time.Time {
+	for _, ancestor := range models.Ancestors(name) {
+		if item, ok := t.findDomain(ancestor); ok && models.HasExpiry(item.Expiry) {
+			return item.Expiry
+		}
+	}
+	return fallback
+}
+
+// TransferDomain -
+func (t *Transaction) TransferDomain(ctx context.Context, domain *models.Domain) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	if existing, ok := t.findDomain(domain.Domain); ok {
+		existing.Owner = domain.Owner
+		t.data.domains.update(existing)
+	}
+	return nil
+}
+
+// ClearDomainAddress -
+func (t *Transaction) ClearDomainAddress(ctx context.Context, domain string) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	if existing, ok := t.findDomain(domain); ok {
+		existing.AddressId = 0
+		existing.AddressHash = nil
+		t.data.domains.update(existing)
+	}
+	return nil
+}
+
+func (t *Transaction) findDomain(name string) (models.Domain, bool) {
+	return t.data.domains.find(func(item models.Domain) bool {
+		return item.Domain == name
+	})
+}
+
+// SaveField -
+func (t *Transaction) SaveField(ctx context.Context, field *models.Field) error {
+	if t.data == nil {
+		return errTxClosed
+	}
+	existing, ok := t.data.fields.find(func(item models.Field) bool {
+		return item.Namespace == field.Namespace && item.OwnerId.Equal(field.OwnerId) && item.Name == field.Name
+	})
+	if !ok {
+		inserted := *field
+		inserted.Id = 0
+		return t.data.fields.insert(&inserted)
+	}
+	existing.Value = field.Value
+	existing.TextValue = field.TextValue
+	existing.NumericValue = field.NumericValue
+	t.data.fields.update(existing)
+	return nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/storagetest"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/stretchr/testify/suite"
+)
+
+// ConformanceTestSuite - runs storage conformance tests against Postgres. Every test works in its own schema.
+type ConformanceTestSuite struct {
+	suite.Suite
+	psqlContainer *database.PostgreSQLContainer
+	schemas       int
+}
+
+// SetupSuite -
+func (s *ConformanceTestSuite) SetupSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	psqlContainer, err := database.NewPostgreSQLContainer(ctx, database.PostgreSQLContainerConfig{
+		User:     "user",
+		Password: "password",
+		Database: "db_test",
+		Port:     5432,
+		Image:    "postgres:15",
+	})
+	s.Require().NoError(err)
+	s.psqlContainer = psqlContainer
+}
+
+// TearDownSuite -
+func (s *ConformanceTestSuite) TearDownSuite() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	s.Require().NoError(s.psqlContainer.Terminate(ctx))
+}
+
+func TestSuiteConformance_Run(t *testing.T) {
+	suite.Run(t, new(ConformanceTestSuite))
+}
+
+func (s *ConformanceTestSuite) TestConformance() {
+	storagetest.Run(s.T(), func(t *testing.T) storage.Backend {
+		ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer ctxCancel()
+
+		s.schemas++
+		strg, err := Create(ctx, config.Database{
+			Kind:       config.DBKindPostgres,
+			User:       s.psqlContainer.Config.User,
+			Database:   s.psqlContainer.Config.Database,
+			Password:   s.psqlContainer.Config.Password,
+			Host:       s.psqlContainer.Config.Host,
+			Port:       s.psqlContainer.MappedPort().Int(),
+			SchemaName: fmt.Sprintf("conformance_%d", s.schemas),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = strg.Close()
+		})
+		return strg.Backend()
+	})
+}
@@ -2,13 +2,14 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 
 	models "github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-net/go-lib/config"
 	"github.com/dipdup-net/go-lib/database"
 	"github.com/dipdup-net/indexer-sdk/pkg/storage/postgres"
 	"github.com/pkg/errors"
-	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
 )
 
@@ -24,9 +25,44 @@ type Storage struct {
 	State       models.IState
 }
 
-// Create -
+// Create - connects to the database and applies pending migrations. Returns ErrSchemaAhead if the database was migrated by newer binary.
+// If schema name is set in config the schema is created before migrations.
 func Create(ctx context.Context, cfg config.Database) (Storage, error) {
-	strg, err := postgres.Create(ctx, cfg, initDatabase)
+	return create(ctx, cfg, func(ctx context.Context, conn *database.Bun) error {
+		return Migrate(ctx, conn, cfg.SchemaName)
+	})
+}
+
+// Migrate - creates the schema and applies pending migrations. It's used by replicas connected by Connect which became leaders.
+func Migrate(ctx context.Context, conn *database.Bun, schema string) error {
+	if err := CreateSchema(ctx, conn, schema); err != nil {
+		return err
+	}
+	return initDatabase(ctx, conn)
+}
+
+// Connect - connects to the database without migrations. Returns ErrSchemaAhead if the database was migrated by newer binary,
+// so replicas don't run against the schema they don't know.
+func Connect(ctx context.Context, cfg config.Database) (Storage, error) {
+	return create(ctx, cfg, func(ctx context.Context, conn *database.Bun) error {
+		migrator, err := NewMigrator(conn)
+		if err != nil {
+			return err
+		}
+		return migrator.Check(ctx)
+	})
+}
+
+// Open - connects to the database without migrations and schema check. It's used by migration commands which handle
+// schema versions themselves, e.g. status of the schema migrated by newer binary.
+func Open(ctx context.Context, cfg config.Database) (Storage, error) {
+	return create(ctx, cfg, nil)
+}
+
+func create(ctx context.Context, cfg config.Database, init postgres.Init) (Storage, error) {
+	cfg.Path = ConnectionString(cfg)
+
+	strg, err := postgres.Create(ctx, cfg, init)
 	if err != nil {
 		return Storage{}, err
 	}
@@ -44,75 +80,149 @@ func Create(ctx context.Context, cfg config.Database) (Storage, error) {
 	return s, nil
 }
 
+// BeginTransaction - opens transaction which saves indexed block
+func (s Storage) BeginTransaction(ctx context.Context) (models.Transaction, error) {
+	tx, err := BeginTransaction(ctx, s.Transactable)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Backend - returns tables and transactions of the storage
+func (s Storage) Backend() models.Backend {
+	return models.Backend{
+		Transactable: s,
+		Addresses:    s.Addresses,
+		Domains:      s.Domains,
+		Subdomains:   s.Subdomains,
+		StarknetIds:  s.StarknetIds,
+		Fields:       s.Fields,
+		State:        s.State,
+	}
+}
+
+// LeaderBackend - returns tables and transactions of the storage for the leader in HA mode. Transactions confirm that
+// the leader lock is held before commit.
+func (s Storage) LeaderBackend(lock *LeaderLock) models.Backend {
+	backend := s.Backend()
+	backend.Transactable = leaderTransactable{s, lock}
+	return backend
+}
+
+// Ping -
+func (s Storage) Ping(ctx context.Context) error {
+	return s.Connection().DB().PingContext(ctx)
+}
+
+// ConnectionString - returns DSN of the database. If schema name is set the schema is the first one in search path
+// of every connection, `public` is kept in search path for extensions.
+func ConnectionString(cfg config.Database) string {
+	if cfg.Path != "" || !isCustomSchema(cfg.SchemaName) {
+		return cfg.Path
+	}
+
+	params := url.Values{}
+	params.Set("sslmode", "disable")
+	params.Set("search_path", fmt.Sprintf("%s,public", cfg.SchemaName))
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:     cfg.Database,
+		RawQuery: params.Encode(),
+	}
+	return dsn.String()
+}
+
+func isCustomSchema(schema string) bool {
+	return schema != "" && schema != "public"
+}
+
+// CreateSchema - creates the schema if it is not public and installs pg_trgm to `public` schema, so search functions of every schema can use it
+func CreateSchema(ctx context.Context, conn *database.Bun, schema string) error {
+	if !isCustomSchema(schema) {
+		return nil
+	}
+	if _, err := conn.DB().NewRaw(`CREATE SCHEMA IF NOT EXISTS ?`, bun.Ident(schema)).Exec(ctx); err != nil {
+		return errors.Wrapf(err, "create schema %s", schema)
+	}
+	if _, err := conn.DB().NewRaw(`CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public`).Exec(ctx); err != nil {
+		return errors.Wrap(err, "create pg_trgm extension")
+	}
+	return nil
+}
+
 func initDatabase(ctx context.Context, conn *database.Bun) error {
+	migrator, err := NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(ctx); err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return errors.Wrap(err, "migrate")
+	}
+
 	data := make([]any, len(models.Models))
 	for i := range models.Models {
 		data[i] = models.Models[i]
 	}
 
-	if err := database.CreateTables(ctx, conn, data...); err != nil {
-		if err := conn.Close(); err != nil {
-			return err
-		}
-		return err
-	}
-
 	if err := database.MakeComments(ctx, conn, data...); err != nil {
 		return errors.Wrap(err, "make comments")
 	}
 
-	return createIndices(ctx, conn)
+	return nil
 }
 
-func createIndices(ctx context.Context, conn *database.Bun) error {
-	log.Info().Msg("creating indexes...")
-	return conn.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		// Address
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS address_hash_idx ON address (hash)`); err != nil {
-			return err
-		}
+// Rewind - rolls back channels to reindex them from the height. Derived tables are shared by channels, so ErrPartialRewind is returned
+// if other channels are indexed. Indexed data has no heights except subdomains, so if height is 0 all derived tables are truncated
+// and states are removed, otherwise subdomains registered since the height are removed and states are rewound to height-1.
+// Other data isn't rolled back: domains, fields and replayed mints are upserted by reindexing, so they are actual again
+// when channels reach their previous heights.
+func (s Storage) Rewind(ctx context.Context, channels []string, height uint64) error {
+	if len(channels) == 0 {
+		return nil
+	}
 
-		// Starknet id
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS starknet_identity_idx ON starknet_id (starknet_id)`); err != nil {
-			return err
-		}
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS starkner_id_owner_idx ON starknet_id (owner_address)`); err != nil {
-			return err
-		}
+	var others []string
+	if err := s.Connection().DB().NewSelect().
+		Model((*models.State)(nil)).
+		Column("name").
+		Where("name NOT IN (?)", bun.In(channels)).
+		Scan(ctx, &others); err != nil {
+		return err
+	}
+	if len(others) > 0 {
+		return errors.Wrapf(models.ErrPartialRewind, "channels %v are not rewound", others)
+	}
 
-		// Domain
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS domain_name_idx ON domain USING hash(domain)`); err != nil {
-			return err
-		}
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS domain_address_idx ON domain (address_hash)`); err != nil {
-			return err
-		}
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS domain_address_id_idx ON domain (address_id)`); err != nil {
-			return err
-		}
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS domain_owner_idx ON domain (owner)`); err != nil {
-			return err
-		}
+	tx, err := BeginTransaction(ctx, s.Transactable)
+	if err != nil {
+		return err
+	}
+	defer tx.Close(ctx)
 
-		// Subdomain
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS subdomain_name_idx ON subdomain USING hash(subdomain)`); err != nil {
-			return err
+	if height == 0 {
+		if _, err := tx.Exec(ctx, `TRUNCATE domain, starknet_id, field, subdomain, address RESTART IDENTITY`); err != nil {
+			return tx.HandleError(ctx, err)
 		}
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS subdomain_resolver_id_idx ON subdomain (resolver_id)`); err != nil {
-			return err
+		if _, err := tx.Exec(ctx, `DELETE FROM state WHERE name IN (?)`, bun.In(channels)); err != nil {
+			return tx.HandleError(ctx, err)
 		}
-
-		// Field
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS field_name_idx ON field USING hash(name)`); err != nil {
-			return err
-		}
-		if _, err := tx.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS field_starknet_id_idx ON field (owner_id)`); err != nil {
-			return err
+	} else {
+		if _, err := tx.Exec(ctx, `DELETE FROM subdomain WHERE registration_height >= ?`, height); err != nil {
+			return tx.HandleError(ctx, err)
 		}
-		if _, err := tx.ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS field_key_idx ON field (namespace,owner_id,name)`); err != nil {
-			return err
+		if _, err := tx.Exec(ctx, `UPDATE state SET last_height = ? WHERE name IN (?) AND last_height >= ?`, height-1, bun.In(channels), height); err != nil {
+			return tx.HandleError(ctx, err)
 		}
+	}
 
-		return nil
-	})
+	if err := tx.Flush(ctx); err != nil {
+		return tx.HandleError(ctx, err)
+	}
+	return nil
 }
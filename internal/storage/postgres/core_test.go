@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionString(t *testing.T) {
+	cfg := config.Database{
+		Kind:     config.DBKindPostgres,
+		Host:     "db",
+		Port:     5432,
+		User:     "dipdup",
+		Password: "p@ss",
+		Database: "starknet_id",
+	}
+
+	require.Empty(t, ConnectionString(cfg))
+
+	cfg.SchemaName = "public"
+	require.Empty(t, ConnectionString(cfg))
+
+	cfg.SchemaName = "sepolia"
+	dsn, err := url.Parse(ConnectionString(cfg))
+	require.NoError(t, err)
+	require.Equal(t, "db:5432", dsn.Host)
+	require.Equal(t, "/starknet_id", dsn.Path)
+	password, _ := dsn.User.Password()
+	require.Equal(t, "p@ss", password)
+	require.Equal(t, "sepolia,public", dsn.Query().Get("search_path"))
+	require.Equal(t, "disable", dsn.Query().Get("sslmode"))
+
+	cfg.Path = "postgres://localhost/test"
+	require.Equal(t, cfg.Path, ConnectionString(cfg))
+}
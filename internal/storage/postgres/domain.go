@@ -1,9 +1,14 @@
 package postgres
 
 import (
+	"context"
+
 	"github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-net/go-lib/database"
 	"github.com/dipdup-net/indexer-sdk/pkg/storage/postgres"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
 )
 
 // Domain -
@@ -17,3 +22,48 @@ func NewDomain(db *database.Bun) *Domain {
 		Table: postgres.NewTable[*storage.Domain](db),
 	}
 }
+
+// Count -
+func (d *Domain) Count(ctx context.Context) (int, error) {
+	return d.DB().NewSelect().Model((*storage.Domain)(nil)).Count(ctx)
+}
+
+// GetByName -
+func (d *Domain) GetByName(ctx context.Context, name string) (domain storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domain).Where("domain = ?", name).Limit(1).Scan(ctx)
+	return
+}
+
+// ListByOwner -
+func (d *Domain) ListByOwner(ctx context.Context, owner decimal.Decimal) (domains []storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domains).Where("owner = ?", owner.String()).Order("id asc").Scan(ctx)
+	return
+}
+
+// ListByAddress -
+func (d *Domain) ListByAddress(ctx context.Context, address []byte) (domains []storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domains).Where("address_hash = ?", address).Order("id asc").Scan(ctx)
+	return
+}
+
+// ListSubdomains - returns all domains which are under the parent domain on any level
+func (d *Domain) ListSubdomains(ctx context.Context, parent string) (domains []storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domains).
+		Where("reverse(domain) LIKE ?", descendantsPattern(parent)).
+		Order("domain asc").
+		Scan(ctx)
+	return
+}
+
+// Search - calls domain search function of the mode. Results are ranked by relevance.
+func (d *Domain) Search(ctx context.Context, mode storage.SearchMode, query string, includeExpired bool, limit, offset int) (results []storage.DomainSearchResult, err error) {
+	function, ok := SearchFunctions[mode]
+	if !ok {
+		return nil, errors.Errorf("unknown search mode: %s", mode)
+	}
+	err = d.DB().NewRaw(
+		"SELECT * FROM ?(?, ?, ?, ?)",
+		bun.Ident(function), query, includeExpired, limit, offset,
+	).Scan(ctx, &results)
+	return
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"strings"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike - escapes special characters of LIKE pattern
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
+
+// descendantsPattern - LIKE pattern of reversed descendants of the domain which uses `domain_reverse_idx`
+func descendantsPattern(domain string) string {
+	return escapeLike(models.Reverse(domain)+".") + "%"
+}
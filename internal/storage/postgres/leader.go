@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"strconv"
+	"sync"
+	"time"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// leaderLockKey - advisory lock key of the leader. Key depends on current schema, so every network has its own leader.
+const leaderLockKey = `hashtext(current_schema() || '.leader')`
+
+// ErrLeadershipLost - the leader lock isn't held by the session of the replica
+var ErrLeadershipLost = errors.New("leader lock is lost")
+
+// LeaderLock - session-level advisory lock which elects the only writer among replicas sharing the schema.
+// The lock is held by a dedicated connection, so Postgres releases it as soon as the leader's session is closed.
+type LeaderLock struct {
+	db        *bun.DB
+	keepAlive time.Duration
+
+	conn *bun.Conn
+	pid  int
+	mx   sync.Mutex
+}
+
+// NewLeaderLock - creates lock. If keepAlive is set, TCP keepalives of the lock session are sent with the interval,
+// so Postgres closes the session of unreachable leader in about two intervals.
+func NewLeaderLock(conn *database.Bun, keepAlive time.Duration) *LeaderLock {
+	return &LeaderLock{
+		db:        conn.DB(),
+		keepAlive: keepAlive,
+	}
+}
+
+// TryLock - tries to acquire the lock without waiting. Returns true if the lock is held by the session.
+func (l *LeaderLock) TryLock(ctx context.Context) (bool, error) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.conn == nil {
+		conn, err := l.connect(ctx)
+		if err != nil {
+			return false, err
+		}
+		l.conn = conn
+	}
+
+	var locked bool
+	if err := l.conn.NewRaw(`SELECT pg_try_advisory_lock(`+leaderLockKey+`), pg_backend_pid()`).Scan(ctx, &locked, &l.pid); err != nil {
+		l.close()
+		return false, errors.Wrap(err, "try advisory lock")
+	}
+	return locked, nil
+}
+
+// Confirm - returns ErrLeadershipLost if the lock isn't held by the session of the replica. It's executed inside write transactions,
+// so a replica which lost the lock doesn't write even if it hasn't noticed that yet.
+func (l *LeaderLock) Confirm(ctx context.Context, db bun.IDB) error {
+	l.mx.Lock()
+	pid := l.pid
+	l.mx.Unlock()
+
+	if pid == 0 {
+		return ErrLeadershipLost
+	}
+	// bigint key of advisory lock is split into `classid` and `objid`
+	var held bool
+	if err := db.NewRaw(`SELECT EXISTS (
+		SELECT FROM pg_locks
+		WHERE locktype = 'advisory' AND granted AND pid = ? AND objsubid = 1
+			AND ((classid::bigint << 32) | objid::bigint) = `+leaderLockKey+`
+	)`, pid).Scan(ctx, &held); err != nil {
+		return errors.Wrap(err, "confirm leadership")
+	}
+	if !held {
+		return ErrLeadershipLost
+	}
+	return nil
+}
+
+// Check - returns error if the session holding the lock is broken. The lock is lost in that case.
+func (l *LeaderLock) Check(ctx context.Context) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.conn == nil {
+		return errors.New("lock is not held")
+	}
+	if err := l.conn.PingContext(ctx); err != nil {
+		l.close()
+		return errors.Wrap(err, "leader session")
+	}
+	return nil
+}
+
+// Unlock - releases the lock and closes its session
+func (l *LeaderLock) Unlock(ctx context.Context) error {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+	defer l.close()
+
+	if _, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock(`+leaderLockKey+`)`); err != nil {
+		return errors.Wrap(err, "advisory unlock")
+	}
+	return nil
+}
+
+func (l *LeaderLock) connect(ctx context.Context) (*bun.Conn, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "leader session")
+	}
+
+	if seconds := int(l.keepAlive.Seconds()); seconds > 0 {
+		params := map[string]string{
+			"tcp_keepalives_idle":     strconv.Itoa(seconds),
+			"tcp_keepalives_interval": strconv.Itoa(seconds),
+			"tcp_keepalives_count":    "1",
+		}
+		for param, value := range params {
+			if _, err := conn.ExecContext(ctx, `SELECT set_config(?, ?, false)`, param, value); err != nil {
+				_ = conn.Close()
+				return nil, errors.Wrapf(err, "set %s", param)
+			}
+		}
+	}
+	return &conn, nil
+}
+
+func (l *LeaderLock) close() {
+	if l.conn == nil {
+		return
+	}
+	// the connection is not returned to the pool: closing the session releases the lock even if unlock failed
+	_ = l.conn.Raw(func(driverConn any) error {
+		return driver.ErrBadConn
+	})
+	_ = l.conn.Close()
+	l.conn = nil
+	l.pid = 0
+}
+
+// leaderTransactable - begins transactions which confirm leadership before commit
+type leaderTransactable struct {
+	storage Storage
+	lock    *LeaderLock
+}
+
+// BeginTransaction -
+func (t leaderTransactable) BeginTransaction(ctx context.Context) (models.Transaction, error) {
+	tx, err := BeginTransaction(ctx, t.storage.Transactable)
+	if err != nil {
+		return nil, err
+	}
+	return leaderTransaction{tx, t.lock}, nil
+}
+
+type leaderTransaction struct {
+	Transaction
+
+	lock *LeaderLock
+}
+
+// Flush - commits the transaction if the lock is still held, so the block is aborted after leadership was lost
+func (t leaderTransaction) Flush(ctx context.Context) error {
+	if err := t.lock.Confirm(ctx, t.Tx()); err != nil {
+		return err
+	}
+	return t.Transaction.Flush(ctx)
+}
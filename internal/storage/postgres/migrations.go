@@ -0,0 +1,327 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// ErrSchemaAhead - database schema was migrated by newer binary
+var ErrSchemaAhead = errors.New("database schema is ahead of the binary")
+
+// Migration - forward and backward SQL scripts of one schema version. Files are named `{version}_{name}.up.sql` and `{version}_{name}.down.sql`.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaVersion - applied migration
+type SchemaVersion struct {
+	bun.BaseModel `bun:"schema_version"`
+
+	Version   int       `bun:"version,pk"`
+	Name      string    `bun:"name,notnull"`
+	AppliedAt time.Time `bun:"applied_at,notnull"`
+}
+
+// MigrationStatus -
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LoadMigrations - reads migrations from file system. Versions have to start from 1 without gaps and every migration has to have both scripts.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for i := range entries {
+		if entries[i].IsDir() {
+			continue
+		}
+		filename := entries[i].Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(filename, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(filename, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(filename, "."+direction+".sql")
+		rawVersion, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, errors.Errorf("invalid migration file name: %s", filename)
+		}
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil || version < 1 {
+			return nil, errors.Errorf("invalid migration version: %s", filename)
+		}
+
+		raw, err := fs.ReadFile(fsys, path.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, errors.Errorf("migration %d has different names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(raw)
+		} else {
+			migration.Down = string(raw)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, errors.Errorf("migration %d has no up or down script", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := range migrations {
+		if migrations[i].Version != i+1 {
+			return nil, errors.Errorf("migration %d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrator - applies embedded migrations and tracks schema version in `schema_version` table
+type Migrator struct {
+	db         *bun.DB
+	migrations []Migration
+}
+
+// NewMigrator - creates migrator with migrations embedded into the binary
+func NewMigrator(conn *database.Bun) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationsFS, "migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "load migrations")
+	}
+	return &Migrator{
+		db:         conn.DB(),
+		migrations: migrations,
+	}, nil
+}
+
+// Latest - returns the latest version known by the binary
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.db.NewCreateTable().Model((*SchemaVersion)(nil)).IfNotExists().Exec(ctx)
+	return err
+}
+
+// Current - returns the latest applied version. 0 means no migrations were applied. Version table isn't created,
+// so replicas can check the schema before it's created by the leader.
+func (m *Migrator) Current(ctx context.Context) (int, error) {
+	var exists bool
+	if err := m.db.NewRaw(`SELECT to_regclass('schema_version') IS NOT NULL`).Scan(ctx, &exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+	var version int
+	err := m.db.NewSelect().Model((*SchemaVersion)(nil)).ColumnExpr("COALESCE(MAX(version), 0)").Scan(ctx, &version)
+	return version, err
+}
+
+// Check - returns ErrSchemaAhead if database has migrations unknown to the binary
+func (m *Migrator) Check(ctx context.Context) error {
+	current, err := m.Current(ctx)
+	if err != nil {
+		return err
+	}
+	if current > m.Latest() {
+		return errors.Wrapf(ErrSchemaAhead, "database version %d, binary version %d", current, m.Latest())
+	}
+	return nil
+}
+
+// Status - returns all known migrations with their state
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	var applied []SchemaVersion
+	if err := m.db.NewSelect().Model(&applied).Order("version asc").Scan(ctx); err != nil {
+		return nil, err
+	}
+	appliedByVersion := make(map[int]SchemaVersion, len(applied))
+	for i := range applied {
+		appliedByVersion[applied[i].Version] = applied[i]
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+	for i := range m.migrations {
+		s := MigrationStatus{
+			Version: m.migrations[i].Version,
+			Name:    m.migrations[i].Name,
+		}
+		if version, ok := appliedByVersion[s.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = version.AppliedAt
+			delete(appliedByVersion, s.Version)
+		}
+		status = append(status, s)
+	}
+	// versions applied by newer binary
+	for _, version := range appliedByVersion {
+		status = append(status, MigrationStatus{
+			Version:   version.Version,
+			Name:      version.Name,
+			Applied:   true,
+			AppliedAt: version.AppliedAt,
+		})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Version < status[j].Version
+	})
+	return status, nil
+}
+
+// Up - applies all pending migrations. Every migration is applied in its own transaction. Returns count of applied migrations.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if err := m.init(ctx); err != nil {
+		return 0, err
+	}
+	if err := m.Check(ctx); err != nil {
+		return 0, err
+	}
+	current, err := m.Current(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for i := range m.migrations {
+		migration := m.migrations[i]
+		if migration.Version <= current {
+			continue
+		}
+
+		var applied bool
+		if err := m.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			exists, err := lockVersion(ctx, tx, migration.Version)
+			if err != nil || exists {
+				return err
+			}
+			applied = true
+
+			log.Info().Int("version", migration.Version).Str("name", migration.Name).Msg("applying migration...")
+			// scripts are executed as is to avoid placeholder formatting
+			if _, err := tx.Tx.ExecContext(ctx, migration.Up); err != nil {
+				return err
+			}
+			_, err = tx.NewInsert().Model(&SchemaVersion{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Exec(ctx)
+			return err
+		}); err != nil {
+			return count, errors.Wrapf(err, "migration %s", migration.fullName())
+		}
+		if applied {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DownTo - reverts migrations in descending order until schema has the version. Returns count of reverted migrations.
+func (m *Migrator) DownTo(ctx context.Context, version int) (int, error) {
+	if version < 0 {
+		return 0, errors.Errorf("invalid target version: %d", version)
+	}
+	if err := m.Check(ctx); err != nil {
+		return 0, err
+	}
+	current, err := m.Current(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= version {
+			continue
+		}
+
+		var reverted bool
+		if err := m.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			exists, err := lockVersion(ctx, tx, migration.Version)
+			if err != nil || !exists {
+				return err
+			}
+			reverted = true
+
+			log.Info().Int("version", migration.Version).Str("name", migration.Name).Msg("reverting migration...")
+			if _, err := tx.Tx.ExecContext(ctx, migration.Down); err != nil {
+				return err
+			}
+			_, err = tx.NewDelete().Model((*SchemaVersion)(nil)).Where("version = ?", migration.Version).Exec(ctx)
+			return err
+		}); err != nil {
+			return count, errors.Wrapf(err, "migration %s", migration.fullName())
+		}
+		if reverted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// lockVersion - waits until concurrent migrators of the schema finish their transactions and returns true if the version is applied.
+// Replicas started at the same time apply every migration once.
+func lockVersion(ctx context.Context, tx bun.Tx, version int) (bool, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext(current_schema() || '.schema_version'))`); err != nil {
+		return false, errors.Wrap(err, "lock schema version")
+	}
+	return tx.NewSelect().Model((*SchemaVersion)(nil)).Where("version = ?", version).Exists(ctx)
+}
+
+func (m Migration) fullName() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
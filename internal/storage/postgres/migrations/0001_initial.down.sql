@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS field CASCADE;
+DROP TABLE IF EXISTS subdomain CASCADE;
+DROP TABLE IF EXISTS domain CASCADE;
+DROP TABLE IF EXISTS starknet_id CASCADE;
+DROP TABLE IF EXISTS address CASCADE;
+DROP TABLE IF EXISTS state CASCADE;
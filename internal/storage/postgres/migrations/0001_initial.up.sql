@@ -0,0 +1,20 @@
+-- Schema which was created by `CreateTables` before versioned migrations. Statements are idempotent to adopt existing databases.
+CREATE TABLE IF NOT EXISTS "state" ("id" BIGSERIAL NOT NULL, "name" VARCHAR, "last_height" BIGINT, "last_time" TIMESTAMPTZ, PRIMARY KEY ("id"), CONSTRAINT "state_name" UNIQUE ("name"));
+CREATE TABLE IF NOT EXISTS "address" ("id" BIGINT NOT NULL, "hash" BYTEA, "height" BIGINT, "class_id" BIGINT, PRIMARY KEY ("id"));
+CREATE TABLE IF NOT EXISTS "starknet_id" ("id" BIGSERIAL NOT NULL, "starknet_id" NUMERIC, "owner_address" BYTEA, "owner_id" BIGINT, PRIMARY KEY ("id"), UNIQUE ("starknet_id"));
+CREATE TABLE IF NOT EXISTS "domain" ("id" BIGSERIAL NOT NULL, "address_id" BIGINT, "address_hash" BYTEA, "domain" VARCHAR, "owner" NUMERIC, "expiry" TIMESTAMPTZ, PRIMARY KEY ("id"), UNIQUE ("domain"));
+CREATE TABLE IF NOT EXISTS "subdomain" ("id" BIGSERIAL NOT NULL, "registration_height" BIGINT, "registration_date" TIMESTAMPTZ, "resolver_id" BIGINT, "subdomain" VARCHAR, PRIMARY KEY ("id"), UNIQUE ("subdomain"));
+CREATE TABLE IF NOT EXISTS "field" ("id" BIGSERIAL NOT NULL, "owner_id" NUMERIC, "namespace" SMALLINT, "name" VARCHAR, "value" BYTEA, PRIMARY KEY ("id"));
+
+CREATE INDEX IF NOT EXISTS address_hash_idx ON address (hash);
+CREATE INDEX IF NOT EXISTS starknet_identity_idx ON starknet_id (starknet_id);
+CREATE INDEX IF NOT EXISTS starkner_id_owner_idx ON starknet_id (owner_address);
+CREATE INDEX IF NOT EXISTS domain_name_idx ON domain USING hash(domain);
+CREATE INDEX IF NOT EXISTS domain_address_idx ON domain (address_hash);
+CREATE INDEX IF NOT EXISTS domain_address_id_idx ON domain (address_id);
+CREATE INDEX IF NOT EXISTS domain_owner_idx ON domain (owner);
+CREATE INDEX IF NOT EXISTS subdomain_name_idx ON subdomain USING hash(subdomain);
+CREATE INDEX IF NOT EXISTS subdomain_resolver_id_idx ON subdomain (resolver_id);
+CREATE INDEX IF NOT EXISTS field_name_idx ON field USING hash(name);
+CREATE INDEX IF NOT EXISTS field_starknet_id_idx ON field (owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS field_key_idx ON field (namespace,owner_id,name);
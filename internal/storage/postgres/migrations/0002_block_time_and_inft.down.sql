@@ -0,0 +1,3 @@
+ALTER TABLE starknet_id DROP COLUMN IF EXISTS inft_id CASCADE;
+ALTER TABLE starknet_id DROP COLUMN IF EXISTS inft_contract CASCADE;
+ALTER TABLE state DROP COLUMN IF EXISTS last_block_time CASCADE;
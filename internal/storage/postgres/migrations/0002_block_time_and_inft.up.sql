@@ -0,0 +1,3 @@
+ALTER TABLE state ADD COLUMN IF NOT EXISTS last_block_time TIMESTAMPTZ;
+ALTER TABLE starknet_id ADD COLUMN IF NOT EXISTS inft_contract BYTEA;
+ALTER TABLE starknet_id ADD COLUMN IF NOT EXISTS inft_id NUMERIC;
@@ -0,0 +1,6 @@
+DROP FUNCTION IF EXISTS search_domains_by_similarity(text, boolean, integer, integer);
+DROP FUNCTION IF EXISTS search_domains_by_substring(text, boolean, integer, integer);
+DROP FUNCTION IF EXISTS search_domains_by_prefix(text, boolean, integer, integer);
+DROP VIEW IF EXISTS domain_search_result;
+DROP INDEX IF EXISTS domain_name_trgm_idx;
+DROP INDEX IF EXISTS domain_name_prefix_idx;
@@ -0,0 +1,69 @@
+-- Domain search: prefix and trigram indexes, functions returning `domain_search_result` rows ranked by relevance.
+-- All functions take the same arguments: search query, flag to include expired domains, limit and offset.
+-- LIKE patterns are escaped and the query is lower-cased because domains are stored in lower case.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS domain_name_prefix_idx ON domain (domain text_pattern_ops);
+CREATE INDEX IF NOT EXISTS domain_name_trgm_idx ON domain USING gin (domain gin_trgm_ops);
+
+CREATE OR REPLACE VIEW domain_search_result AS
+SELECT
+    domain.id,
+    domain.address_hash AS address,
+    domain.domain,
+    domain.expiry,
+    domain.owner AS starknet_id,
+    0::real AS rank
+FROM domain
+WHERE false;
+
+-- shorter domains are ranked higher: rank is the share of the domain matched by the query
+CREATE OR REPLACE FUNCTION search_domains_by_prefix(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        (length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+-- prefix matches are ranked above other matches, then shorter domains are ranked higher
+CREATE OR REPLACE FUNCTION search_domains_by_substring(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        ((CASE WHEN starts_with(d.domain, lower(query)) THEN 1 ELSE 0 END) + length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE '%' || replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+-- uses pg_trgm similarity threshold (pg_trgm.similarity_threshold, 0.3 by default)
+CREATE OR REPLACE FUNCTION search_domains_by_similarity(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        similarity(d.domain, lower(query))::real AS rank
+    FROM domain d
+    WHERE d.domain % lower(query)
+        AND (include_expired OR d.expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS field_value_idx;
+ALTER TABLE field DROP COLUMN IF EXISTS numeric_value CASCADE;
+ALTER TABLE field DROP COLUMN IF EXISTS text_value CASCADE;
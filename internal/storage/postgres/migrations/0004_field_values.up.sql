@@ -0,0 +1,5 @@
+-- Normalized values of well-known verifier fields
+ALTER TABLE field ADD COLUMN IF NOT EXISTS text_value VARCHAR;
+ALTER TABLE field ADD COLUMN IF NOT EXISTS numeric_value NUMERIC;
+
+CREATE INDEX IF NOT EXISTS field_value_idx ON field (name,text_value) WHERE text_value IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Hashes of view definitions. A view is recreated when its definition changes.
+CREATE TABLE IF NOT EXISTS view_version ("name" VARCHAR NOT NULL, "hash" VARCHAR NOT NULL, "updated_at" TIMESTAMPTZ NOT NULL, PRIMARY KEY ("name"));
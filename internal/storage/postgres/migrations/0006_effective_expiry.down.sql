@@ -0,0 +1,55 @@
+-- search functions filtered by own expiry as before 0006
+-- shorter domains are ranked higher: rank is the share of the domain matched by the query
+CREATE OR REPLACE FUNCTION search_domains_by_prefix(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        (length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+-- prefix matches are ranked above other matches, then shorter domains are ranked higher
+CREATE OR REPLACE FUNCTION search_domains_by_substring(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        ((CASE WHEN starts_with(d.domain, lower(query)) THEN 1 ELSE 0 END) + length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE '%' || replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+-- uses pg_trgm similarity threshold (pg_trgm.similarity_threshold, 0.3 by default)
+CREATE OR REPLACE FUNCTION search_domains_by_similarity(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        similarity(d.domain, lower(query))::real AS rank
+    FROM domain d
+    WHERE d.domain % lower(query)
+        AND (include_expired OR d.expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+DROP INDEX IF EXISTS domain_effective_expiry_idx;
+-- actual_domains view depends on the column, it is recreated on start by the previous version
+ALTER TABLE domain DROP COLUMN IF EXISTS effective_expiry CASCADE;
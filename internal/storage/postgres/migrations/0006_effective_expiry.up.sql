@@ -0,0 +1,70 @@
+-- Effective expiry: expiration time of the domain or of its nearest ancestor with expiration time.
+-- Subdomains don't receive `starknet_id_update` events, so they expire together with their parents.
+ALTER TABLE domain ADD COLUMN IF NOT EXISTS effective_expiry TIMESTAMPTZ;
+
+UPDATE domain SET effective_expiry = expiry;
+
+-- ancestors are split off the name as its suffixes and joined by equality which uses the unique index of domains
+WITH RECURSIVE ancestor (id, domain) AS (
+    SELECT id, substr(domain, strpos(domain, '.') + 1) FROM domain
+    WHERE strpos(domain, '.') > 0 AND (expiry IS NULL OR expiry <= to_timestamp(0))
+    UNION ALL
+    SELECT id, substr(domain, strpos(domain, '.') + 1) FROM ancestor WHERE strpos(domain, '.') > 0
+)
+UPDATE domain AS d SET effective_expiry = nearest.expiry FROM (
+    SELECT DISTINCT ON (a.id) a.id, p.expiry
+    FROM ancestor a JOIN domain p ON p.domain = a.domain
+    WHERE p.expiry > to_timestamp(0)
+    ORDER BY a.id, length(p.domain) DESC
+) AS nearest
+WHERE nearest.id = d.id;
+
+CREATE INDEX IF NOT EXISTS domain_effective_expiry_idx ON domain (effective_expiry);
+
+CREATE OR REPLACE FUNCTION search_domains_by_prefix(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        (length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_domains_by_substring(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        ((CASE WHEN starts_with(d.domain, lower(query)) THEN 1 ELSE 0 END) + length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE '%' || replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_domains_by_similarity(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        similarity(d.domain, lower(query))::real AS rank
+    FROM domain d
+    WHERE d.domain % lower(query)
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
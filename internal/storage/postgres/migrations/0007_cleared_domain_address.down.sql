@@ -0,0 +1,3 @@
+-- Deliberately a no-op: zero addresses aren't restored. Cleared domains can't be told apart from domains
+-- which never had an address, and domains without address don't resolve in both versions.
+SELECT 1;
@@ -0,0 +1,2 @@
+-- Zero address set by `domain_to_addr_update` removes the record. It was stored as is before.
+UPDATE domain SET address_id = 0, address_hash = NULL WHERE address_hash = decode(repeat('00', 32), 'hex');
@@ -0,0 +1,48 @@
+-- search functions as of 0006: domains cleared by zero address are found
+CREATE OR REPLACE FUNCTION search_domains_by_prefix(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        (length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_domains_by_substring(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        ((CASE WHEN starts_with(d.domain, lower(query)) THEN 1 ELSE 0 END) + length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE '%' || replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_domains_by_similarity(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        similarity(d.domain, lower(query))::real AS rank
+    FROM domain d
+    WHERE d.domain % lower(query)
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,51 @@
+-- Domains cleared by zero address don't resolve, so search functions skip them as `actual_domains` view does.
+CREATE OR REPLACE FUNCTION search_domains_by_prefix(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        (length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND d.address_hash IS NOT NULL
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_domains_by_substring(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        ((CASE WHEN starts_with(d.domain, lower(query)) THEN 1 ELSE 0 END) + length(lower(query))::real / length(d.domain))::real AS rank
+    FROM domain d
+    WHERE d.domain LIKE '%' || replace(replace(replace(lower(query), '\', '\\'), '%', '\%'), '_', '\_') || '%'
+        AND d.address_hash IS NOT NULL
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION search_domains_by_similarity(query text, include_expired boolean DEFAULT false, result_limit integer DEFAULT 10, result_offset integer DEFAULT 0)
+RETURNS SETOF domain_search_result AS $$
+    SELECT
+        d.id,
+        d.address_hash AS address,
+        d.domain,
+        d.expiry,
+        d.owner AS starknet_id,
+        similarity(d.domain, lower(query))::real AS rank
+    FROM domain d
+    WHERE d.domain % lower(query)
+        AND d.address_hash IS NOT NULL
+        AND (include_expired OR d.effective_expiry > current_timestamp)
+    ORDER BY rank DESC, d.domain ASC
+    LIMIT result_limit OFFSET result_offset
+$$ LANGUAGE sql STABLE;
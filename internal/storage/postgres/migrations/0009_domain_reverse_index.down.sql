@@ -0,0 +1 @@
+DROP INDEX IF EXISTS domain_reverse_idx;
@@ -0,0 +1,2 @@
+-- Descendants of a domain are found by prefix of reversed domain: `LIKE '%.' || parent` can't use an index.
+CREATE INDEX IF NOT EXISTS domain_reverse_idx ON domain (reverse(domain) text_pattern_ops);
@@ -0,0 +1,3 @@
+-- Names of well-known fields whose stored values were decoded. Values indexed before decoder of the field was added
+-- are decoded by the indexer once instead of scanning all fields on every start.
+CREATE TABLE IF NOT EXISTS decoded_field ("name" VARCHAR NOT NULL, PRIMARY KEY ("name"));
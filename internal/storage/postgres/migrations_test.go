@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := LoadMigrations(migrationsFS, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i := range migrations {
+		require.Equal(t, i+1, migrations[i].Version)
+		require.NotEmpty(t, migrations[i].Name)
+		require.NotEmpty(t, migrations[i].Up)
+		require.NotEmpty(t, migrations[i].Down)
+	}
+	require.Equal(t, "initial", migrations[0].Name)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		fs      fstest.MapFS
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "sorted by version",
+			fs: fstest.MapFS{
+				"m/0002_second.up.sql":   {Data: []byte("SELECT 2")},
+				"m/0002_second.down.sql": {Data: []byte("SELECT -2")},
+				"m/0001_first.up.sql":    {Data: []byte("SELECT 1")},
+				"m/0001_first.down.sql":  {Data: []byte("SELECT -1")},
+				"m/README.md":            {Data: []byte("skipped")},
+			},
+			want: []int{1, 2},
+		}, {
+			name: "missing down script",
+			fs: fstest.MapFS{
+				"m/0001_first.up.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: true,
+		}, {
+			name: "gap in versions",
+			fs: fstest.MapFS{
+				"m/0001_first.up.sql":   {Data: []byte("SELECT 1")},
+				"m/0001_first.down.sql": {Data: []byte("SELECT -1")},
+				"m/0003_third.up.sql":   {Data: []byte("SELECT 3")},
+				"m/0003_third.down.sql": {Data: []byte("SELECT -3")},
+			},
+			wantErr: true,
+		}, {
+			name: "invalid version",
+			fs: fstest.MapFS{
+				"m/first.up.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: true,
+		}, {
+			name: "different names",
+			fs: fstest.MapFS{
+				"m/0001_first.up.sql":   {Data: []byte("SELECT 1")},
+				"m/0001_other.down.sql": {Data: []byte("SELECT -1")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := LoadMigrations(tt.fs, "m")
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			versions := make([]int, len(migrations))
+			for i := range migrations {
+				versions[i] = migrations[i].Version
+			}
+			require.Equal(t, tt.want, versions)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+// SearchResultView - name of the view which is used as return type of search functions
+const SearchResultView = "domain_search_result"
+
+// SearchFunctions - names of domain search functions by mode. Functions are created by migrations.
+var SearchFunctions = map[storage.SearchMode]string{
+	storage.SearchModePrefix:     "search_domains_by_prefix",
+	storage.SearchModeSubstring:  "search_domains_by_substring",
+	storage.SearchModeSimilarity: "search_domains_by_similarity",
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+
+	"github.com/dipdup-io/starknet-id/internal/snapshot"
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+const dumpBatchSize = 1000
+
+// ReadSnapshot - calls fn inside read-only repeatable read transaction, so all tables are dumped at the same moment
+func (s Storage) ReadSnapshot(ctx context.Context, fn func(tx snapshot.ReadTx) error) error {
+	tx, err := s.Connection().DB().BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return fn(snapshotTx{tx})
+}
+
+// RestoreSnapshot - calls fn inside transaction which is committed only if fn succeeds. Sequences of serial identities are moved after restored rows.
+func (s Storage) RestoreSnapshot(ctx context.Context, fn func(tx snapshot.WriteTx) error) error {
+	tx, err := s.Connection().DB().BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(snapshotTx{tx}); err != nil {
+		return err
+	}
+
+	for _, model := range models.Models {
+		if _, err := tx.NewRaw(
+			`SELECT setval(pg_get_serial_sequence(?, 'id'), COALESCE(MAX(id), 0) + 1, false) FROM ?`,
+			model.TableName(), bun.Ident(model.TableName()),
+		).Exec(ctx); err != nil {
+			return errors.Wrapf(err, "reset sequence of %s", model.TableName())
+		}
+	}
+	return tx.Commit()
+}
+
+type snapshotTx struct {
+	tx bun.Tx
+}
+
+// SchemaVersion - returns the latest applied migration. 0 means the database was not migrated.
+func (t snapshotTx) SchemaVersion(ctx context.Context) (int, error) {
+	var exists bool
+	if err := t.tx.NewRaw(`SELECT to_regclass('schema_version') IS NOT NULL`).Scan(ctx, &exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+	var version int
+	err := t.tx.NewSelect().Model((*SchemaVersion)(nil)).ColumnExpr("COALESCE(MAX(version), 0)").Scan(ctx, &version)
+	return version, err
+}
+
+// Dump - reads table of the model by batches ordered by primary key
+func (t snapshotTx) Dump(ctx context.Context, model storage.Model, fn func(row storage.Model) error) error {
+	typ := reflect.TypeOf(model).Elem()
+	table := t.tx.Dialect().Tables().Get(typ)
+	if len(table.PKs) != 1 {
+		return errors.Errorf("table %s has to have one primary key", model.TableName())
+	}
+	pk := table.PKs[0]
+
+	var lastId uint64
+	for {
+		rows := reflect.New(reflect.SliceOf(reflect.PointerTo(typ)))
+		if err := t.tx.NewSelect().
+			Model(rows.Interface()).
+			Where("? > ?", bun.Ident(pk.Name), lastId).
+			OrderExpr("? ASC", bun.Ident(pk.Name)).
+			Limit(dumpBatchSize).
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		slice := rows.Elem()
+		for i := 0; i < slice.Len(); i++ {
+			if err := fn(slice.Index(i).Interface().(storage.Model)); err != nil {
+				return err
+			}
+		}
+		if slice.Len() < dumpBatchSize {
+			return nil
+		}
+		lastId = pk.Value(slice.Index(slice.Len() - 1).Elem()).Uint()
+	}
+}
+
+// Truncate - removes all rows of models' tables
+func (t snapshotTx) Truncate(ctx context.Context, models ...storage.Model) error {
+	for _, model := range models {
+		if _, err := t.tx.NewTruncateTable().Model(model).Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert - inserts rows of one model
+func (t snapshotTx) Insert(ctx context.Context, rows []storage.Model) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	slice := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(rows[0])), 0, len(rows))
+	for i := range rows {
+		slice = reflect.Append(slice, reflect.ValueOf(rows[i]))
+	}
+	ptr := reflect.New(slice.Type())
+	ptr.Elem().Set(slice)
+
+	_, err := t.tx.NewInsert().Model(ptr.Interface()).Exec(ctx)
+	return err
+}
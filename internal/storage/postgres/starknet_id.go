@@ -1,9 +1,12 @@
 package postgres
 
 import (
+	"context"
+
 	"github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-net/go-lib/database"
 	"github.com/dipdup-net/indexer-sdk/pkg/storage/postgres"
+	"github.com/shopspring/decimal"
 )
 
 // StarknetId -
@@ -17,3 +20,20 @@ func NewStarknetId(db *database.Bun) *StarknetId {
 		Table: postgres.NewTable[*storage.StarknetId](db),
 	}
 }
+
+// Count -
+func (s *StarknetId) Count(ctx context.Context) (int, error) {
+	return s.DB().NewSelect().Model((*storage.StarknetId)(nil)).Count(ctx)
+}
+
+// GetByStarknetId -
+func (s *StarknetId) GetByStarknetId(ctx context.Context, starknetId decimal.Decimal) (result storage.StarknetId, err error) {
+	err = s.DB().NewSelect().Model(&result).Where("starknet_id = ?", starknetId.String()).Limit(1).Scan(ctx)
+	return
+}
+
+// ListByOwner -
+func (s *StarknetId) ListByOwner(ctx context.Context, address []byte) (result []storage.StarknetId, err error) {
+	err = s.DB().NewSelect().Model(&result).Where("owner_address = ?", address).Order("id asc").Scan(ctx)
+	return
+}
@@ -1,16 +1,20 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/hex"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/dipdup-io/starknet-id/internal/snapshot"
 	"github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-net/go-lib/config"
 	"github.com/dipdup-net/go-lib/database"
 	"github.com/go-testfixtures/testfixtures/v3"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -19,6 +23,7 @@ type StorageTestSuite struct {
 	suite.Suite
 	psqlContainer *database.PostgreSQLContainer
 	storage       Storage
+	config        config.Database
 }
 
 // SetupSuite -
@@ -36,14 +41,15 @@ func (s *StorageTestSuite) SetupSuite() {
 	s.Require().NoError(err)
 	s.psqlContainer = psqlContainer
 
-	storage, err := Create(ctx, config.Database{
+	s.config = config.Database{
 		Kind:     config.DBKindPostgres,
 		User:     s.psqlContainer.Config.User,
 		Database: s.psqlContainer.Config.Database,
 		Password: s.psqlContainer.Config.Password,
 		Host:     s.psqlContainer.Config.Host,
 		Port:     s.psqlContainer.MappedPort().Int(),
-	})
+	}
+	storage, err := Create(ctx, s.config)
 	s.Require().NoError(err)
 	s.storage = storage
 
@@ -116,6 +122,290 @@ func (s *StorageTestSuite) TestGetByResolverId() {
 	s.Require().Error(err)
 }
 
+func (s *StorageTestSuite) TestCreateViews() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	views := []View{
+		{Name: "test_domains", Definition: "CREATE OR REPLACE VIEW test_domains AS SELECT id, domain FROM domain"},
+		{Name: "test_states", Definition: "CREATE OR REPLACE VIEW test_states AS SELECT name FROM state"},
+	}
+
+	changed, err := CreateViews(ctx, s.storage.Connection(), views)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"test_domains", "test_states"}, changed)
+
+	changed, err = CreateViews(ctx, s.storage.Connection(), views)
+	s.Require().NoError(err)
+	s.Require().Empty(changed)
+
+	// columns are changed: CREATE OR REPLACE fails without dropping
+	views[0].Definition = "CREATE OR REPLACE VIEW test_domains AS SELECT domain, expiry FROM domain"
+	changed, err = CreateViews(ctx, s.storage.Connection(), views)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"test_domains"}, changed)
+
+	var columns []string
+	err = s.storage.Connection().DB().NewSelect().
+		Table("information_schema.columns").
+		Column("column_name").
+		Where("table_name = ?", "test_domains").
+		Order("ordinal_position asc").
+		Scan(ctx, &columns)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"domain", "expiry"}, columns)
+
+	// view is dropped outside
+	_, err = s.storage.Connection().DB().ExecContext(ctx, "DROP VIEW test_states")
+	s.Require().NoError(err)
+	changed, err = CreateViews(ctx, s.storage.Connection(), views)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"test_states"}, changed)
+
+	_, err = s.storage.Connection().DB().ExecContext(ctx, "DROP VIEW test_domains, test_states")
+	s.Require().NoError(err)
+	_, err = s.storage.Connection().DB().NewDelete().Model((*ViewVersion)(nil)).Where("name LIKE 'test_%'").Exec(ctx)
+	s.Require().NoError(err)
+}
+
+func (s *StorageTestSuite) TestDomainGetByName() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	domain, err := s.storage.Domains.GetByName(ctx, "fricoben.stark")
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, domain.Id)
+	s.Require().EqualValues(16, domain.AddressId)
+	s.Require().Equal("1", domain.Owner.String())
+
+	_, err = s.storage.Domains.GetByName(ctx, "unknown.stark")
+	s.Require().Error(err)
+	s.Require().True(s.storage.Domains.IsNoRows(err))
+}
+
+func (s *StorageTestSuite) TestDomainListSubdomains() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	domains, err := s.storage.Domains.ListSubdomains(ctx, "fricoben.stark")
+	s.Require().NoError(err)
+	s.Require().Len(domains, 1)
+	s.Require().Equal("deployer.fricoben.stark", domains[0].Domain)
+
+	domains, err = s.storage.Domains.ListSubdomains(ctx, "expired.stark")
+	s.Require().NoError(err)
+	s.Require().Len(domains, 0)
+}
+
+func (s *StorageTestSuite) TestDomainListByOwner() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	domains, err := s.storage.Domains.ListByOwner(ctx, decimal.NewFromInt(1))
+	s.Require().NoError(err)
+	s.Require().Len(domains, 1)
+	s.Require().Equal("fricoben.stark", domains[0].Domain)
+}
+
+func (s *StorageTestSuite) TestDomainCount() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	count, err := s.storage.Domains.Count(ctx)
+	s.Require().NoError(err)
+	s.Require().EqualValues(6, count)
+}
+
+func (s *StorageTestSuite) TestDomainSearch() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	tests := []struct {
+		name           string
+		mode           storage.SearchMode
+		query          string
+		includeExpired bool
+		limit          int
+		offset         int
+		want           []string
+	}{
+		{
+			name:  "prefix: shorter domains first",
+			mode:  storage.SearchModePrefix,
+			query: "fri",
+			limit: 10,
+			want:  []string{"fri.stark", "frico.stark", "fricoben.stark"},
+		}, {
+			name:  "prefix: case insensitive",
+			mode:  storage.SearchModePrefix,
+			query: "FRICO",
+			limit: 10,
+			want:  []string{"frico.stark", "fricoben.stark"},
+		}, {
+			name:   "prefix: pagination",
+			mode:   storage.SearchModePrefix,
+			query:  "fri",
+			limit:  1,
+			offset: 1,
+			want:   []string{"frico.stark"},
+		}, {
+			name:  "prefix: like wildcards are escaped",
+			mode:  storage.SearchModePrefix,
+			query: "fri_",
+			limit: 10,
+			want:  []string{},
+		}, {
+			name:  "substring: prefix matches first",
+			mode:  storage.SearchModeSubstring,
+			query: "frico",
+			limit: 10,
+			want:  []string{"frico.stark", "fricoben.stark", "alfricoben.stark", "deployer.fricoben.stark"},
+		}, {
+			name:           "substring: with expired",
+			mode:           storage.SearchModeSubstring,
+			query:          "frico",
+			includeExpired: true,
+			limit:          10,
+			want:           []string{"frico.stark", "fricoben.stark", "alfricoben.stark", "deployer.fricoben.stark"},
+		}, {
+			name:  "substring: expired are skipped by default",
+			mode:  storage.SearchModeSubstring,
+			query: "expired",
+			limit: 10,
+			want:  []string{},
+		}, {
+			name:  "similarity: the most similar first",
+			mode:  storage.SearchModeSimilarity,
+			query: "fricoben",
+			limit: 2,
+			want:  []string{"fricoben.stark", "alfricoben.stark"},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			results, err := s.storage.Domains.Search(ctx, tt.mode, tt.query, tt.includeExpired, tt.limit, tt.offset)
+			s.Require().NoError(err)
+
+			domains := make([]string, len(results))
+			for i := range results {
+				domains[i] = results[i].Domain
+				if i > 0 {
+					s.Require().LessOrEqual(results[i].Rank, results[i-1].Rank)
+				}
+			}
+			s.Require().Equal(tt.want, domains)
+		})
+	}
+
+	_, err := s.storage.Domains.Search(ctx, storage.SearchMode("unknown"), "fri", false, 10, 0)
+	s.Require().Error(err)
+}
+
+func (s *StorageTestSuite) TestFieldListStarknetIdsByValue() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer ctxCancel()
+
+	ids, err := s.storage.Fields.ListStarknetIdsByValue(ctx, "twitter", "256")
+	s.Require().NoError(err)
+	s.Require().Len(ids, 2)
+	s.Require().Equal("1", ids[0].String())
+	s.Require().Equal("2", ids[1].String())
+
+	ids, err = s.storage.Fields.ListStarknetIdsByValue(ctx, "proof_of_personhood", "true")
+	s.Require().NoError(err)
+	s.Require().Len(ids, 1)
+	s.Require().Equal("3", ids[0].String())
+
+	ids, err = s.storage.Fields.ListStarknetIdsByValue(ctx, "github", "256")
+	s.Require().NoError(err)
+	s.Require().Len(ids, 0)
+}
+
+func (s *StorageTestSuite) TestMigrator() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	migrator, err := NewMigrator(s.storage.Connection())
+	s.Require().NoError(err)
+
+	current, err := migrator.Current(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(migrator.Latest(), current)
+	s.Require().NoError(migrator.Check(ctx))
+
+	status, err := migrator.Status(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(status, migrator.Latest())
+	for i := range status {
+		s.Require().True(status[i].Applied)
+	}
+
+	count, err := migrator.DownTo(ctx, migrator.Latest()-1)
+	s.Require().NoError(err)
+	s.Require().Equal(1, count)
+
+	current, err = migrator.Current(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(migrator.Latest()-1, current)
+
+	count, err = migrator.Up(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(1, count)
+
+	_, err = s.storage.Connection().DB().NewInsert().Model(&SchemaVersion{
+		Version:   migrator.Latest() + 1,
+		Name:      "future",
+		AppliedAt: time.Now(),
+	}).Exec(ctx)
+	s.Require().NoError(err)
+
+	s.Require().ErrorIs(migrator.Check(ctx), ErrSchemaAhead)
+	_, err = migrator.Up(ctx)
+	s.Require().ErrorIs(err, ErrSchemaAhead)
+	_, err = Connect(ctx, s.config)
+	s.Require().ErrorIs(err, ErrSchemaAhead, "replica doesn't run against newer schema")
+
+	_, err = s.storage.Connection().DB().NewDelete().Model((*SchemaVersion)(nil)).Where("version > ?", migrator.Latest()).Exec(ctx)
+	s.Require().NoError(err)
+}
+
+func (s *StorageTestSuite) TestSnapshotRoundTrip() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer ctxCancel()
+
+	counts := make(map[string]int)
+	for _, model := range storage.Models {
+		count, err := s.storage.Connection().DB().NewSelect().Model(model).Count(ctx)
+		s.Require().NoError(err)
+		counts[model.TableName()] = count
+	}
+
+	var archive bytes.Buffer
+	manifest, err := snapshot.Export(ctx, s.storage, storage.Models, &archive)
+	s.Require().NoError(err)
+	s.Require().Positive(manifest.SchemaVersion)
+	for _, table := range manifest.Tables {
+		s.Require().EqualValues(counts[table.Name], table.Rows, table.Name)
+	}
+
+	broken := bytes.NewReader(archive.Bytes()[:archive.Len()/2])
+	_, err = snapshot.Import(ctx, s.storage, storage.Models, broken)
+	s.Require().Error(err)
+
+	_, err = snapshot.Import(ctx, s.storage, storage.Models, &archive)
+	s.Require().NoError(err)
+
+	for _, model := range storage.Models {
+		count, err := s.storage.Connection().DB().NewSelect().Model(model).Count(ctx)
+		s.Require().NoError(err)
+		s.Require().Equal(counts[model.TableName()], count, model.TableName())
+	}
+
+	domain, err := s.storage.Domains.GetByName(ctx, "fricoben.stark")
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, domain.Id)
+}
+
 func (s *StorageTestSuite) TestTxSaveState() {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer ctxCancel()
@@ -124,10 +414,12 @@ func (s *StorageTestSuite) TestTxSaveState() {
 	s.Require().NoError(err)
 	defer tx.Close(ctx)
 
+	blockTime := time.Date(2023, 8, 14, 10, 20, 0, 0, time.UTC)
 	err = tx.SaveState(ctx, &storage.State{
-		Name:       "test",
-		LastHeight: 101,
-		LastTime:   time.Now(),
+		Name:          "test",
+		LastHeight:    101,
+		LastTime:      time.Now(),
+		LastBlockTime: blockTime,
 	})
 	s.Require().NoError(err)
 
@@ -139,6 +431,7 @@ func (s *StorageTestSuite) TestTxSaveState() {
 	s.Require().EqualValues(1, response.ID)
 	s.Require().EqualValues("test", response.Name)
 	s.Require().EqualValues(101, response.LastHeight)
+	s.Require().True(blockTime.Equal(response.LastBlockTime))
 }
 
 func (s *StorageTestSuite) TestTxSaveAddress() {
@@ -207,3 +500,140 @@ func (s *StorageTestSuite) TestTxSaveAddressUpdate() {
 func TestSuiteStorage_Run(t *testing.T) {
 	suite.Run(t, new(StorageTestSuite))
 }
+
+func (s *StorageTestSuite) TestSchemaIsolation() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	strg, err := Create(ctx, config.Database{
+		Kind:       config.DBKindPostgres,
+		User:       s.psqlContainer.Config.User,
+		Database:   s.psqlContainer.Config.Database,
+		Password:   s.psqlContainer.Config.Password,
+		Host:       s.psqlContainer.Config.Host,
+		Port:       s.psqlContainer.MappedPort().Int(),
+		SchemaName: "sepolia",
+	})
+	s.Require().NoError(err)
+	defer strg.Close()
+
+	_, err = strg.State.ByName(ctx, "test")
+	s.Require().Error(err)
+	s.Require().True(strg.State.IsNoRows(err))
+
+	var schema string
+	s.Require().NoError(strg.Connection().DB().NewRaw(`SELECT current_schema()`).Scan(ctx, &schema))
+	s.Require().Equal("sepolia", schema)
+
+	results, err := strg.Domains.Search(ctx, storage.SearchModeSimilarity, "fricoben", false, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Empty(results)
+
+	state, err := s.storage.State.ByName(ctx, "test")
+	s.Require().NoError(err)
+	s.Require().Equal("test", state.Name)
+}
+
+func (s *StorageTestSuite) TestLeaderLock() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	cfg := config.Database{
+		Kind:     config.DBKindPostgres,
+		User:     s.psqlContainer.Config.User,
+		Database: s.psqlContainer.Config.Database,
+		Password: s.psqlContainer.Config.Password,
+		Host:     s.psqlContainer.Config.Host,
+		Port:     s.psqlContainer.MappedPort().Int(),
+	}
+	leaderStorage, err := Connect(ctx, cfg)
+	s.Require().NoError(err)
+	defer leaderStorage.Close()
+
+	followerStorage, err := Connect(ctx, cfg)
+	s.Require().NoError(err)
+	defer followerStorage.Close()
+
+	leader := NewLeaderLock(leaderStorage.Connection(), time.Second)
+	follower := NewLeaderLock(followerStorage.Connection(), time.Second)
+
+	locked, err := leader.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().True(locked)
+	s.Require().NoError(leader.Check(ctx))
+	s.Require().NoError(leader.Confirm(ctx, leaderStorage.Connection().DB()))
+
+	locked, err = follower.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().False(locked)
+	s.Require().ErrorIs(follower.Confirm(ctx, followerStorage.Connection().DB()), ErrLeadershipLost)
+
+	// the leader which hasn't noticed lost lock can't commit blocks
+	tx, err := leaderStorage.LeaderBackend(leader).BeginTransaction(ctx)
+	s.Require().NoError(err)
+	defer tx.Close(ctx)
+	s.Require().NoError(tx.SaveState(ctx, &storage.State{Name: "leader_test", LastHeight: 1}))
+
+	// leader crash: its session is terminated by the server
+	_, err = s.storage.Connection().DB().ExecContext(ctx, `
+		SELECT pg_terminate_backend(pid) FROM pg_locks
+		WHERE locktype = 'advisory' AND granted AND pid <> pg_backend_pid()`)
+	s.Require().NoError(err)
+
+	s.Require().Eventually(func() bool {
+		locked, err := follower.TryLock(ctx)
+		return err == nil && locked
+	}, 10*time.Second, 100*time.Millisecond)
+	s.Require().ErrorIs(tx.Flush(ctx), ErrLeadershipLost)
+	s.Require().NoError(follower.Confirm(ctx, followerStorage.Connection().DB()))
+	s.Require().Error(leader.Check(ctx))
+
+	locked, err = leader.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().False(locked)
+
+	s.Require().NoError(follower.Unlock(ctx))
+	locked, err = leader.TryLock(ctx)
+	s.Require().NoError(err)
+	s.Require().True(locked)
+	s.Require().NoError(leader.Unlock(ctx))
+}
+
+func (s *StorageTestSuite) TestMigratorConcurrentUp() {
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer ctxCancel()
+
+	migrator, err := NewMigrator(s.storage.Connection())
+	s.Require().NoError(err)
+
+	_, err = migrator.DownTo(ctx, migrator.Latest()-1)
+	s.Require().NoError(err)
+
+	var (
+		wg    sync.WaitGroup
+		mx    sync.Mutex
+		total int
+		errs  []error
+	)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := migrator.Up(ctx)
+
+			mx.Lock()
+			total += count
+			errs = append(errs, err)
+			mx.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for i := range errs {
+		s.Require().NoError(errs[i])
+	}
+	s.Require().Equal(1, total)
+	current, err := migrator.Current(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal(migrator.Latest(), current)
+}
@@ -2,9 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 
 	models "github.com/dipdup-io/starknet-id/internal/storage"
 	"github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
 )
 
 // Transaction -
@@ -24,6 +28,7 @@ func (t Transaction) SaveState(ctx context.Context, state *models.State) error {
 		On("CONFLICT (name) DO UPDATE").
 		Set("last_height = excluded.last_height").
 		Set("last_time = excluded.last_time").
+		Set("last_block_time = excluded.last_block_time").
 		Exec(ctx)
 	return err
 }
@@ -38,3 +43,146 @@ func (t Transaction) SaveAddress(ctx context.Context, addresses ...*models.Addre
 		Exec(ctx)
 	return err
 }
+
+// SaveStarknetIds - mints replayed after reindex replace existing starknet ids with their minted state
+func (t Transaction) SaveStarknetIds(ctx context.Context, starknetIds ...*models.StarknetId) error {
+	if len(starknetIds) == 0 {
+		return nil
+	}
+	_, err := t.Tx().NewInsert().Model(&starknetIds).
+		On("CONFLICT (starknet_id) DO UPDATE").
+		Set("owner_address = excluded.owner_address").
+		Set("owner_id = excluded.owner_id").
+		Set("inft_contract = excluded.inft_contract").
+		Set("inft_id = excluded.inft_id").
+		Returning("id").
+		Exec(ctx)
+	return err
+}
+
+// TransferStarknetId -
+func (t Transaction) TransferStarknetId(ctx context.Context, starknetId *models.StarknetId) error {
+	_, err := t.Exec(ctx,
+		`UPDATE starknet_id SET owner_address = ?, owner_id = ? WHERE starknet_id = ?`,
+		starknetId.OwnerAddress, starknetId.OwnerId, starknetId.StarknetId.String())
+	return err
+}
+
+// BurnStarknetIds -
+func (t Transaction) BurnStarknetIds(ctx context.Context, starknetIds ...decimal.Decimal) error {
+	if len(starknetIds) == 0 {
+		return nil
+	}
+	burned := make([]string, len(starknetIds))
+	for i := range starknetIds {
+		burned[i] = starknetIds[i].String()
+	}
+	_, err := t.Exec(ctx, `DELETE FROM starknet_id WHERE starknet_id IN (?)`, bun.In(burned))
+	return err
+}
+
+// EquipInft -
+func (t Transaction) EquipInft(ctx context.Context, starknetId *models.StarknetId) error {
+	_, err := t.Exec(ctx,
+		`UPDATE starknet_id SET inft_contract = ?, inft_id = ? WHERE starknet_id = ?`,
+		starknetId.InftContract, starknetId.InftId, starknetId.StarknetId.String())
+	return err
+}
+
+// SaveSubdomain -
+func (t Transaction) SaveSubdomain(ctx context.Context, subdomain *models.Subdomain) error {
+	_, err := t.Exec(ctx, `INSERT INTO subdomain (registration_height, registration_date, resolver_id, subdomain)
+		VALUES (?,?,?,?)
+		ON CONFLICT (subdomain)
+		DO 
+		UPDATE SET registration_height = excluded.registration_height, registration_date = excluded.registration_date, resolver_id = excluded.resolver_id`,
+		subdomain.RegistrationHeight, subdomain.RegistrationDate, subdomain.ResolverId, subdomain.Subdomain,
+	)
+	return err
+}
+
+// SaveDomain -
+func (t Transaction) SaveDomain(ctx context.Context, domain *models.Domain) error {
+	var (
+		query         string
+		timeIsZero    = domain.Expiry.IsZero()
+		addressIsNull = len(domain.AddressHash) == 0
+	)
+
+	switch {
+	case !timeIsZero && !addressIsNull:
+		query = `INSERT INTO domain (address_id, address_hash, domain, owner, expiry, effective_expiry)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (domain)
+		DO 
+		UPDATE SET address_id = excluded.address_id, address_hash = excluded.address_hash, owner = excluded.owner, expiry = excluded.expiry, effective_expiry = excluded.effective_expiry`
+	case timeIsZero && !addressIsNull:
+		query = `INSERT INTO domain (address_id, address_hash, domain, owner, expiry, effective_expiry)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (domain)
+		DO 
+		UPDATE SET address_id = excluded.address_id, address_hash = excluded.address_hash`
+	case !timeIsZero && addressIsNull:
+		query = `INSERT INTO domain (address_id, address_hash, domain, owner, expiry, effective_expiry)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (domain)
+		DO 
+		UPDATE SET owner = excluded.owner, expiry = excluded.expiry, effective_expiry = excluded.effective_expiry`
+	default:
+		return nil
+	}
+
+	var (
+		hasExpiry           = models.HasExpiry(domain.Expiry)
+		effectiveExpiry any = domain.Expiry
+	)
+	if ancestors := models.Ancestors(domain.Domain); !hasExpiry && len(ancestors) > 0 {
+		effectiveExpiry = schema.SafeQuery(`COALESCE((
+			SELECT expiry FROM domain WHERE domain IN (?) AND expiry > to_timestamp(0) ORDER BY length(domain) DESC LIMIT 1
+		), ?)`, []any{bun.In(ancestors), domain.Expiry})
+	}
+
+	if _, err := t.Exec(ctx, query,
+		domain.AddressId, domain.AddressHash, domain.Domain, domain.Owner.String(), domain.Expiry, effectiveExpiry,
+	); err != nil {
+		return err
+	}
+	if !hasExpiry {
+		return nil
+	}
+
+	// descendants without own expiry and without nearer ancestor with expiry inherit the new one
+	_, err := t.Exec(ctx, `UPDATE domain AS d SET effective_expiry = ?
+		WHERE reverse(d.domain) LIKE ? AND (d.expiry IS NULL OR d.expiry <= to_timestamp(0))
+		AND NOT EXISTS (
+			SELECT FROM domain AS m
+			WHERE reverse(m.domain) LIKE ? AND m.expiry > to_timestamp(0) AND right(d.domain, length(m.domain) + 1) = '.' || m.domain
+		)`,
+		domain.Expiry, descendantsPattern(domain.Domain), descendantsPattern(domain.Domain),
+	)
+	return err
+}
+
+// TransferDomain -
+func (t Transaction) TransferDomain(ctx context.Context, domain *models.Domain) error {
+	_, err := t.Exec(ctx, `UPDATE domain SET owner = ? WHERE domain = ?`, domain.Owner, domain.Domain)
+	return err
+}
+
+// ClearDomainAddress -
+func (t Transaction) ClearDomainAddress(ctx context.Context, domain string) error {
+	_, err := t.Exec(ctx, `UPDATE domain SET address_id = 0, address_hash = NULL WHERE domain = ?`, domain)
+	return err
+}
+
+// SaveField -
+func (t Transaction) SaveField(ctx context.Context, field *models.Field) error {
+	_, err := t.Exec(ctx, `INSERT INTO field (owner_id, name, namespace, value, text_value, numeric_value)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (namespace,owner_id,name)
+		DO 
+		UPDATE SET value = excluded.value, text_value = excluded.text_value, numeric_value = excluded.numeric_value`,
+		field.OwnerId.String(), field.Name, field.Namespace, field.Value, sql.NullString{String: field.TextValue, Valid: field.TextValue != ""}, field.NumericValue,
+	)
+	return err
+}
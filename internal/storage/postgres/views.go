@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/dipdup-net/go-lib/database"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+// View - definition of SQL view
+type View struct {
+	Name       string
+	Definition string
+}
+
+// Hash - returns hash of view definition
+func (v View) Hash() string {
+	hash := sha256.Sum256([]byte(v.Definition))
+	return hex.EncodeToString(hash[:])
+}
+
+// ViewVersion - hash of applied view definition
+type ViewVersion struct {
+	bun.BaseModel `bun:"view_version"`
+
+	Name      string    `bun:"name,pk"`
+	Hash      string    `bun:"hash,notnull"`
+	UpdatedAt time.Time `bun:"updated_at,notnull"`
+}
+
+// CreateViews - creates views which don't exist and recreates views which definitions were changed. Changed views are dropped with dependent objects.
+// Returns names of created and recreated views.
+func CreateViews(ctx context.Context, conn *database.Bun, views []View) ([]string, error) {
+	var versions []ViewVersion
+	if err := conn.DB().NewSelect().Model(&versions).Scan(ctx); err != nil {
+		return nil, errors.Wrap(err, "receiving view versions")
+	}
+	hashes := make(map[string]string, len(versions))
+	for i := range versions {
+		hashes[versions[i].Name] = versions[i].Hash
+	}
+
+	changed := make([]string, 0)
+	err := conn.DB().RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		outdated := make(map[string]struct{})
+		for i := range views {
+			if hash, ok := hashes[views[i].Name]; ok && hash == views[i].Hash() {
+				continue
+			}
+			log.Info().Str("view", views[i].Name).Msg("view definition was changed, dropping...")
+			if _, err := tx.ExecContext(ctx, "DROP VIEW IF EXISTS ? CASCADE", bun.Ident(views[i].Name)); err != nil {
+				return errors.Wrapf(err, "drop view %s", views[i].Name)
+			}
+			outdated[views[i].Name] = struct{}{}
+		}
+
+		// views can be dropped as dependent objects or outside of the indexer, so existence is checked after dropping
+		for i := range views {
+			exists, err := tx.NewSelect().
+				Table("information_schema.views").
+				Where("table_schema = current_schema()").
+				Where("table_name = ?", views[i].Name).
+				Exists(ctx)
+			if err != nil {
+				return errors.Wrapf(err, "check view %s", views[i].Name)
+			}
+			if _, ok := outdated[views[i].Name]; !ok && exists {
+				continue
+			}
+
+			// definition is executed as is to avoid placeholder formatting
+			if _, err := tx.Tx.ExecContext(ctx, views[i].Definition); err != nil {
+				return errors.Wrapf(err, "create view %s", views[i].Name)
+			}
+			if _, err := tx.NewInsert().Model(&ViewVersion{
+				Name:      views[i].Name,
+				Hash:      views[i].Hash(),
+				UpdatedAt: time.Now().UTC(),
+			}).
+				On("CONFLICT (name) DO UPDATE").
+				Set("hash = excluded.hash").
+				Set("updated_at = excluded.updated_at").
+				Exec(ctx); err != nil {
+				return errors.Wrapf(err, "save view version %s", views[i].Name)
+			}
+			changed = append(changed, views[i].Name)
+		}
+		return nil
+	})
+	return changed, err
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SimilarityThreshold - default value of pg_trgm.similarity_threshold. Domains less similar to the query aren't found by similarity search.
+const SimilarityThreshold = 0.3
+
+// Similarity - trigram similarity as pg_trgm computes it: share of common trigrams in all trigrams of both strings.
+// It's used by storage backends which have no pg_trgm.
+func Similarity(a, b string) float64 {
+	left, right := trigrams(a), trigrams(b)
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	var common int
+	for trigram := range left {
+		if _, ok := right[trigram]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(left)+len(right)-common)
+}
+
+// trigrams - returns set of trigrams of the words in the string. Every word is padded by two spaces in the beginning
+// and one space in the end. Non-alphanumeric characters split words.
+func trigrams(s string) map[string]struct{} {
+	result := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+	return result
+}
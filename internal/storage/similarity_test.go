@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"fricoben.stark", "fricoben", 0.6},
+		{"fricoben", "fricoben", 1},
+		{"word", "WORD", 1},
+		{"fri.stark", "fricoben", 3.0 / 16},
+		{"", "fricoben", 0},
+		{"...", "...", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			require.InDelta(t, tt.want, Similarity(tt.a, tt.b), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+// Address -
+type Address struct {
+	*Table[storage.Address, *storage.Address]
+}
+
+// NewAddress -
+func NewAddress(db *bun.DB) *Address {
+	return &Address{
+		Table: NewTable[storage.Address, *storage.Address](db),
+	}
+}
+
+// GetByHash -
+func (a *Address) GetByHash(ctx context.Context, hash []byte) (address storage.Address, err error) {
+	err = a.DB().NewSelect().Model(&address).Where("hash = ?", hash).Limit(1).Scan(ctx)
+	return
+}
@@ -0,0 +1,240 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	_ "embed"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+	"modernc.org/sqlite"
+)
+
+//go:embed schema.sql
+var schema string
+
+func init() {
+	// pg_trgm replacement which is used by similarity search
+	if err := sqlite.RegisterDeterministicScalarFunction("similarity", 2, similarity); err != nil {
+		panic(err)
+	}
+	// `reverse` of Postgres: reversed domains are indexed to find descendants
+	if err := sqlite.RegisterDeterministicScalarFunction("reverse", 1, reverse); err != nil {
+		panic(err)
+	}
+}
+
+func reverse(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, nil
+	}
+	return models.Reverse(s), nil
+}
+
+func similarity(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	a, ok := args[0].(string)
+	if !ok {
+		return nil, nil
+	}
+	b, ok := args[1].(string)
+	if !ok {
+		return nil, nil
+	}
+	return models.Similarity(a, b), nil
+}
+
+// Storage - SQLite storage backend for lightweight deployments which don't need Postgres and Hasura
+type Storage struct {
+	db *bun.DB
+
+	Addresses   models.IAddress
+	Domains     models.IDomain
+	Subdomains  models.ISubdomain
+	StarknetIds models.IStarknetId
+	Fields      models.IField
+	State       models.IState
+}
+
+// Create - opens the database file and creates tables, indexes and views which don't exist. Views are recreated on every start.
+func Create(ctx context.Context, cfg config.Database) (Storage, error) {
+	path, err := Path(cfg)
+	if err != nil {
+		return Storage{}, err
+	}
+
+	sqldb, err := sql.Open("sqlite", dsn(path))
+	if err != nil {
+		return Storage{}, errors.Wrap(err, "open database")
+	}
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+
+	if err := upgrade(ctx, db); err != nil {
+		db.Close()
+		return Storage{}, errors.Wrap(err, "upgrade schema")
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return Storage{}, errors.Wrap(err, "create schema")
+	}
+
+	return Storage{
+		db:          db,
+		State:       NewState(db),
+		Addresses:   NewAddress(db),
+		StarknetIds: NewStarknetId(db),
+		Domains:     NewDomain(db),
+		Subdomains:  NewSubdomain(db),
+		Fields:      NewField(db),
+	}, nil
+}
+
+// upgrade - adds columns which were introduced after the database file had been created. `CREATE TABLE IF NOT EXISTS` doesn't change existing tables.
+func upgrade(ctx context.Context, db *bun.DB) error {
+	var count int
+	if err := db.NewRaw(`SELECT count(*) FROM pragma_table_info('domain')`).Scan(ctx, &count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+	if err := db.NewRaw(`SELECT count(*) FROM pragma_table_info('domain') WHERE name = 'effective_expiry'`).Scan(ctx, &count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, upgradeEffectiveExpiry)
+	return err
+}
+
+// upgradeEffectiveExpiry - the same as `0006_effective_expiry` migration of Postgres. Ancestors are split off the name
+// as its suffixes and joined by equality which uses the unique index of domains.
+const upgradeEffectiveExpiry = `ALTER TABLE domain ADD COLUMN "effective_expiry" TIMESTAMP;
+UPDATE domain SET effective_expiry = expiry;
+WITH RECURSIVE ancestor (id, domain) AS (
+    SELECT id, substr(domain, instr(domain, '.') + 1) FROM domain
+    WHERE instr(domain, '.') > 0 AND (expiry IS NULL OR unixepoch(expiry) <= 0)
+    UNION ALL
+    SELECT id, substr(domain, instr(domain, '.') + 1) FROM ancestor WHERE instr(domain, '.') > 0
+)
+UPDATE domain AS d SET effective_expiry = nearest.expiry FROM (
+    SELECT a.id, p.expiry, row_number() OVER (PARTITION BY a.id ORDER BY length(p.domain) DESC) AS n
+    FROM ancestor a JOIN domain p ON p.domain = a.domain
+    WHERE unixepoch(p.expiry) > 0
+) AS nearest
+WHERE nearest.id = d.id AND nearest.n = 1;`
+
+// Path - returns path of the database file. Schemas are stored in separate files: schema name is added to the file name
+// before extension, e.g. `starknet-id.sepolia.db`.
+func Path(cfg config.Database) (string, error) {
+	if cfg.Path == "" {
+		return "", errors.New("path of SQLite database is required")
+	}
+	if cfg.SchemaName == "" || cfg.SchemaName == "public" {
+		return cfg.Path, nil
+	}
+	ext := filepath.Ext(cfg.Path)
+	return strings.TrimSuffix(cfg.Path, ext) + "." + cfg.SchemaName + ext, nil
+}
+
+// dsn - readers don't block the writer in WAL mode and writers wait for each other instead of failing with SQLITE_BUSY
+func dsn(path string) string {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(10000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+	return "file:" + path + "?" + params.Encode()
+}
+
+// DB - returns connection to the database
+func (s Storage) DB() *bun.DB {
+	return s.db
+}
+
+// BeginTransaction - opens transaction which saves indexed block
+func (s Storage) BeginTransaction(ctx context.Context) (models.Transaction, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{tx: tx}, nil
+}
+
+// Backend - returns tables and transactions of the storage
+func (s Storage) Backend() models.Backend {
+	return models.Backend{
+		Transactable: s,
+		Addresses:    s.Addresses,
+		Domains:      s.Domains,
+		Subdomains:   s.Subdomains,
+		StarknetIds:  s.StarknetIds,
+		Fields:       s.Fields,
+		State:        s.State,
+	}
+}
+
+// Ping -
+func (s Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close -
+func (s Storage) Close() error {
+	return s.db.Close()
+}
+
+// Rewind - rolls back channels to reindex them from the height as Postgres storage does. If height is 0 all derived tables are cleared
+// and their id sequences are reset.
+func (s Storage) Rewind(ctx context.Context, channels []string, height uint64) error {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		var others []string
+		if err := tx.NewSelect().
+			Model((*models.State)(nil)).
+			Column("name").
+			Where("name NOT IN (?)", bun.In(channels)).
+			Scan(ctx, &others); err != nil {
+			return err
+		}
+		if len(others) > 0 {
+			return errors.Wrapf(models.ErrPartialRewind, "channels %v are not rewound", others)
+		}
+
+		if height == 0 {
+			tables := []string{"domain", "starknet_id", "field", "subdomain", "address"}
+			for _, table := range tables {
+				if _, err := tx.ExecContext(ctx, `DELETE FROM ?`, bun.Ident(table)); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM sqlite_sequence WHERE name IN (?)`, bun.In(tables)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM state WHERE name IN (?)`, bun.In(channels))
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM subdomain WHERE registration_height >= ?`, height); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE state SET last_height = ? WHERE name IN (?) AND last_height >= ?`, height-1, bun.In(channels), height)
+		return err
+	})
+}
+
+// IsNoRows - checks the error is returned because requested row isn't found
+func IsNoRows(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
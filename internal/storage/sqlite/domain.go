@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+// Domain -
+type Domain struct {
+	*Table[storage.Domain, *storage.Domain]
+}
+
+// NewDomain -
+func NewDomain(db *bun.DB) *Domain {
+	return &Domain{
+		Table: NewTable[storage.Domain, *storage.Domain](db),
+	}
+}
+
+// Count -
+func (d *Domain) Count(ctx context.Context) (int, error) {
+	return d.DB().NewSelect().Model((*storage.Domain)(nil)).Count(ctx)
+}
+
+// GetByName -
+func (d *Domain) GetByName(ctx context.Context, name string) (domain storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domain).Where("domain = ?", name).Limit(1).Scan(ctx)
+	return
+}
+
+// ListByOwner -
+func (d *Domain) ListByOwner(ctx context.Context, owner decimal.Decimal) (domains []storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domains).Where("owner = ?", owner.String()).Order("id asc").Scan(ctx)
+	return
+}
+
+// ListByAddress -
+func (d *Domain) ListByAddress(ctx context.Context, address []byte) (domains []storage.Domain, err error) {
+	err = d.DB().NewSelect().Model(&domains).Where("address_hash = ?", address).Order("id asc").Scan(ctx)
+	return
+}
+
+// ListSubdomains - returns all domains which are under the parent domain on any level
+func (d *Domain) ListSubdomains(ctx context.Context, parent string) (domains []storage.Domain, err error) {
+	from, to := descendantsRange(parent)
+	err = d.DB().NewSelect().Model(&domains).
+		Where("reverse(domain) >= ? AND reverse(domain) < ?", from, to).
+		Order("domain asc").
+		Scan(ctx)
+	return
+}
+
+// Search - searches domains as search functions of Postgres do. Results are ranked by relevance.
+func (d *Domain) Search(ctx context.Context, mode storage.SearchMode, query string, includeExpired bool, limit, offset int) ([]storage.DomainSearchResult, error) {
+	query = strings.ToLower(query)
+
+	q := d.DB().NewSelect().
+		TableExpr("domain AS d").
+		ColumnExpr("d.id, d.address_hash AS address, d.domain, d.expiry, d.effective_expiry, d.owner AS starknet_id")
+
+	switch mode {
+	case storage.SearchModePrefix:
+		// shorter domains are ranked higher: rank is the share of the domain matched by the query
+		q = q.ColumnExpr("CAST(length(?) AS REAL) / length(d.domain) AS rank", query).
+			Where(`d.domain LIKE ? ESCAPE '\'`, escapeLike(query)+"%")
+	case storage.SearchModeSubstring:
+		// prefix matches are ranked above other matches, then shorter domains are ranked higher
+		q = q.ColumnExpr("(CASE WHEN substr(d.domain, 1, length(?0)) = ?0 THEN 1 ELSE 0 END) + CAST(length(?0) AS REAL) / length(d.domain) AS rank", query).
+			Where(`d.domain LIKE ? ESCAPE '\'`, "%"+escapeLike(query)+"%")
+	case storage.SearchModeSimilarity:
+		q = q.ColumnExpr("similarity(d.domain, ?) AS rank", query).
+			Where("similarity(d.domain, ?) >= ?", query, storage.SimilarityThreshold)
+	default:
+		return nil, errors.Errorf("unknown search mode: %s", mode)
+	}
+
+	results := make([]storage.DomainSearchResult, 0)
+	if limit <= 0 {
+		return results, nil
+	}
+	// domains cleared by zero address don't resolve
+	q = q.Where("d.address_hash IS NOT NULL")
+	if !includeExpired {
+		q = q.Where("unixepoch(d.effective_expiry) > unixepoch()")
+	}
+
+	err := q.OrderExpr("rank DESC, d.domain ASC").
+		Limit(limit).
+		Offset(max(offset, 0)).
+		Scan(ctx, &results)
+	return results, err
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+// Field -
+type Field struct {
+	*Table[storage.Field, *storage.Field]
+}
+
+// NewField -
+func NewField(db *bun.DB) *Field {
+	return &Field{
+		Table: NewTable[storage.Field, *storage.Field](db),
+	}
+}
+
+// Count -
+func (f *Field) Count(ctx context.Context) (int, error) {
+	return f.DB().NewSelect().Model((*storage.Field)(nil)).Count(ctx)
+}
+
+// ListByOwner -
+func (f *Field) ListByOwner(ctx context.Context, owner decimal.Decimal) (fields []storage.Field, err error) {
+	err = f.DB().NewSelect().Model(&fields).
+		Where("owner_id = ?", owner.String()).
+		Order("namespace asc", "name asc").
+		Scan(ctx)
+	return
+}
+
+// ListStarknetIdsByValue - returns starknet ids which have verified field with the normalized value. Ids are stored as text,
+// so they are ordered by length first to be ordered as numbers.
+func (f *Field) ListStarknetIdsByValue(ctx context.Context, name, value string) ([]decimal.Decimal, error) {
+	// bun scans decimals as struct models, so ids are scanned as strings
+	var values []string
+	if err := f.DB().NewSelect().Model((*storage.Field)(nil)).
+		Column("owner_id").
+		Where("name = ?", name).
+		Where("text_value = ?", value).
+		Where("namespace = ?", storage.FieldNamespaceVerifier).
+		OrderExpr("length(owner_id) asc, owner_id asc").
+		Scan(ctx, &values); err != nil {
+		return nil, err
+	}
+
+	ids := make([]decimal.Decimal, len(values))
+	for i := range values {
+		id, err := decimal.NewFromString(values[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "starknet id %s", values[i])
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// ListDecoded - returns names of well-known fields whose stored values were decoded
+func (f *Field) ListDecoded(ctx context.Context) (names []string, err error) {
+	err = f.DB().NewSelect().Model((*storage.DecodedField)(nil)).Column("name").Order("name asc").Scan(ctx, &names)
+	return
+}
+
+// MarkDecoded -
+func (f *Field) MarkDecoded(ctx context.Context, names ...string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	decoded := make([]storage.DecodedField, len(names))
+	for i := range names {
+		decoded[i].Name = names[i]
+	}
+	_, err := f.DB().NewInsert().Model(&decoded).On("CONFLICT (name) DO NOTHING").Exec(ctx)
+	return err
+}
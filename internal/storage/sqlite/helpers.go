@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"strings"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+)
+
+var likeReplacer = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike - escapes special characters of LIKE pattern. Patterns are used with `ESCAPE '\'` clause.
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
+
+// descendantsRange - bounds of reversed descendants of the domain which use `domain_reverse_idx`. LIKE can't use expression index,
+// so the prefix is replaced with the range: reversed descendants are between `kcats.nebocirf.` and `kcats.nebocirf/`.
+func descendantsRange(domain string) (string, string) {
+	reversed := models.Reverse(domain)
+	return reversed + ".", reversed + "/"
+}
@@ -0,0 +1,56 @@
+-- SQLite schema. It mirrors Postgres migrations: numeric values are stored as text because they don't fit into 64-bit integers,
+-- times are stored as text in UTC. Statements are idempotent and executed on every start, views are recreated.
+CREATE TABLE IF NOT EXISTS "state" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "name" TEXT, "last_height" INTEGER, "last_time" TIMESTAMP, "last_block_time" TIMESTAMP, CONSTRAINT "state_name" UNIQUE ("name"));
+CREATE TABLE IF NOT EXISTS "address" ("id" INTEGER NOT NULL PRIMARY KEY, "hash" BLOB, "height" INTEGER, "class_id" INTEGER);
+CREATE TABLE IF NOT EXISTS "starknet_id" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "starknet_id" TEXT, "owner_address" BLOB, "owner_id" INTEGER, "inft_contract" BLOB, "inft_id" TEXT, UNIQUE ("starknet_id"));
+CREATE TABLE IF NOT EXISTS "domain" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "address_id" INTEGER, "address_hash" BLOB, "domain" TEXT, "owner" TEXT, "expiry" TIMESTAMP, "effective_expiry" TIMESTAMP, UNIQUE ("domain"));
+CREATE TABLE IF NOT EXISTS "subdomain" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "registration_height" INTEGER, "registration_date" TIMESTAMP, "resolver_id" INTEGER, "subdomain" TEXT, UNIQUE ("subdomain"));
+CREATE TABLE IF NOT EXISTS "field" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "owner_id" TEXT, "namespace" INTEGER, "name" TEXT, "value" BLOB, "text_value" TEXT, "numeric_value" TEXT);
+CREATE TABLE IF NOT EXISTS "decoded_field" ("name" TEXT NOT NULL PRIMARY KEY);
+
+CREATE INDEX IF NOT EXISTS address_hash_idx ON address (hash);
+CREATE INDEX IF NOT EXISTS starkner_id_owner_idx ON starknet_id (owner_address);
+CREATE INDEX IF NOT EXISTS domain_address_idx ON domain (address_hash);
+CREATE INDEX IF NOT EXISTS domain_address_id_idx ON domain (address_id);
+CREATE INDEX IF NOT EXISTS domain_owner_idx ON domain (owner);
+CREATE INDEX IF NOT EXISTS domain_effective_expiry_idx ON domain (effective_expiry);
+-- `reverse` is registered by the indexer: descendants of a domain are found by prefix of reversed domain
+CREATE INDEX IF NOT EXISTS domain_reverse_idx ON domain (reverse(domain));
+CREATE INDEX IF NOT EXISTS subdomain_resolver_id_idx ON subdomain (resolver_id);
+CREATE INDEX IF NOT EXISTS field_name_idx ON field (name);
+CREATE INDEX IF NOT EXISTS field_starknet_id_idx ON field (owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS field_key_idx ON field (namespace,owner_id,name);
+CREATE INDEX IF NOT EXISTS field_value_idx ON field (name,text_value) WHERE text_value IS NOT NULL;
+
+-- zero address set by `domain_to_addr_update` was stored as is before it was treated as removal of the record
+UPDATE domain SET address_id = 0, address_hash = NULL WHERE address_hash = zeroblob(32);
+
+DROP VIEW IF EXISTS actual_domains;
+CREATE VIEW actual_domains AS
+SELECT
+    domain.id,
+    domain.address_hash as address,
+    domain.domain,
+    domain.expiry,
+    starknet_id.starknet_id,
+    starknet_id.owner_address,
+    domain.effective_expiry
+FROM
+    domain
+left join starknet_id on owner = starknet_id.starknet_id
+where unixepoch(effective_expiry) > unixepoch() and domain.address_hash is not null;
+
+DROP VIEW IF EXISTS dipdup_head_status;
+CREATE VIEW dipdup_head_status AS
+SELECT
+    name,
+    CASE
+        WHEN unixepoch(last_time) < unixepoch('now', '-15 minutes') THEN 'OUTDATED'
+        WHEN unixepoch(last_block_time) < unixepoch('now', '-15 minutes') THEN 'OUTDATED'
+        ELSE 'OK'
+    END AS status,
+    last_time,
+    last_height,
+    last_block_time
+FROM
+    state;
@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/dipdup-io/starknet-id/internal/storage/storagetest"
+	"github.com/dipdup-net/go-lib/config"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T) Storage {
+	strg, err := Create(context.Background(), config.Database{
+		Kind: config.DBKindSqlite,
+		Path: filepath.Join(t.TempDir(), "starknet-id.db"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, strg.Close())
+	})
+	return strg
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Backend {
+		return newStorage(t).Backend()
+	})
+}
+
+func TestPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Database
+		want    string
+		wantErr bool
+	}{
+		{"default schema", config.Database{Path: "data/starknet-id.db"}, "data/starknet-id.db", false},
+		{"public schema", config.Database{Path: "data/starknet-id.db", SchemaName: "public"}, "data/starknet-id.db", false},
+		{"network schema", config.Database{Path: "data/starknet-id.db", SchemaName: "sepolia"}, "data/starknet-id.sepolia.db", false},
+		{"without extension", config.Database{Path: "starknet-id", SchemaName: "sepolia"}, "starknet-id.sepolia", false},
+		{"without path", config.Database{SchemaName: "sepolia"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := Path(tt.cfg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, path)
+		})
+	}
+}
+
+func TestCreate_reopen(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Database{
+		Kind: config.DBKindSqlite,
+		Path: filepath.Join(t.TempDir(), "starknet-id.db"),
+	}
+
+	strg, err := Create(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, strg.State.Save(ctx, &storage.State{Name: "starknet_id", LastHeight: 100, LastTime: time.Now()}))
+	require.NoError(t, strg.Close())
+
+	strg, err = Create(ctx, cfg)
+	require.NoError(t, err)
+	defer strg.Close()
+
+	state, err := strg.State.ByName(ctx, "starknet_id")
+	require.NoError(t, err)
+	require.EqualValues(t, 100, state.LastHeight)
+}
+
+func TestCreate_upgrade(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Database{
+		Kind: config.DBKindSqlite,
+		Path: filepath.Join(t.TempDir(), "starknet-id.db"),
+	}
+
+	// database file created before effective expiry was introduced
+	strg, err := Create(ctx, cfg)
+	require.NoError(t, err)
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	for _, query := range []string{
+		`DROP VIEW actual_domains`,
+		`DROP INDEX domain_effective_expiry_idx`,
+		`ALTER TABLE domain DROP COLUMN effective_expiry`,
+	} {
+		_, err := strg.DB().ExecContext(ctx, query)
+		require.NoError(t, err)
+	}
+	for _, domain := range []storage.Domain{
+		{Domain: "fricoben.stark", Expiry: expiry},
+		{Domain: "deployer.fricoben.stark", AddressHash: []byte{0x02}},
+	} {
+		_, err := strg.DB().NewInsert().Model(&domain).ExcludeColumn("effective_expiry").Exec(ctx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, strg.Close())
+
+	strg, err = Create(ctx, cfg)
+	require.NoError(t, err)
+	defer strg.Close()
+
+	for _, name := range []string{"fricoben.stark", "deployer.fricoben.stark"} {
+		domain, err := strg.Domains.GetByName(ctx, name)
+		require.NoError(t, err)
+		require.Equal(t, expiry.Unix(), domain.EffectiveExpiry.Unix(), name)
+	}
+}
+
+func TestCreate_zeroAddress(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.Database{
+		Kind: config.DBKindSqlite,
+		Path: filepath.Join(t.TempDir(), "starknet-id.db"),
+	}
+
+	strg, err := Create(ctx, cfg)
+	require.NoError(t, err)
+	_, err = strg.DB().NewInsert().Model(&storage.Domain{Domain: "cleared.stark", AddressId: 1, AddressHash: make([]byte, 32)}).Exec(ctx)
+	require.NoError(t, err)
+	require.NoError(t, strg.Close())
+
+	strg, err = Create(ctx, cfg)
+	require.NoError(t, err)
+	defer strg.Close()
+
+	domain, err := strg.Domains.GetByName(ctx, "cleared.stark")
+	require.NoError(t, err)
+	require.Zero(t, domain.AddressId)
+	require.Empty(t, domain.AddressHash)
+}
+
+func TestViews(t *testing.T) {
+	ctx := context.Background()
+	strg := newStorage(t)
+
+	tx, err := strg.BeginTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.SaveStarknetIds(ctx, &storage.StarknetId{StarknetId: decimal.NewFromInt(1), OwnerAddress: []byte{0x01}}))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "fricoben.stark", AddressId: 16, AddressHash: []byte{0x01}, Owner: decimal.NewFromInt(1), Expiry: time.Now().Add(time.Hour)}))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "cleared.stark", AddressId: 16, AddressHash: []byte{0x01}, Owner: decimal.NewFromInt(1), Expiry: time.Now().Add(time.Hour)}))
+	require.NoError(t, tx.ClearDomainAddress(ctx, "cleared.stark"))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "frost.stark", Owner: decimal.NewFromInt(1), Expiry: time.Now().Add(-time.Hour)}))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "deployer.fricoben.stark", AddressId: 16, AddressHash: []byte{0x02}}))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "deployer.frost.stark", AddressId: 16, AddressHash: []byte{0x02}}))
+	require.NoError(t, tx.SaveState(ctx, &storage.State{Name: "fresh", LastTime: time.Now(), LastBlockTime: time.Now()}))
+	require.NoError(t, tx.SaveState(ctx, &storage.State{Name: "stale", LastTime: time.Now(), LastBlockTime: time.Now().Add(-time.Hour)}))
+	require.NoError(t, tx.Flush(ctx))
+	require.NoError(t, tx.Close(ctx))
+
+	var domains []struct {
+		Domain       string `bun:"domain"`
+		StarknetId   string `bun:"starknet_id"`
+		OwnerAddress []byte `bun:"owner_address"`
+	}
+	require.NoError(t, strg.DB().NewSelect().Table("actual_domains").Column("domain", "starknet_id", "owner_address").Order("id").Scan(ctx, &domains))
+	require.Len(t, domains, 2)
+	require.Equal(t, "fricoben.stark", domains[0].Domain)
+	require.Equal(t, "1", domains[0].StarknetId)
+	require.Equal(t, []byte{0x01}, domains[0].OwnerAddress)
+	require.Equal(t, "deployer.fricoben.stark", domains[1].Domain, "subdomain expires with the parent")
+
+	var statuses []struct {
+		Name   string `bun:"name"`
+		Status string `bun:"status"`
+	}
+	require.NoError(t, strg.DB().NewSelect().Table("dipdup_head_status").Column("name", "status").Order("name").Scan(ctx, &statuses))
+	require.Len(t, statuses, 2)
+	require.Equal(t, "OK", statuses[0].Status)
+	require.Equal(t, "OUTDATED", statuses[1].Status)
+}
+
+func TestStorage_Rewind(t *testing.T) {
+	ctx := context.Background()
+	strg := newStorage(t)
+
+	save := func() {
+		tx, err := strg.BeginTransaction(ctx)
+		require.NoError(t, err)
+		defer tx.Close(ctx)
+
+		require.NoError(t, tx.SaveState(ctx, &storage.State{Name: "starknet_id", LastHeight: 200, LastTime: time.Now()}))
+		require.NoError(t, tx.SaveSubdomain(ctx, &storage.Subdomain{Subdomain: "braavos", RegistrationHeight: 100}))
+		require.NoError(t, tx.SaveSubdomain(ctx, &storage.Subdomain{Subdomain: "xplorer", RegistrationHeight: 150}))
+		require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "fricoben.stark", Expiry: time.Now()}))
+		require.NoError(t, tx.Flush(ctx))
+	}
+	save()
+
+	// subdomains registered by other channel are kept
+	tx, err := strg.BeginTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.SaveState(ctx, &storage.State{Name: "naming", LastHeight: 200, LastTime: time.Now()}))
+	require.NoError(t, tx.Flush(ctx))
+	tx.Close(ctx)
+
+	err = strg.Rewind(ctx, []string{"starknet_id"}, 120)
+	require.ErrorIs(t, err, storage.ErrPartialRewind)
+	_, err = strg.Subdomains.GetByID(ctx, 2)
+	require.NoError(t, err)
+	state, err := strg.State.ByName(ctx, "naming")
+	require.NoError(t, err)
+	require.EqualValues(t, 200, state.LastHeight)
+	err = strg.Rewind(ctx, []string{"starknet_id"}, 0)
+	require.ErrorIs(t, err, storage.ErrPartialRewind)
+	count, err := strg.Domains.Count(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, count)
+
+	require.NoError(t, strg.Rewind(ctx, []string{"naming", "starknet_id"}, 120))
+	state, err = strg.State.ByName(ctx, "starknet_id")
+	require.NoError(t, err)
+	require.EqualValues(t, 119, state.LastHeight)
+	_, err = strg.Subdomains.GetByID(ctx, 2)
+	require.True(t, IsNoRows(err))
+
+	require.NoError(t, strg.Rewind(ctx, []string{"naming", "starknet_id"}, 0))
+	_, err = strg.State.ByName(ctx, "starknet_id")
+	require.True(t, IsNoRows(err))
+	count, err = strg.Domains.Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	save()
+	domain, err := strg.Domains.GetByName(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, domain.Id, "ids are restarted")
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+)
+
+// StarknetId -
+type StarknetId struct {
+	*Table[storage.StarknetId, *storage.StarknetId]
+}
+
+// NewStarknetId -
+func NewStarknetId(db *bun.DB) *StarknetId {
+	return &StarknetId{
+		Table: NewTable[storage.StarknetId, *storage.StarknetId](db),
+	}
+}
+
+// Count -
+func (s *StarknetId) Count(ctx context.Context) (int, error) {
+	return s.DB().NewSelect().Model((*storage.StarknetId)(nil)).Count(ctx)
+}
+
+// GetByStarknetId -
+func (s *StarknetId) GetByStarknetId(ctx context.Context, starknetId decimal.Decimal) (result storage.StarknetId, err error) {
+	err = s.DB().NewSelect().Model(&result).Where("starknet_id = ?", starknetId.String()).Limit(1).Scan(ctx)
+	return
+}
+
+// ListByOwner -
+func (s *StarknetId) ListByOwner(ctx context.Context, address []byte) (result []storage.StarknetId, err error) {
+	err = s.DB().NewSelect().Model(&result).Where("owner_address = ?", address).Order("id asc").Scan(ctx)
+	return
+}
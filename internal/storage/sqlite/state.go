@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+// State -
+type State struct {
+	*Table[storage.State, *storage.State]
+}
+
+// NewState -
+func NewState(db *bun.DB) *State {
+	return &State{
+		Table: NewTable[storage.State, *storage.State](db),
+	}
+}
+
+// ByName -
+func (s *State) ByName(ctx context.Context, name string) (state storage.State, err error) {
+	err = s.DB().NewSelect().Model(&state).Where("name = ?", name).Scan(ctx)
+	return
+}
@@ -0,0 +1,28 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/uptrace/bun"
+)
+
+// Subdomain -
+type Subdomain struct {
+	*Table[storage.Subdomain, *storage.Subdomain]
+}
+
+// NewSubdomain -
+func NewSubdomain(db *bun.DB) *Subdomain {
+	return &Subdomain{
+		Table: NewTable[storage.Subdomain, *storage.Subdomain](db),
+	}
+}
+
+// GetByResolverId -
+func (s *Subdomain) GetByResolverId(ctx context.Context, resolverId uint64) (result storage.Subdomain, err error) {
+	err = s.DB().NewSelect().Model(&result).
+		Where("resolver_id = ?", resolverId).
+		Limit(1).Scan(ctx)
+	return
+}
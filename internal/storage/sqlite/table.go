@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/dipdup-net/indexer-sdk/pkg/storage/postgres"
+	"github.com/uptrace/bun"
+)
+
+// Table - SQLite realization of storage.Table. M is pointer to the model T.
+type Table[T any, M interface {
+	*T
+	storage.Model
+}] struct {
+	db *bun.DB
+}
+
+// NewTable -
+func NewTable[T any, M interface {
+	*T
+	storage.Model
+}](db *bun.DB) *Table[T, M] {
+	return &Table[T, M]{db}
+}
+
+// Save - inserts row to table and sets its id
+func (t *Table[T, M]) Save(ctx context.Context, m M) error {
+	_, err := t.db.NewInsert().Model(m).Returning("id").Exec(ctx)
+	return err
+}
+
+// Update - updates table row by primary key
+func (t *Table[T, M]) Update(ctx context.Context, m M) error {
+	_, err := t.db.NewUpdate().Model(m).WherePK().Exec(ctx)
+	return err
+}
+
+// List - returns array of rows
+func (t *Table[T, M]) List(ctx context.Context, limit, offset uint64, order storage.SortOrder) ([]M, error) {
+	var models []M
+	query := t.db.NewSelect().Model(&models)
+	query = postgres.Pagination(query, limit, offset, order)
+
+	err := query.Scan(ctx)
+	return models, err
+}
+
+// GetByID - returns row by id
+func (t *Table[T, M]) GetByID(ctx context.Context, id uint64) (M, error) {
+	m := M(new(T))
+	err := t.db.NewSelect().Model(m).Where("id = ?", id).Scan(ctx)
+	return m, err
+}
+
+// CursorList - returns array of rows by cursor pagination
+func (t *Table[T, M]) CursorList(ctx context.Context, id, limit uint64, order storage.SortOrder, cmp storage.Comparator) ([]M, error) {
+	var models []M
+	query := t.db.NewSelect().Model(&models)
+	query = postgres.CursorPagination(query, id, limit, order, cmp)
+
+	err := query.Scan(ctx)
+	return models, err
+}
+
+// LastID - returns last used id
+func (t *Table[T, M]) LastID(ctx context.Context) (id uint64, err error) {
+	err = t.db.NewSelect().Model((M)(nil)).ColumnExpr("max(id)").Scan(ctx, &id)
+	return
+}
+
+// IsNoRows -
+func (t *Table[T, M]) IsNoRows(err error) bool {
+	return IsNoRows(err)
+}
+
+// DB - returns connection to the database
+func (t *Table[T, M]) DB() *bun.DB {
+	return t.db
+}
@@ -0,0 +1,204 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	models "github.com/dipdup-io/starknet-id/internal/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/uptrace/bun"
+	bunschema "github.com/uptrace/bun/schema"
+)
+
+// Transaction - saves indexed block. Queries are the same as Postgres ones: SQLite supports `ON CONFLICT` upserts too.
+type Transaction struct {
+	tx bun.Tx
+}
+
+// Flush - commits changes
+func (t *Transaction) Flush(ctx context.Context) error {
+	return t.tx.Commit()
+}
+
+// HandleError - rolls back changes and wraps the error
+func (t *Transaction) HandleError(ctx context.Context, err error) error {
+	processorErr := errors.Wrap(err, "transaction error")
+	if err := t.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return errors.Wrap(processorErr, errors.Wrap(err, "rollback").Error())
+	}
+	return processorErr
+}
+
+// Close - rolls back changes if transaction wasn't committed. SQLite has the only writer, so unfinished transaction would block others.
+func (t *Transaction) Close(ctx context.Context) error {
+	if err := t.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return err
+	}
+	return nil
+}
+
+func (t *Transaction) exec(ctx context.Context, query string, args ...any) error {
+	_, err := t.tx.NewRaw(query, args...).Exec(ctx)
+	return err
+}
+
+// SaveState -
+func (t *Transaction) SaveState(ctx context.Context, state *models.State) error {
+	_, err := t.tx.NewInsert().Model(state).
+		On("CONFLICT (name) DO UPDATE").
+		Set("last_height = excluded.last_height").
+		Set("last_time = excluded.last_time").
+		Set("last_block_time = excluded.last_block_time").
+		Returning("id").
+		Exec(ctx)
+	return err
+}
+
+// SaveAddress -
+func (t *Transaction) SaveAddress(ctx context.Context, addresses ...*models.Address) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	_, err := t.tx.NewInsert().Model(&addresses).
+		On("CONFLICT (id) DO UPDATE").
+		Set("class_id = excluded.class_id").
+		Exec(ctx)
+	return err
+}
+
+// SaveStarknetIds - mints replayed after reindex replace existing starknet ids with their minted state
+func (t *Transaction) SaveStarknetIds(ctx context.Context, starknetIds ...*models.StarknetId) error {
+	if len(starknetIds) == 0 {
+		return nil
+	}
+	_, err := t.tx.NewInsert().Model(&starknetIds).
+		On("CONFLICT (starknet_id) DO UPDATE").
+		Set("owner_address = excluded.owner_address").
+		Set("owner_id = excluded.owner_id").
+		Set("inft_contract = excluded.inft_contract").
+		Set("inft_id = excluded.inft_id").
+		Returning("id").
+		Exec(ctx)
+	return err
+}
+
+// TransferStarknetId -
+func (t *Transaction) TransferStarknetId(ctx context.Context, starknetId *models.StarknetId) error {
+	return t.exec(ctx,
+		`UPDATE starknet_id SET owner_address = ?, owner_id = ? WHERE starknet_id = ?`,
+		starknetId.OwnerAddress, starknetId.OwnerId, starknetId.StarknetId.String())
+}
+
+// BurnStarknetIds -
+func (t *Transaction) BurnStarknetIds(ctx context.Context, starknetIds ...decimal.Decimal) error {
+	if len(starknetIds) == 0 {
+		return nil
+	}
+	burned := make([]string, len(starknetIds))
+	for i := range starknetIds {
+		burned[i] = starknetIds[i].String()
+	}
+	return t.exec(ctx, `DELETE FROM starknet_id WHERE starknet_id IN (?)`, bun.In(burned))
+}
+
+// EquipInft -
+func (t *Transaction) EquipInft(ctx context.Context, starknetId *models.StarknetId) error {
+	return t.exec(ctx,
+		`UPDATE starknet_id SET inft_contract = ?, inft_id = ? WHERE starknet_id = ?`,
+		starknetId.InftContract, starknetId.InftId, starknetId.StarknetId.String())
+}
+
+// SaveSubdomain -
+func (t *Transaction) SaveSubdomain(ctx context.Context, subdomain *models.Subdomain) error {
+	return t.exec(ctx, `INSERT INTO subdomain (registration_height, registration_date, resolver_id, subdomain)
+		VALUES (?,?,?,?)
+		ON CONFLICT (subdomain)
+		DO 
+		UPDATE SET registration_height = excluded.registration_height, registration_date = excluded.registration_date, resolver_id = excluded.resolver_id`,
+		subdomain.RegistrationHeight, subdomain.RegistrationDate, subdomain.ResolverId, subdomain.Subdomain,
+	)
+}
+
+// SaveDomain -
+func (t *Transaction) SaveDomain(ctx context.Context, domain *models.Domain) error {
+	var (
+		query         string
+		timeIsZero    = domain.Expiry.IsZero()
+		addressIsNull = len(domain.AddressHash) == 0
+	)
+
+	switch {
+	case !timeIsZero && !addressIsNull:
+		query = `INSERT INTO domain (address_id, address_hash, domain, owner, expiry, effective_expiry)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (domain)
+		DO 
+		UPDATE SET address_id = excluded.address_id, address_hash = excluded.address_hash, owner = excluded.owner, expiry = excluded.expiry, effective_expiry = excluded.effective_expiry`
+	case timeIsZero && !addressIsNull:
+		query = `INSERT INTO domain (address_id, address_hash, domain, owner, expiry, effective_expiry)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (domain)
+		DO 
+		UPDATE SET address_id = excluded.address_id, address_hash = excluded.address_hash`
+	case !timeIsZero && addressIsNull:
+		query = `INSERT INTO domain (address_id, address_hash, domain, owner, expiry, effective_expiry)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (domain)
+		DO 
+		UPDATE SET owner = excluded.owner, expiry = excluded.expiry, effective_expiry = excluded.effective_expiry`
+	default:
+		return nil
+	}
+
+	var (
+		hasExpiry           = models.HasExpiry(domain.Expiry)
+		effectiveExpiry any = domain.Expiry
+	)
+	if ancestors := models.Ancestors(domain.Domain); !hasExpiry && len(ancestors) > 0 {
+		effectiveExpiry = bunschema.SafeQuery(`COALESCE((
+			SELECT expiry FROM domain WHERE domain IN (?) AND unixepoch(expiry) > 0 ORDER BY length(domain) DESC LIMIT 1
+		), ?)`, []any{bun.In(ancestors), domain.Expiry})
+	}
+
+	if err := t.exec(ctx, query,
+		domain.AddressId, domain.AddressHash, domain.Domain, domain.Owner.String(), domain.Expiry, effectiveExpiry,
+	); err != nil {
+		return err
+	}
+	if !hasExpiry {
+		return nil
+	}
+
+	// descendants without own expiry and without nearer ancestor with expiry inherit the new one
+	from, to := descendantsRange(domain.Domain)
+	return t.exec(ctx, `UPDATE domain AS d SET effective_expiry = ?0
+		WHERE reverse(d.domain) >= ?1 AND reverse(d.domain) < ?2 AND (d.expiry IS NULL OR unixepoch(d.expiry) <= 0)
+		AND NOT EXISTS (
+			SELECT 1 FROM domain AS m
+			WHERE reverse(m.domain) >= ?1 AND reverse(m.domain) < ?2 AND unixepoch(m.expiry) > 0 AND substr(d.domain, -(length(m.domain) + 1)) = '.' || m.domain
+		)`,
+		domain.Expiry, from, to,
+	)
+}
+
+// TransferDomain -
+func (t *Transaction) TransferDomain(ctx context.Context, domain *models.Domain) error {
+	return t.exec(ctx, `UPDATE domain SET owner = ? WHERE domain = ?`, domain.Owner.String(), domain.Domain)
+}
+
+// ClearDomainAddress -
+func (t *Transaction) ClearDomainAddress(ctx context.Context, domain string) error {
+	return t.exec(ctx, `UPDATE domain SET address_id = 0, address_hash = NULL WHERE domain = ?`, domain)
+}
+
+// SaveField -
+func (t *Transaction) SaveField(ctx context.Context, field *models.Field) error {
+	return t.exec(ctx, `INSERT INTO field (owner_id, name, namespace, value, text_value, numeric_value)
+		VALUES (?,?,?,?,?,?)
+		ON CONFLICT (namespace,owner_id,name)
+		DO 
+		UPDATE SET value = excluded.value, text_value = excluded.text_value, numeric_value = excluded.numeric_value`,
+		field.OwnerId.String(), field.Name, field.Namespace, field.Value, sql.NullString{String: field.TextValue, Valid: field.TextValue != ""}, field.NumericValue,
+	)
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+
 	"github.com/dipdup-net/indexer-sdk/pkg/storage"
 	"github.com/shopspring/decimal"
 	"github.com/uptrace/bun"
@@ -9,16 +11,22 @@ import (
 // IStarknetId -
 type IStarknetId interface {
 	storage.Table[*StarknetId]
+
+	Count(ctx context.Context) (int, error)
+	GetByStarknetId(ctx context.Context, starknetId decimal.Decimal) (StarknetId, error)
+	ListByOwner(ctx context.Context, address []byte) ([]StarknetId, error)
 }
 
 // StarknetId -
 type StarknetId struct {
 	bun.BaseModel `bun:"starknet_id" comment:"Starknet id table"`
 
-	Id           uint64          `bun:"id,pk,autoincrement"             comment:"Unique internal identity"`
-	StarknetId   decimal.Decimal `bun:",unique,type:numeric"            comment:"Starknet Id (token id)"`
-	OwnerAddress []byte          `comment:"Address hash of token owner"`
-	OwnerId      uint64          `comment:"Owner identity of address"`
+	Id           uint64              `bun:"id,pk,autoincrement"             comment:"Unique internal identity"`
+	StarknetId   decimal.Decimal     `bun:",unique,type:numeric"            comment:"Starknet Id (token id)"`
+	OwnerAddress []byte              `comment:"Address hash of token owner"`
+	OwnerId      uint64              `comment:"Owner identity of address"`
+	InftContract []byte              `bun:",nullzero"                       comment:"Contract of equipped iNFT"`
+	InftId       decimal.NullDecimal `bun:",type:numeric"                   comment:"Token id of equipped iNFT"`
 
 	Owner  Address `bun:"-" hasura:"table:address,field:owner_id,remote_field:id,type:oto,name:owner"`
 	Fields []Field `bun:"-" hasura:"table:field,field:starknet_id,remote_field:owner_id,type:otm,name:fields"`
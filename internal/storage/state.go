@@ -5,9 +5,13 @@ import (
 	"time"
 
 	"github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 )
 
+// ErrPartialRewind - derived tables are shared by channels, so channels can be rewound only all together
+var ErrPartialRewind = errors.New("all channels have to be rewound together")
+
 // IState -
 type IState interface {
 	storage.Table[*State]
@@ -19,10 +23,11 @@ type IState interface {
 type State struct {
 	bun.BaseModel `bun:"state" comment:"Table contains current indexer's state"`
 
-	ID         uint64    `bun:"id,pk,autoincrement"    comment:"Unique internal identity"`
-	Name       string    `bun:",unique:state_name"     comment:"Indexer human-readable name"`
-	LastHeight uint64    `comment:"Last block height"`
-	LastTime   time.Time `comment:"Time of last block"`
+	ID            uint64    `bun:"id,pk,autoincrement"    comment:"Unique internal identity"`
+	Name          string    `bun:",unique:state_name"     comment:"Indexer human-readable name"`
+	LastHeight    uint64    `comment:"Last block height"`
+	LastTime      time.Time `comment:"Time of last block"`
+	LastBlockTime time.Time `bun:",nullzero"              comment:"Timestamp of last block"`
 }
 
 // TableName -
@@ -0,0 +1,568 @@
+// Package storagetest contains conformance tests which every storage backend has to pass.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dipdup-io/starknet-id/internal/storage"
+	sdk "github.com/dipdup-net/indexer-sdk/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+// NewBackend - creates empty backend for the test. Backend has to be released by t.Cleanup.
+type NewBackend func(t *testing.T) storage.Backend
+
+var (
+	alice = []byte{0xa1}
+	bob   = []byte{0xb0}
+
+	blockTime = time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	expiry    = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	expired   = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Run - runs conformance tests. Every test gets new empty backend.
+func Run(t *testing.T, newBackend NewBackend) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, backend storage.Backend)
+	}{
+		{"state", testState},
+		{"address", testAddress},
+		{"starknet_id", testStarknetId},
+		{"domain", testDomain},
+		{"effective_expiry", testEffectiveExpiry},
+		{"subdomain", testSubdomain},
+		{"field", testField},
+		{"search", testSearch},
+		{"rollback", testRollback},
+		{"table", testTable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			tt.test(t, ctx, newBackend(t))
+		})
+	}
+}
+
+// save - saves data in single transaction
+func save(t *testing.T, ctx context.Context, backend storage.Backend, f func(tx storage.Transaction) error) {
+	t.Helper()
+
+	tx, err := backend.BeginTransaction(ctx)
+	require.NoError(t, err)
+	defer tx.Close(ctx)
+
+	require.NoError(t, f(tx))
+	require.NoError(t, tx.Flush(ctx))
+}
+
+func testState(t *testing.T, ctx context.Context, backend storage.Backend) {
+	_, err := backend.State.ByName(ctx, "starknet_id")
+	require.True(t, backend.State.IsNoRows(err))
+
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveState(ctx, &storage.State{
+			Name:          "starknet_id",
+			LastHeight:    100,
+			LastTime:      blockTime,
+			LastBlockTime: blockTime,
+		})
+	})
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveState(ctx, &storage.State{
+			Name:          "starknet_id",
+			LastHeight:    101,
+			LastTime:      blockTime,
+			LastBlockTime: blockTime.Add(time.Minute),
+		})
+	})
+
+	state, err := backend.State.ByName(ctx, "starknet_id")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, state.ID)
+	require.EqualValues(t, 101, state.LastHeight)
+	require.True(t, blockTime.Add(time.Minute).Equal(state.LastBlockTime))
+
+	states, err := backend.State.List(ctx, 10, 0, sdk.SortOrderAsc)
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+}
+
+func testAddress(t *testing.T, ctx context.Context, backend storage.Backend) {
+	classId := uint64(5)
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveAddress(ctx,
+			&storage.Address{Id: 14, Hash: bob, Height: 100},
+			&storage.Address{Id: 16, Hash: alice, Height: 100},
+		)
+	})
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveAddress(ctx, &storage.Address{Id: 16, Hash: alice, Height: 200, ClassId: &classId})
+	})
+
+	address, err := backend.Addresses.GetByHash(ctx, alice)
+	require.NoError(t, err)
+	require.EqualValues(t, 16, address.Id)
+	require.EqualValues(t, 100, address.Height, "only class id is updated")
+	require.NotNil(t, address.ClassId)
+	require.EqualValues(t, 5, *address.ClassId)
+
+	byId, err := backend.Addresses.GetByID(ctx, 14)
+	require.NoError(t, err)
+	require.Equal(t, bob, byId.Hash)
+	require.Nil(t, byId.ClassId)
+
+	lastId, err := backend.Addresses.LastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 16, lastId)
+
+	_, err = backend.Addresses.GetByHash(ctx, []byte{0xff})
+	require.True(t, backend.Addresses.IsNoRows(err))
+	_, err = backend.Addresses.GetByID(ctx, 1)
+	require.True(t, backend.Addresses.IsNoRows(err))
+}
+
+func testStarknetId(t *testing.T, ctx context.Context, backend storage.Backend) {
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveStarknetIds(ctx,
+			&storage.StarknetId{StarknetId: decimal.NewFromInt(1), OwnerAddress: alice, OwnerId: 16},
+			&storage.StarknetId{StarknetId: decimal.NewFromInt(2), OwnerAddress: alice, OwnerId: 16},
+			&storage.StarknetId{StarknetId: decimal.NewFromInt(3), OwnerAddress: alice, OwnerId: 16},
+		)
+	})
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		if err := tx.TransferStarknetId(ctx, &storage.StarknetId{StarknetId: decimal.NewFromInt(2), OwnerAddress: bob, OwnerId: 14}); err != nil {
+			return err
+		}
+		if err := tx.TransferStarknetId(ctx, &storage.StarknetId{StarknetId: decimal.NewFromInt(10), OwnerAddress: bob, OwnerId: 14}); err != nil {
+			return err
+		}
+		if err := tx.EquipInft(ctx, &storage.StarknetId{StarknetId: decimal.NewFromInt(1), InftContract: []byte{0x1f}, InftId: decimal.NewNullDecimal(decimal.NewFromInt(7))}); err != nil {
+			return err
+		}
+		return tx.BurnStarknetIds(ctx, decimal.NewFromInt(3), decimal.NewFromInt(11))
+	})
+
+	count, err := backend.StarknetIds.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count, "unknown starknet id isn't created by transfer")
+
+	first, err := backend.StarknetIds.GetByStarknetId(ctx, decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Equal(t, alice, first.OwnerAddress)
+	require.Equal(t, []byte{0x1f}, first.InftContract)
+	require.Equal(t, "7", first.InftId.Decimal.String())
+
+	owned, err := backend.StarknetIds.ListByOwner(ctx, bob)
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	require.Equal(t, "2", owned[0].StarknetId.String())
+	require.EqualValues(t, 14, owned[0].OwnerId)
+
+	_, err = backend.StarknetIds.GetByStarknetId(ctx, decimal.NewFromInt(3))
+	require.True(t, backend.StarknetIds.IsNoRows(err))
+
+	// replayed mint replaces the starknet id with its minted state
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveStarknetIds(ctx, &storage.StarknetId{StarknetId: decimal.NewFromInt(1), OwnerAddress: bob, OwnerId: 14})
+	})
+
+	count, err = backend.StarknetIds.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count, "starknet id is unique")
+
+	first, err = backend.StarknetIds.GetByStarknetId(ctx, decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Equal(t, bob, first.OwnerAddress)
+	require.EqualValues(t, 14, first.OwnerId)
+	require.Empty(t, first.InftContract)
+	require.False(t, first.InftId.Valid)
+}
+
+func testDomain(t *testing.T, ctx context.Context, backend storage.Backend) {
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		for _, domain := range []*storage.Domain{
+			{Domain: "fricoben.stark", Owner: decimal.NewFromInt(1), Expiry: expiry},
+			{Domain: "deployer.fricoben.stark", AddressId: 16, AddressHash: alice},
+			{Domain: "cat.stark", AddressId: 14, AddressHash: bob, Owner: decimal.NewFromInt(2), Expiry: expiry},
+			{Domain: "ignored.stark"},
+		} {
+			if err := tx.SaveDomain(ctx, domain); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	count, err := backend.Domains.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, count, "domain without expiry and address isn't saved")
+
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		// address is set, owner and expiry are kept
+		if err := tx.SaveDomain(ctx, &storage.Domain{Domain: "fricoben.stark", AddressId: 16, AddressHash: alice}); err != nil {
+			return err
+		}
+		// owner and expiry are set, address is kept
+		if err := tx.SaveDomain(ctx, &storage.Domain{Domain: "cat.stark", Owner: decimal.NewFromInt(3), Expiry: expired}); err != nil {
+			return err
+		}
+		if err := tx.TransferDomain(ctx, &storage.Domain{Domain: "deployer.fricoben.stark", Owner: decimal.NewFromInt(1)}); err != nil {
+			return err
+		}
+		return tx.TransferDomain(ctx, &storage.Domain{Domain: "unknown.stark", Owner: decimal.NewFromInt(1)})
+	})
+
+	fricoben, err := backend.Domains.GetByName(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	require.EqualValues(t, 16, fricoben.AddressId)
+	require.Equal(t, alice, fricoben.AddressHash)
+	require.Equal(t, "1", fricoben.Owner.String())
+	require.True(t, expiry.Equal(fricoben.Expiry))
+
+	cat, err := backend.Domains.GetByName(ctx, "cat.stark")
+	require.NoError(t, err)
+	require.EqualValues(t, 14, cat.AddressId)
+	require.Equal(t, bob, cat.AddressHash)
+	require.Equal(t, "3", cat.Owner.String())
+	require.True(t, expired.Equal(cat.Expiry))
+
+	_, err = backend.Domains.GetByName(ctx, "unknown.stark")
+	require.True(t, backend.Domains.IsNoRows(err))
+
+	owned, err := backend.Domains.ListByOwner(ctx, decimal.NewFromInt(1))
+	require.NoError(t, err)
+	require.Equal(t, []string{"fricoben.stark", "deployer.fricoben.stark"}, domainNames(owned))
+
+	byAddress, err := backend.Domains.ListByAddress(ctx, alice)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fricoben.stark", "deployer.fricoben.stark"}, domainNames(byAddress))
+
+	subdomains, err := backend.Domains.ListSubdomains(ctx, "fricoben.stark")
+	require.NoError(t, err)
+	require.Equal(t, []string{"deployer.fricoben.stark"}, domainNames(subdomains))
+
+	subdomains, err = backend.Domains.ListSubdomains(ctx, "deployer.fricoben.stark")
+	require.NoError(t, err)
+	require.Empty(t, subdomains)
+
+	subdomains, err = backend.Domains.ListSubdomains(ctx, "ben.stark")
+	require.NoError(t, err)
+	require.Empty(t, subdomains, "only whole labels are matched")
+
+	// address is removed, owner and expiry are kept
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		if err := tx.ClearDomainAddress(ctx, "cat.stark"); err != nil {
+			return err
+		}
+		return tx.ClearDomainAddress(ctx, "unknown.stark")
+	})
+
+	cat, err = backend.Domains.GetByName(ctx, "cat.stark")
+	require.NoError(t, err)
+	require.Zero(t, cat.AddressId)
+	require.Empty(t, cat.AddressHash)
+	require.Equal(t, "3", cat.Owner.String())
+	require.True(t, expired.Equal(cat.Expiry))
+
+	byAddress, err = backend.Domains.ListByAddress(ctx, bob)
+	require.NoError(t, err)
+	require.Empty(t, byAddress)
+}
+
+func testEffectiveExpiry(t *testing.T, ctx context.Context, backend storage.Backend) {
+	renewed := expiry.AddDate(1, 0, 0)
+
+	// subdomains are saved before their parents as it happens inside a block
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		for _, domain := range []*storage.Domain{
+			{Domain: "alice.braavos.stark", AddressId: 16, AddressHash: alice},
+			{Domain: "epoch.fricoben.stark", Owner: decimal.NewFromInt(3), Expiry: time.Unix(0, 0).UTC(), AddressId: 14, AddressHash: bob},
+			{Domain: "deep.sub.fricoben.stark", AddressId: 16, AddressHash: alice},
+			{Domain: "sub.fricoben.stark", Owner: decimal.NewFromInt(2), Expiry: expired},
+			{Domain: "braavos.stark", Owner: decimal.NewFromInt(1), Expiry: expiry},
+			{Domain: "fricoben.stark", Owner: decimal.NewFromInt(1), Expiry: expiry, AddressId: 16, AddressHash: alice},
+			{Domain: "deployer.fricoben.stark", AddressId: 14, AddressHash: bob},
+			{Domain: "orphan.unknown.stark", AddressId: 14, AddressHash: bob},
+		} {
+			if err := tx.SaveDomain(ctx, domain); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	requireEffectiveExpiry := func(name string, want time.Time) {
+		t.Helper()
+		domain, err := backend.Domains.GetByName(ctx, name)
+		require.NoError(t, err, name)
+		require.Equal(t, want.Unix(), domain.EffectiveExpiry.Unix(), name)
+	}
+
+	requireEffectiveExpiry("fricoben.stark", expiry)
+	requireEffectiveExpiry("braavos.stark", expiry)
+	requireEffectiveExpiry("alice.braavos.stark", expiry)
+	requireEffectiveExpiry("deployer.fricoben.stark", expiry)
+	requireEffectiveExpiry("epoch.fricoben.stark", expiry)
+	requireEffectiveExpiry("sub.fricoben.stark", expired)
+	requireEffectiveExpiry("deep.sub.fricoben.stark", expired)
+
+	orphan, err := backend.Domains.GetByName(ctx, "orphan.unknown.stark")
+	require.NoError(t, err)
+	require.False(t, orphan.EffectiveExpiry.After(expired), "domain without ancestors is expired")
+
+	// parent is renewed, subdomain under ancestor with own expiry keeps it
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		if err := tx.SaveDomain(ctx, &storage.Domain{Domain: "fricoben.stark", Owner: decimal.NewFromInt(1), Expiry: renewed}); err != nil {
+			return err
+		}
+		return tx.SaveDomain(ctx, &storage.Domain{Domain: "deployer.fricoben.stark", AddressId: 16, AddressHash: alice})
+	})
+
+	requireEffectiveExpiry("fricoben.stark", renewed)
+	requireEffectiveExpiry("deployer.fricoben.stark", renewed)
+	requireEffectiveExpiry("epoch.fricoben.stark", renewed)
+	requireEffectiveExpiry("sub.fricoben.stark", expired)
+	requireEffectiveExpiry("deep.sub.fricoben.stark", expired)
+	requireEffectiveExpiry("alice.braavos.stark", expiry)
+
+	results, err := backend.Domains.Search(ctx, storage.SearchModeSubstring, "fricoben", false, 10, 0)
+	require.NoError(t, err)
+	names := make([]string, len(results))
+	for i := range results {
+		names[i] = results[i].Domain
+	}
+	require.Equal(t, []string{"fricoben.stark", "epoch.fricoben.stark", "deployer.fricoben.stark"}, names, "subdomains of expired ancestor aren't found")
+}
+
+func testSubdomain(t *testing.T, ctx context.Context, backend storage.Backend) {
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveSubdomain(ctx, &storage.Subdomain{
+			Subdomain:          "deployer.fricoben",
+			ResolverId:         20,
+			RegistrationHeight: 100,
+			RegistrationDate:   blockTime,
+		})
+	})
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveSubdomain(ctx, &storage.Subdomain{
+			Subdomain:          "deployer.fricoben",
+			ResolverId:         21,
+			RegistrationHeight: 101,
+			RegistrationDate:   blockTime.Add(time.Minute),
+		})
+	})
+
+	subdomain, err := backend.Subdomains.GetByResolverId(ctx, 21)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, subdomain.Id)
+	require.Equal(t, "deployer.fricoben", subdomain.Subdomain)
+	require.EqualValues(t, 101, subdomain.RegistrationHeight)
+	require.True(t, blockTime.Add(time.Minute).Equal(subdomain.RegistrationDate))
+
+	_, err = backend.Subdomains.GetByResolverId(ctx, 20)
+	require.True(t, backend.Subdomains.IsNoRows(err))
+}
+
+func testField(t *testing.T, ctx context.Context, backend storage.Backend) {
+	owner := decimal.NewFromInt(1)
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		for _, field := range []*storage.Field{
+			{OwnerId: owner, Namespace: storage.FieldNamespaceVerifier, Name: "twitter", Value: []byte{0x1}, TextValue: "1"},
+			{OwnerId: owner, Namespace: storage.FieldNamespaceUser, Name: "avatar", Value: []byte{0x2}},
+			{OwnerId: owner, Namespace: storage.FieldNamespaceVerifier, Name: "discord", Value: []byte{0x3}, NumericValue: decimal.NewNullDecimal(decimal.NewFromInt(3))},
+			{OwnerId: decimal.NewFromInt(2), Namespace: storage.FieldNamespaceVerifier, Name: "twitter", Value: []byte{0x4}, TextValue: "4"},
+		} {
+			if err := tx.SaveField(ctx, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveField(ctx, &storage.Field{
+			OwnerId: decimal.NewFromInt(2), Namespace: storage.FieldNamespaceVerifier, Name: "twitter", Value: []byte{0x1}, TextValue: "1",
+		})
+	})
+
+	count, err := backend.Fields.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+
+	fields, err := backend.Fields.ListByOwner(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, fields, 3)
+	require.Equal(t, "discord", fields[0].Name)
+	require.True(t, fields[0].NumericValue.Valid)
+	require.Equal(t, "3", fields[0].NumericValue.Decimal.String())
+	require.Equal(t, "twitter", fields[1].Name)
+	require.Equal(t, "avatar", fields[2].Name)
+	require.Empty(t, fields[2].TextValue)
+	require.False(t, fields[2].NumericValue.Valid)
+
+	ids, err := backend.Fields.ListStarknetIdsByValue(ctx, "twitter", "1")
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+	require.Equal(t, "1", ids[0].String())
+	require.Equal(t, "2", ids[1].String())
+
+	ids, err = backend.Fields.ListStarknetIdsByValue(ctx, "twitter", "4")
+	require.NoError(t, err)
+	require.Empty(t, ids)
+
+	decoded, err := backend.Fields.ListDecoded(ctx)
+	require.NoError(t, err)
+	require.Empty(t, decoded)
+
+	require.NoError(t, backend.Fields.MarkDecoded(ctx, "twitter", "discord"))
+	require.NoError(t, backend.Fields.MarkDecoded(ctx, "twitter"))
+	decoded, err = backend.Fields.ListDecoded(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"discord", "twitter"}, decoded)
+}
+
+func testSearch(t *testing.T, ctx context.Context, backend storage.Backend) {
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		for _, domain := range []*storage.Domain{
+			{Domain: "fricoben.stark", Owner: decimal.NewFromInt(1), Expiry: expiry, AddressHash: alice},
+			// subdomain is found by the expiry of its parent
+			{Domain: "deployer.fricoben.stark", AddressHash: bob},
+			{Domain: "fri.stark", Owner: decimal.NewFromInt(2), Expiry: expiry, AddressHash: bob},
+			{Domain: "frost.stark", Owner: decimal.NewFromInt(3), Expiry: expired, AddressHash: bob},
+			// cleared by zero address
+			{Domain: "frank.stark", Owner: decimal.NewFromInt(4), Expiry: expiry},
+		} {
+			if err := tx.SaveDomain(ctx, domain); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	tests := []struct {
+		mode           storage.SearchMode
+		query          string
+		includeExpired bool
+		limit, offset  int
+		want           []string
+	}{
+		{storage.SearchModePrefix, "FR", false, 10, 0, []string{"fri.stark", "fricoben.stark"}},
+		{storage.SearchModePrefix, "fr", true, 10, 0, []string{"fri.stark", "frost.stark", "fricoben.stark"}},
+		{storage.SearchModePrefix, "fr", true, 1, 1, []string{"frost.stark"}},
+		{storage.SearchModePrefix, "fr%", true, 10, 0, []string{}},
+		{storage.SearchModeSubstring, "fri", false, 10, 0, []string{"fri.stark", "fricoben.stark", "deployer.fricoben.stark"}},
+		{storage.SearchModeSubstring, "_", true, 10, 0, []string{}},
+		{storage.SearchModeSimilarity, "fricoben", false, 10, 0, []string{"fricoben.stark", "deployer.fricoben.stark"}},
+	}
+	for _, tt := range tests {
+		results, err := backend.Domains.Search(ctx, tt.mode, tt.query, tt.includeExpired, tt.limit, tt.offset)
+		require.NoError(t, err, "%s %s", tt.mode, tt.query)
+
+		names := make([]string, len(results))
+		for i := range results {
+			names[i] = results[i].Domain
+		}
+		require.Equal(t, tt.want, names, "%s %s", tt.mode, tt.query)
+	}
+
+	results, err := backend.Domains.Search(ctx, storage.SearchModePrefix, "fricoben", false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, alice, results[0].Address)
+	require.Equal(t, "1", results[0].StarknetId.String())
+	require.InDelta(t, 8.0/14, results[0].Rank, 1e-6)
+	require.Equal(t, expiry.Unix(), results[0].EffectiveExpiry.Unix())
+
+	results, err = backend.Domains.Search(ctx, storage.SearchModeSimilarity, "fricoben", false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.InDelta(t, 0.6, results[0].Rank, 1e-6)
+	require.Equal(t, "deployer.fricoben.stark", results[1].Domain)
+	require.Equal(t, expiry.Unix(), results[1].EffectiveExpiry.Unix())
+	require.True(t, results[1].Expiry.Before(expired))
+
+	_, err = backend.Domains.Search(ctx, "unknown", "fricoben", false, 10, 0)
+	require.Error(t, err)
+}
+
+func testRollback(t *testing.T, ctx context.Context, backend storage.Backend) {
+	errTest := errors.New("test")
+
+	tx, err := backend.BeginTransaction(ctx)
+	require.NoError(t, err)
+	require.NoError(t, tx.SaveState(ctx, &storage.State{Name: "starknet_id", LastHeight: 100}))
+	require.NoError(t, tx.SaveDomain(ctx, &storage.Domain{Domain: "fricoben.stark", Expiry: expiry}))
+	require.ErrorIs(t, tx.HandleError(ctx, errTest), errTest)
+	require.NoError(t, tx.Close(ctx))
+
+	_, err = backend.State.ByName(ctx, "starknet_id")
+	require.True(t, backend.State.IsNoRows(err))
+
+	count, err := backend.Domains.Count(ctx)
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// the next transaction isn't affected by the rolled back one
+	save(t, ctx, backend, func(tx storage.Transaction) error {
+		return tx.SaveState(ctx, &storage.State{Name: "starknet_id", LastHeight: 101})
+	})
+	state, err := backend.State.ByName(ctx, "starknet_id")
+	require.NoError(t, err)
+	require.EqualValues(t, 101, state.LastHeight)
+}
+
+func testTable(t *testing.T, ctx context.Context, backend storage.Backend) {
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, backend.Subdomains.Save(ctx, &storage.Subdomain{
+			Subdomain:          string(rune('a' + i - 1)),
+			ResolverId:         uint64(i),
+			RegistrationHeight: 100,
+			RegistrationDate:   blockTime,
+		}))
+	}
+
+	subdomain, err := backend.Subdomains.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "b", subdomain.Subdomain)
+
+	subdomain.RegistrationHeight = 200
+	require.NoError(t, backend.Subdomains.Update(ctx, subdomain))
+	updated, err := backend.Subdomains.GetByResolverId(ctx, 2)
+	require.NoError(t, err)
+	require.EqualValues(t, 200, updated.RegistrationHeight)
+
+	list, err := backend.Subdomains.List(ctx, 2, 1, sdk.SortOrderDesc)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.EqualValues(t, 2, list[0].Id)
+	require.EqualValues(t, 1, list[1].Id)
+
+	list, err = backend.Subdomains.CursorList(ctx, 1, 10, sdk.SortOrderAsc, sdk.ComparatorGt)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.EqualValues(t, 2, list[0].Id)
+	require.EqualValues(t, 3, list[1].Id)
+
+	lastId, err := backend.Subdomains.LastID(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, lastId)
+
+	_, err = backend.Subdomains.GetByID(ctx, 10)
+	require.True(t, backend.Subdomains.IsNoRows(err))
+}
+
+func domainNames(domains []storage.Domain) []string {
+	names := make([]string, len(domains))
+	for i := range domains {
+		names[i] = domains[i].Domain
+	}
+	return names
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transaction - atomically saves data of the indexed block. It's implemented by every storage backend.
+type Transaction interface {
+	// SaveState - inserts the state or updates last height and times of the state with the same name
+	SaveState(ctx context.Context, state *State) error
+	// SaveAddress - inserts addresses or updates class id of the addresses with the same id
+	SaveAddress(ctx context.Context, addresses ...*Address) error
+	// SaveStarknetIds - inserts minted starknet ids. Starknet id with the same token id is replaced: mints are replayed after reindex
+	SaveStarknetIds(ctx context.Context, starknetIds ...*StarknetId) error
+	// TransferStarknetId - updates owner of the starknet id if it exists
+	TransferStarknetId(ctx context.Context, starknetId *StarknetId) error
+	// BurnStarknetIds - deletes starknet ids
+	BurnStarknetIds(ctx context.Context, starknetIds ...decimal.Decimal) error
+	// EquipInft - updates equipped iNFT of the starknet id if it exists
+	EquipInft(ctx context.Context, starknetId *StarknetId) error
+	// SaveSubdomain - inserts the subdomain or updates registration and resolver of the subdomain with the same name
+	SaveSubdomain(ctx context.Context, subdomain *Subdomain) error
+	// SaveDomain - inserts the domain or updates the existing one. Zero expiry means owner and expiry are not changed,
+	// empty address hash means address is not changed. If both are zero nothing is saved.
+	// Effective expiry of inserted domain without expiry is taken from its nearest ancestor with expiry.
+	// Expiry is set as effective expiry of the domain and of its descendants without expiry which have no nearer
+	// ancestor with expiry.
+	SaveDomain(ctx context.Context, domain *Domain) error
+	// TransferDomain - updates owner of the domain if it exists
+	TransferDomain(ctx context.Context, domain *Domain) error
+	// ClearDomainAddress - removes address of the domain if it exists. Domain without address doesn't resolve.
+	ClearDomainAddress(ctx context.Context, domain string) error
+	// SaveField - inserts the field or updates values of the field with the same namespace, owner and name
+	SaveField(ctx context.Context, field *Field) error
+
+	Flush(ctx context.Context) error
+	HandleError(ctx context.Context, err error) error
+	Close(ctx context.Context) error
+}
+
+// Transactable -
+type Transactable interface {
+	BeginTransaction(ctx context.Context) (Transaction, error)
+}
+
+// Backend - tables and transactions of the storage backend. Indexing and API work with any backend through it.
+type Backend struct {
+	Transactable
+
+	Addresses   IAddress
+	Domains     IDomain
+	Subdomains  ISubdomain
+	StarknetIds IStarknetId
+	Fields      IField
+	State       IState
+}
@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/pkg/errors"
+)
+
+// Archive is newline-delimited JSON file of messages in the same form as recorded streams. Subscription ids aren't
+// written. Files with `.gz` extension are compressed by gzip.
+
+// IsCompressed - returns true if archive file is compressed
+func IsCompressed(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".gz")
+}
+
+// Writer - writes messages to archive file
+type Writer struct {
+	file    *os.File
+	gzip    *gzip.Writer
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// Create - creates archive file or truncates existing one
+func Create(path string) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		file: file,
+	}
+	var output io.Writer = file
+	if IsCompressed(path) {
+		w.gzip = gzip.NewWriter(file)
+		output = w.gzip
+	}
+	w.buf = bufio.NewWriter(output)
+	w.encoder = json.NewEncoder(w.buf)
+	return w, nil
+}
+
+// Write - writes message as a line of the archive
+func (w *Writer) Write(msg *pb.Subscription) error {
+	m, err := NewMessage(msg)
+	if err != nil {
+		return err
+	}
+	m.Subscription = 0
+	return w.encoder.Encode(m)
+}
+
+// Flush - writes buffered messages to the file, so the archive can be read up to the last written message
+func (w *Writer) Flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if w.gzip != nil {
+		if err := w.gzip.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close - flushes buffered messages and closes the file
+func (w *Writer) Close() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if w.gzip != nil {
+		if err := w.gzip.Close(); err != nil {
+			return err
+		}
+	}
+	return w.file.Close()
+}
+
+// Reader - reads messages of archive file
+type Reader struct {
+	file    *os.File
+	gzip    *gzip.Reader
+	decoder *json.Decoder
+	count   int
+}
+
+// Open - opens archive file
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reader{
+		file: file,
+	}
+	var input io.Reader = bufio.NewReader(file)
+	if IsCompressed(path) {
+		r.gzip, err = gzip.NewReader(input)
+		if err != nil {
+			_ = file.Close()
+			return nil, errors.Wrap(err, path)
+		}
+		input = r.gzip
+	}
+	r.decoder = json.NewDecoder(input)
+	return r, nil
+}
+
+// Next - returns the next message. io.EOF is returned at the end of the archive.
+func (r *Reader) Next() (*pb.Subscription, error) {
+	var m Message
+	if err := r.decoder.Decode(&m); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, errors.Wrapf(err, "message %d", r.count)
+	}
+	msg, err := m.Proto()
+	if err != nil {
+		return nil, errors.Wrapf(err, "message %d", r.count)
+	}
+	r.count++
+	return msg, nil
+}
+
+// Close - closes the file
+func (r *Reader) Close() error {
+	if r.gzip != nil {
+		if err := r.gzip.Close(); err != nil {
+			return err
+		}
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "stream.yml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(testYAML), 0o644))
+
+	messages, err := Load(yamlPath)
+	require.NoError(t, err)
+
+	for _, name := range []string{"events.ndjson", "events.ndjson.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+
+			w, err := Create(path)
+			require.NoError(t, err)
+			for i := range messages {
+				require.NoError(t, w.Write(messages[i]))
+			}
+			require.NoError(t, w.Close())
+
+			r, err := Open(path)
+			require.NoError(t, err)
+			defer r.Close()
+
+			for i := range messages {
+				msg, err := r.Next()
+				require.NoError(t, err)
+				require.Zero(t, msg.GetResponse().GetId(), "subscription ids aren't archived")
+
+				msg.Response = messages[i].Response
+				requireEqualMessages(t, messages[i], msg)
+			}
+			_, err = r.Next()
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestArchive_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte(`{"end_of_block":{"height":1}}`+"\n"+`{}`+"\n"), 0o644))
+
+	r, err := Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	msg, err := r.Next()
+	require.NoError(t, err)
+	require.EqualValues(t, 1, msg.GetEndOfBlock().GetHeight())
+
+	_, err = r.Next()
+	require.ErrorContains(t, err, "message 1")
+}
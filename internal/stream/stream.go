@@ -0,0 +1,226 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	generalPB "github.com/dipdup-net/indexer-sdk/pkg/modules/grpc/pb"
+	"github.com/pkg/errors"
+)
+
+// Message - human-readable form of the subscription message. Exactly one of the fields except subscription is set.
+type Message struct {
+	// Subscription - id of the subscription which received the message
+	Subscription uint64      `json:"subscription,omitempty"`
+	Block        *Block      `json:"block,omitempty"`
+	Event        *Event      `json:"event,omitempty"`
+	Address      *Address    `json:"address,omitempty"`
+	EndOfBlock   *EndOfBlock `json:"end_of_block,omitempty"`
+}
+
+// Block -
+type Block struct {
+	Id     uint64 `json:"id,omitempty"`
+	Height uint64 `json:"height"`
+	Time   uint64 `json:"time"`
+}
+
+// Event - event with decoded data as object instead of serialized bytes
+type Event struct {
+	Id         uint64          `json:"id,omitempty"`
+	Height     uint64          `json:"height"`
+	Time       uint64          `json:"time"`
+	Order      uint64          `json:"order,omitempty"`
+	Name       string          `json:"name"`
+	Contract   *Address        `json:"contract,omitempty"`
+	From       *Address        `json:"from,omitempty"`
+	Keys       []string        `json:"keys,omitempty"`
+	Data       []string        `json:"data,omitempty"`
+	ParsedData json.RawMessage `json:"parsed_data,omitempty"`
+}
+
+// Address -
+type Address struct {
+	Id      uint64  `json:"id"`
+	Hash    Hex     `json:"hash"`
+	ClassId *uint64 `json:"class_id,omitempty"`
+	Height  uint64  `json:"height,omitempty"`
+}
+
+// EndOfBlock -
+type EndOfBlock struct {
+	Height uint64 `json:"height"`
+}
+
+// Hex - bytes which are written as 0x-prefixed hex string
+type Hex []byte
+
+// MarshalJSON -
+func (h Hex) MarshalJSON() ([]byte, error) {
+	return json.Marshal("0x" + hex.EncodeToString(h))
+}
+
+// UnmarshalJSON -
+func (h *Hex) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return errors.Wrapf(err, "decode hex: %s", s)
+	}
+	*h = decoded
+	return nil
+}
+
+// NewMessage - converts subscription message to its readable form
+func NewMessage(msg *pb.Subscription) (Message, error) {
+	m := Message{
+		Subscription: msg.GetResponse().GetId(),
+	}
+	switch {
+	case msg.Block != nil:
+		m.Block = &Block{
+			Id:     msg.Block.Id,
+			Height: msg.Block.Height,
+			Time:   msg.Block.Time,
+		}
+	case msg.Event != nil:
+		m.Event = &Event{
+			Id:       msg.Event.Id,
+			Height:   msg.Event.Height,
+			Time:     msg.Event.Time,
+			Order:    msg.Event.Order,
+			Name:     msg.Event.Name,
+			Contract: newAddress(msg.Event.Contract),
+			From:     newAddress(msg.Event.From),
+			Keys:     msg.Event.Keys,
+			Data:     msg.Event.Data,
+		}
+		if len(msg.Event.ParsedData) > 0 {
+			if !json.Valid(msg.Event.ParsedData) {
+				return m, errors.Errorf("invalid parsed data of event %d", msg.Event.Id)
+			}
+			m.Event.ParsedData = json.RawMessage(msg.Event.ParsedData)
+		}
+	case msg.Address != nil:
+		m.Address = newAddress(msg.Address)
+	case msg.EndOfBlock != nil:
+		m.EndOfBlock = &EndOfBlock{
+			Height: msg.EndOfBlock.Height,
+		}
+	default:
+		return m, errors.New("unsupported subscription message")
+	}
+	return m, nil
+}
+
+func newAddress(address *pb.Address) *Address {
+	if address == nil {
+		return nil
+	}
+	return &Address{
+		Id:      address.Id,
+		Hash:    address.Hash,
+		ClassId: address.ClassId,
+		Height:  address.Height,
+	}
+}
+
+// Proto - converts message to the form received from the indexer
+func (m Message) Proto() (*pb.Subscription, error) {
+	msg := &pb.Subscription{
+		Response: &generalPB.SubscribeResponse{
+			Id: m.Subscription,
+		},
+	}
+	switch {
+	case m.Block != nil:
+		msg.Block = &pb.Block{
+			Id:     m.Block.Id,
+			Height: m.Block.Height,
+			Time:   m.Block.Time,
+		}
+	case m.Event != nil:
+		msg.Event = &pb.Event{
+			Id:       m.Event.Id,
+			Height:   m.Event.Height,
+			Time:     m.Event.Time,
+			Order:    m.Event.Order,
+			Name:     m.Event.Name,
+			Contract: m.Event.Contract.pb(),
+			From:     m.Event.From.pb(),
+			Keys:     m.Event.Keys,
+			Data:     m.Event.Data,
+		}
+		if len(m.Event.ParsedData) > 0 {
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, m.Event.ParsedData); err != nil {
+				return nil, errors.Wrapf(err, "parsed data of event %d", m.Event.Id)
+			}
+			msg.Event.ParsedData = compacted.Bytes()
+		}
+	case m.Address != nil:
+		msg.Address = m.Address.pb()
+	case m.EndOfBlock != nil:
+		msg.EndOfBlock = &pb.EndOfBlock{
+			Height: m.EndOfBlock.Height,
+		}
+	default:
+		return nil, errors.New("empty message")
+	}
+	return msg, nil
+}
+
+func (a *Address) pb() *pb.Address {
+	if a == nil {
+		return nil
+	}
+	return &pb.Address{
+		Id:      a.Id,
+		Hash:    a.Hash,
+		ClassId: a.ClassId,
+		Height:  a.Height,
+	}
+}
+
+// Load - reads recorded stream from JSON or YAML file. Format is chosen by file extension.
+func Load(path string) ([]*pb.Subscription, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(raw, &messages)
+	case ".yml", ".yaml":
+		messages, err = parseYAML(raw)
+	default:
+		return nil, errors.Errorf("unknown stream format: %s", ext)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, path)
+	}
+
+	result := make([]*pb.Subscription, len(messages))
+	for i := range messages {
+		msg, err := messages[i].Proto()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: message %d", path, i)
+		}
+		result[i] = msg
+	}
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dipdup-io/starknet-indexer/pkg/grpc/pb"
+	"github.com/stretchr/testify/require"
+)
+
+const testYAML = `
+- subscription: 1
+  block: {height: 100, time: 1700000000}
+- subscription: 1
+  event:
+    id: 10
+    height: 100
+    time: 1700000000
+    order: 2
+    name: starknet_id_update
+    contract:
+      id: 1
+      hash: 0x05dbdedc203e92749e2e746e2d40a768d966bd243df04a6b712e222bc040a9af
+    parsed_data:
+      domain_len: 0x1
+      domain: [0x15d246f6c1b]
+      owner: 0x1
+      expiry: 0x64b3b2d0
+- subscription: 1
+  address: {id: 16, hash: 0x327d34747122d7a40f4670265b098757270a449ec80c4871450fffdab7c2fa8, class_id: 3, height: 100}
+- subscription: 1
+  end_of_block: {height: 100}
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "stream.yml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(testYAML), 0o644))
+
+	messages, err := Load(yamlPath)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+
+	require.EqualValues(t, 1, messages[0].GetResponse().GetId())
+	require.EqualValues(t, 100, messages[0].Block.Height)
+
+	event := messages[1].Event
+	require.NotNil(t, event)
+	require.EqualValues(t, 10, event.Id)
+	require.EqualValues(t, 2, event.Order)
+	require.Equal(t, "starknet_id_update", event.Name)
+	require.EqualValues(t, 1, event.Contract.Id)
+	require.Len(t, event.Contract.Hash, 32)
+	require.JSONEq(t, `{"domain_len":"0x1","domain":["0x15d246f6c1b"],"owner":"0x1","expiry":"0x64b3b2d0"}`, string(event.ParsedData))
+
+	address := messages[2].Address
+	require.NotNil(t, address)
+	require.EqualValues(t, 16, address.Id)
+	require.Len(t, address.Hash, 32)
+	require.EqualValues(t, 0x03, address.Hash[0])
+	require.NotNil(t, address.ClassId)
+	require.EqualValues(t, 3, *address.ClassId)
+
+	require.EqualValues(t, 100, messages[3].EndOfBlock.Height)
+
+	t.Run("json", func(t *testing.T) {
+		records := make([]Message, len(messages))
+		for i := range messages {
+			records[i], err = NewMessage(messages[i])
+			require.NoError(t, err)
+		}
+		jsonPath := filepath.Join(dir, "stream.json")
+		writeJSON(t, jsonPath, records)
+
+		fromJSON, err := Load(jsonPath)
+		require.NoError(t, err)
+		require.Len(t, fromJSON, len(messages))
+		for i := range messages {
+			requireEqualMessages(t, messages[i], fromJSON[i])
+		}
+	})
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"unknown format", "stream.txt", "[]"},
+		{"empty message", "stream.yml", "- subscription: 1"},
+		{"invalid hex", "stream.json", `[{"address":{"id":1,"hash":"0xzz"}}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			_, err := Load(path)
+			require.Error(t, err)
+		})
+	}
+}
+
+func writeJSON(t *testing.T, path string, messages []Message) {
+	t.Helper()
+	raw, err := json.MarshalIndent(messages, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, raw, 0o644))
+}
+
+func requireEqualMessages(t *testing.T, expected, actual *pb.Subscription) {
+	t.Helper()
+	expectedMessage, err := NewMessage(expected)
+	require.NoError(t, err)
+	actualMessage, err := NewMessage(actual)
+	require.NoError(t, err)
+	require.Equal(t, expectedMessage, actualMessage)
+}
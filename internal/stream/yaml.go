@@ -0,0 +1,84 @@
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// parseYAML - decodes YAML through JSON, so both formats share the same field names. Felts are usually written
+// as plain 0x-prefixed scalars which YAML resolves to integers, so scalars are converted by their source text.
+func parseYAML(raw []byte) ([]Message, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	value, err := yamlValue(node.Content[0])
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	err = json.Unmarshal(data, &messages)
+	return messages, err
+}
+
+func yamlValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.SequenceNode:
+		result := make([]any, len(node.Content))
+		for i := range node.Content {
+			value, err := yamlValue(node.Content[i])
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	case yaml.MappingNode:
+		result := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			result[node.Content[i].Value] = value
+		}
+		return result, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool":
+			var b bool
+			err := node.Decode(&b)
+			return b, err
+		case "!!int", "!!float":
+			if strings.HasPrefix(strings.TrimLeft(node.Value, "+-"), "0x") {
+				return node.Value, nil
+			}
+			return json.Number(node.Value), nil
+		default:
+			return node.Value, nil
+		}
+	default:
+		return nil, errors.Errorf("unknown YAML node kind at line %d", node.Line)
+	}
+}